@@ -65,6 +65,17 @@ func NodeHasDefaultEngineImage(m *manager.VolumeManager) func(req *http.Request)
 	}
 }
 
+func OwnerIDFromInstanceManager(m *manager.VolumeManager) func(req *http.Request) (string, error) {
+	return func(req *http.Request) (string, error) {
+		name := mux.Vars(req)["name"]
+		im, err := m.GetInstanceManager(name)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get instance manager '%s'", name)
+		}
+		return im.Status.OwnerID, nil
+	}
+}
+
 func OwnerIDFromNode(m *manager.VolumeManager) func(req *http.Request) (string, error) {
 	return func(req *http.Request) (string, error) {
 		id := mux.Vars(req)["name"]
@@ -62,7 +62,26 @@ func (s *Server) InstanceManagerGet(rw http.ResponseWriter, req *http.Request) e
 		return err
 	}
 
-	apiContext.Write(toInstanceManagerResource(im))
+	apiContext.Write(toInstanceManagerResource(im, apiContext))
+	return nil
+}
+
+// InstanceManagerRefresh polls the instances of the instance manager right away. The request is forwarded to the node
+// owning the instance manager.
+func (s *Server) InstanceManagerRefresh(rw http.ResponseWriter, req *http.Request) error {
+	id := mux.Vars(req)["name"]
+	apiContext := api.GetApiContext(req)
+
+	if err := s.imc.RefreshInstanceManager(id); err != nil {
+		return errors.Wrapf(err, "failed to refresh instance manager %v", id)
+	}
+
+	im, err := s.m.GetInstanceManager(id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get instance manager %v", id)
+	}
+
+	apiContext.Write(toInstanceManagerResource(im, apiContext))
 	return nil
 }
 
@@ -74,6 +93,18 @@ func (s *Server) InstanceManagerList(rw http.ResponseWriter, req *http.Request)
 		return errors.Wrap(err, "failed to list instance managers")
 	}
 
-	apiContext.Write(toInstanceManagerCollection(instanceManagers))
+	apiContext.Write(toInstanceManagerCollection(instanceManagers, apiContext))
+	return nil
+}
+
+func (s *Server) InstanceProcessInfoList(rw http.ResponseWriter, req *http.Request) error {
+	apiContext := api.GetApiContext(req)
+
+	processes, err := s.m.ListAllInstanceProcesses()
+	if err != nil {
+		return errors.Wrap(err, "failed to list instance processes of all instance managers")
+	}
+
+	apiContext.Write(toInstanceProcessInfoCollection(processes))
 	return nil
 }
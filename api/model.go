@@ -536,6 +536,16 @@ type InstanceManager struct {
 	Instances map[string]longhorn.InstanceProcess `json:"instances"`
 }
 
+type InstanceProcessInfo struct {
+	client.Resource
+	Name                string                   `json:"name"`
+	InstanceManagerName string                   `json:"instanceManagerName"`
+	InstanceManagerType string                   `json:"instanceManagerType"`
+	NodeID              string                   `json:"nodeID"`
+	DataEngine          string                   `json:"dataEngine"`
+	Process             longhorn.InstanceProcess `json:"process"`
+}
+
 type RecurringJob struct {
 	client.Resource
 	longhorn.RecurringJobSpec
@@ -633,8 +643,9 @@ func NewSchema() *client.Schemas {
 
 	schemas.AddType("tag", Tag{})
 
-	schemas.AddType("instanceManager", InstanceManager{})
+	instanceManagerSchema(schemas.AddType("instanceManager", InstanceManager{}))
 	schemas.AddType("instanceProcess", longhorn.InstanceProcess{})
+	schemas.AddType("instanceProcessInfo", InstanceProcessInfo{})
 
 	schemas.AddType("backingImageDiskFileStatus", longhorn.BackingImageDiskFileStatus{})
 	schemas.AddType("backingImageCleanupInput", BackingImageCleanupInput{})
@@ -663,6 +674,17 @@ func NewSchema() *client.Schemas {
 	return schemas
 }
 
+func instanceManagerSchema(instanceManager *client.Schema) {
+	instanceManager.CollectionMethods = []string{"GET"}
+	instanceManager.ResourceMethods = []string{"GET"}
+
+	instanceManager.ResourceActions = map[string]client.Action{
+		"refresh": {
+			Output: "instanceManager",
+		},
+	}
+}
+
 func nodeSchema(node *client.Schema) {
 	node.CollectionMethods = []string{"GET"}
 	node.ResourceMethods = []string{"GET", "PUT"}
@@ -1942,13 +1964,15 @@ func toBackingImageCollection(bis []*longhorn.BackingImage, apiContext *api.ApiC
 type Server struct {
 	m   *manager.VolumeManager
 	wsc *controller.WebsocketController
+	imc *controller.InstanceManagerController
 	fwd *Fwd
 }
 
-func NewServer(m *manager.VolumeManager, wsc *controller.WebsocketController) *Server {
+func NewServer(m *manager.VolumeManager, wsc *controller.WebsocketController, imc *controller.InstanceManagerController) *Server {
 	s := &Server{
 		m:   m,
 		wsc: wsc,
+		imc: imc,
 		fwd: NewFwd(m),
 	}
 	return s
@@ -2142,11 +2166,12 @@ func toTagCollection(tags []string, tagType string, apiContext *api.ApiContext)
 	return &client.GenericCollection{Data: data, Collection: client.Collection{ResourceType: "tag"}}
 }
 
-func toInstanceManagerResource(im *longhorn.InstanceManager) *InstanceManager {
-	return &InstanceManager{
+func toInstanceManagerResource(im *longhorn.InstanceManager, apiContext *api.ApiContext) *InstanceManager {
+	res := &InstanceManager{
 		Resource: client.Resource{
-			Id:   im.Name,
-			Type: "instanceManager",
+			Id:      im.Name,
+			Type:    "instanceManager",
+			Actions: map[string]string{},
 		},
 		CurrentState:     im.Status.CurrentState,
 		Image:            im.Spec.Image,
@@ -2158,16 +2183,40 @@ func toInstanceManagerResource(im *longhorn.InstanceManager) *InstanceManager {
 		InstanceReplicas: im.Status.InstanceReplicas,
 		Instances:        im.Status.Instances,
 	}
+	res.Actions = map[string]string{
+		"refresh": apiContext.UrlBuilder.ActionLink(res.Resource, "refresh"),
+	}
+	return res
 }
 
-func toInstanceManagerCollection(instanceManagers map[string]*longhorn.InstanceManager) *client.GenericCollection {
+func toInstanceManagerCollection(instanceManagers map[string]*longhorn.InstanceManager, apiContext *api.ApiContext) *client.GenericCollection {
 	var data []interface{}
 	for _, im := range instanceManagers {
-		data = append(data, toInstanceManagerResource(im))
+		data = append(data, toInstanceManagerResource(im, apiContext))
 	}
 	return &client.GenericCollection{Data: data, Collection: client.Collection{ResourceType: "instanceManager"}}
 }
 
+func toInstanceProcessInfoCollection(processes []datastore.InstanceProcessInfo) *client.GenericCollection {
+	var data []interface{}
+	for _, p := range processes {
+		data = append(data, &InstanceProcessInfo{
+			Resource: client.Resource{
+				// The instance may be hosted by multiple instance managers, for example during the migration
+				Id:   p.InstanceManagerName + "-" + p.Process.Spec.Name,
+				Type: "instanceProcessInfo",
+			},
+			Name:                p.Process.Spec.Name,
+			InstanceManagerName: p.InstanceManagerName,
+			InstanceManagerType: string(p.InstanceManagerType),
+			NodeID:              p.NodeID,
+			DataEngine:          string(p.DataEngine),
+			Process:             p.Process,
+		})
+	}
+	return &client.GenericCollection{Data: data, Collection: client.Collection{ResourceType: "instanceProcessInfo"}}
+}
+
 func toRecurringJobResource(recurringJob *longhorn.RecurringJob, apiContext *api.ApiContext) *RecurringJob {
 	return &RecurringJob{
 		Resource: client.Resource{
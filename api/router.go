@@ -54,6 +54,8 @@ func NewRouter(s *Server) *mux.Router {
 	versionHandler := api.VersionHandler(schemas, "v1")
 	r.Methods("GET").Path("/").Handler(versionsHandler)
 	r.Methods("GET").Path("/metrics").Handler(registry.Handler())
+	r.Methods("GET").Path("/v1/healthz/instancemanagerwatches").Handler(s.imc.InstanceManagerWatchHealthHandler())
+	r.Methods("GET").Path("/v1/instancemanagerprocesses").Handler(s.imc.InstanceManagerProcessListHandler())
 	r.Methods("GET").Path("/v1").Handler(versionHandler)
 	r.Methods("GET").Path("/v1/apiversions").Handler(versionsHandler)
 	r.Methods("GET").Path("/v1/apiversions/v1").Handler(versionHandler)
@@ -155,6 +157,9 @@ func NewRouter(s *Server) *mux.Router {
 
 	r.Methods("GET").Path("/v1/instancemanagers").Handler(f(schemas, s.InstanceManagerList))
 	r.Methods("GET").Path("/v1/instancemanagers/{name}").Handler(f(schemas, s.InstanceManagerGet))
+	r.Methods("POST").Path("/v1/instancemanagers/{name}").Queries("action", "refresh").Handler(f(schemas,
+		s.fwd.Handler(s.fwd.HandleProxyRequestByNodeID, s.fwd.GetHTTPAddressByNodeID(OwnerIDFromInstanceManager(s.m)), s.InstanceManagerRefresh)))
+	r.Methods("GET").Path("/v1/instanceprocessinfos").Handler(f(schemas, s.InstanceProcessInfoList))
 
 	r.Methods("GET").Path("/v1/backingimages").Handler(f(schemas, s.BackingImageList))
 	r.Methods("GET").Path("/v1/backingimages/{name}").Handler(f(schemas, s.BackingImageGet))
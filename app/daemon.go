@@ -176,7 +176,7 @@ func startManager(c *cli.Context) error {
 
 	proxyConnCounter := util.NewAtomicCounter()
 
-	wsc, err := controller.StartControllers(logger, clients,
+	wsc, imc, err := controller.StartControllers(logger, clients,
 		currentNodeID, serviceAccount, managerImage, backingImageManagerImage, shareManagerImage,
 		kubeconfigPath, meta.Version, proxyConnCounter)
 	if err != nil {
@@ -209,7 +209,7 @@ func startManager(c *cli.Context) error {
 		return err
 	}
 
-	server := api.NewServer(m, wsc)
+	server := api.NewServer(m, wsc, imc)
 	router := http.Handler(api.NewRouter(server))
 	router = util.FilteredLoggingHandler(map[string]struct{}{
 		"/v1/apiversions":  {},
@@ -45,6 +45,7 @@ const (
 	EventReasonEvictionUserRequested = "EvictionUserRequested"
 	EventReasonEvictionCanceled      = "EvictionCanceled"
 	EventReasonEvictionFailed        = "EvictionFailed"
+	EventReasonEvicted               = "Evicted"
 
 	EventReasonDetachedUnexpectedly = "DetachedUnexpectedly"
 	EventReasonRemount              = "Remount"
@@ -58,6 +59,17 @@ const (
 
 	EventReasonFailedSnapshotDataIntegrityCheck = "FailedSnapshotDataIntegrityCheck"
 
+	EventReasonNodeRebooted = "NodeRebooted"
+
+	EventReasonExpiredInstanceUpdate = "ExpiredInstanceUpdate"
+	EventReasonDuplicateInstance     = "DuplicateInstance"
+
+	EventReasonProgressing  = "Progressing"
+	EventReasonStateChanged = "StateChanged"
+	EventReasonOwnerChanged = "OwnerChanged"
+	EventReasonPaused       = "Paused"
+	EventReasonStopping     = "Stopping"
+
 	EventReasonFailed   = "Failed"
 	EventReasonReady    = "Ready"
 	EventReasonUploaded = "Uploaded"
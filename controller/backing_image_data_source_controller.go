@@ -3,6 +3,7 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/kubernetes/pkg/controller"
+	"k8s.io/utils/clock"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -29,6 +31,7 @@ import (
 
 	bimtypes "github.com/longhorn/backing-image-manager/pkg/types"
 
+	"github.com/longhorn/longhorn-manager/constant"
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/types"
@@ -61,6 +64,8 @@ type BackingImageDataSourceController struct {
 	lock       *sync.RWMutex
 	monitorMap map[string]chan struct{}
 
+	preparationTracker *backingImageDataSourcePreparationTracker
+
 	proxyConnCounter util.Counter
 }
 
@@ -74,6 +79,9 @@ type BackingImageDataSourceMonitor struct {
 	log          *logrus.Entry
 	ds           *datastore.DataStore
 	backoff      *flowcontrol.Backoff
+
+	eventRecorder      record.EventRecorder
+	preparationTracker *backingImageDataSourcePreparationTracker
 }
 
 func NewBackingImageDataSourceController(
@@ -107,6 +115,8 @@ func NewBackingImageDataSourceController(
 		lock:       &sync.RWMutex{},
 		monitorMap: map[string]chan struct{}{},
 
+		preparationTracker: newBackingImageDataSourcePreparationTracker(clock.RealClock{}),
+
 		proxyConnCounter: proxyConnCounter,
 	}
 
@@ -269,6 +279,7 @@ func (c *BackingImageDataSourceController) syncBackingImageDataSource(key string
 	}
 
 	if bids.DeletionTimestamp != nil {
+		c.preparationTracker.forget(bids.Name)
 		if err := c.cleanup(bids); err != nil {
 			return err
 		}
@@ -302,7 +313,10 @@ func (c *BackingImageDataSourceController) syncBackingImageDataSource(key string
 			err = nil
 		}
 		if err == nil && !reflect.DeepEqual(existingBIDS.Status, bids.Status) {
-			_, err = c.ds.UpdateBackingImageDataSourceStatus(bids)
+			if _, err = c.ds.UpdateBackingImageDataSourceStatus(bids); err == nil {
+				recordBackingImageDataSourceEvents(c.eventRecorder, existingBIDS, bids)
+				c.preparationTracker.record(existingBIDS, bids)
+			}
 		}
 		if apierrors.IsConflict(errors.Cause(err)) {
 			log.WithError(err).Debugf("Requeue %v due to conflict", key)
@@ -320,6 +334,10 @@ func (c *BackingImageDataSourceController) syncBackingImageDataSource(key string
 		return c.cleanup(bids)
 	}
 
+	if bids.Spec.Cancel {
+		return c.handleCancellation(bids)
+	}
+
 	node, diskName, err := c.ds.GetReadyDiskNode(bids.Spec.DiskUUID)
 	if err != nil && !types.ErrorIsNotFound(err) {
 		return err
@@ -368,6 +386,21 @@ func (c *BackingImageDataSourceController) cleanup(bids *longhorn.BackingImageDa
 	return nil
 }
 
+// handleCancellation stops the file preparation of the canceled backing image data source without recreating the pod.
+// The state is set to failed, then the backing image manager cleans up the partial file and updates the state to
+// failed-and-cleanup.
+func (c *BackingImageDataSourceController) handleCancellation(bids *longhorn.BackingImageDataSource) error {
+	if bids.Status.CurrentState != longhorn.BackingImageStateFailed &&
+		bids.Status.CurrentState != longhorn.BackingImageStateFailedAndCleanUp {
+		getLoggerForBackingImageDataSource(c.logger, bids).Infof("Canceling the file preparation in state %v", bids.Status.CurrentState)
+		bids.Status.CurrentState = longhorn.BackingImageStateFailed
+		bids.Status.Message = fmt.Sprintf("the file preparation with data source type %v is canceled", bids.Spec.SourceType)
+	}
+	c.backoff.DeleteEntry(bids.Name)
+
+	return c.cleanup(bids)
+}
+
 func (c *BackingImageDataSourceController) syncBackingImage(bids *longhorn.BackingImageDataSource) (err error) {
 	// TODO: HA backing image
 	bi, err := c.ds.GetBackingImage(bids.Name)
@@ -520,6 +553,18 @@ func (c *BackingImageDataSourceController) syncBackingImageDataSourcePod(bids *l
 			bids.Status.Message = ""
 			bids.Status.Progress = 0
 			bids.Status.Checksum = ""
+			// There is no need to launch the pod if the disk or the parameters are invalid.
+			err := c.validateBackingImageDataSourceDisk(bids)
+			if err == nil {
+				err = c.validateBackingImageDataSourceParameters(bids)
+			}
+			if err != nil {
+				log.WithError(err).Error("Failed to validate backing image data source")
+				bids.Status.Message = err.Error()
+				bids.Status.CurrentState = longhorn.BackingImageStateFailed
+				c.backoff.Next(bids.Name, time.Now())
+				return nil
+			}
 			if err := c.createBackingImageDataSourcePod(bids); err != nil {
 				return err
 			}
@@ -531,6 +576,54 @@ func (c *BackingImageDataSourceController) syncBackingImageDataSourcePod(bids *l
 	return nil
 }
 
+// validateBackingImageDataSourceDisk verifies that the disk UUID and the disk path of the data source refer to the same disk of the node
+func (c *BackingImageDataSourceController) validateBackingImageDataSourceDisk(bids *longhorn.BackingImageDataSource) error {
+	node, err := c.ds.GetNodeRO(bids.Spec.NodeID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get node %v for backing image data source", bids.Spec.NodeID)
+	}
+
+	for diskName, diskStatus := range node.Status.DiskStatus {
+		if diskStatus.DiskUUID != bids.Spec.DiskUUID {
+			continue
+		}
+		diskSpec, exists := node.Spec.Disks[diskName]
+		if !exists {
+			return fmt.Errorf("disk %v with UUID %v is not in the spec of node %v", diskName, bids.Spec.DiskUUID, node.Name)
+		}
+		if filepath.Clean(diskSpec.Path) != filepath.Clean(bids.Spec.DiskPath) {
+			return fmt.Errorf("disk path %v does not match path %v of disk %v with UUID %v on node %v",
+				bids.Spec.DiskPath, diskSpec.Path, diskName, bids.Spec.DiskUUID, node.Name)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("disk with UUID %v is not found on node %v", bids.Spec.DiskUUID, node.Name)
+}
+
+func (c *BackingImageDataSourceController) validateBackingImageDataSourceParameters(bids *longhorn.BackingImageDataSource) error {
+	switch bids.Spec.SourceType {
+	case longhorn.BackingImageDataSourceTypeDownload:
+		// The URL is forwarded to the HTTP client of the data source pod as is
+		return types.ValidateBackingImageDownloadParameters(bids.Spec.Parameters)
+	case longhorn.BackingImageDataSourceTypeExportFromVolume:
+		// The file is exported from the current volume data via a new snapshot unless a snapshot is specified
+		snapshotName := bids.Spec.Parameters[longhorn.DataSourceTypeExportFromVolumeParameterSnapshotName]
+		if snapshotName == "" {
+			return nil
+		}
+		volumeName := bids.Spec.Parameters[longhorn.DataSourceTypeExportFromVolumeParameterVolumeName]
+		snapshot, err := c.ds.GetSnapshotRO(snapshotName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get snapshot %v of volume %v for exporting", snapshotName, volumeName)
+		}
+		if snapshot.Spec.Volume != volumeName {
+			return fmt.Errorf("snapshot %v for exporting does not belong to volume %v", snapshotName, volumeName)
+		}
+	}
+	return nil
+}
+
 // handleAttachmentTicketDeletion check and delete attachment so that the source volume is detached if needed
 func (c *BackingImageDataSourceController) handleAttachmentTicketDeletion(bids *longhorn.BackingImageDataSource) (err error) {
 	if bids.Spec.SourceType != longhorn.BackingImageDataSourceTypeExportFromVolume {
@@ -819,10 +912,12 @@ func (c *BackingImageDataSourceController) prepareRunningParameters(bids *longho
 	}
 
 	newSnapshotRequired := true
-	if bids.Status.RunningParameters[longhorn.DataSourceTypeExportFromVolumeParameterSnapshotName] != "" {
-		if _, ok := e.Status.Snapshots[bids.Status.RunningParameters[longhorn.DataSourceTypeExportFromVolumeParameterSnapshotName]]; ok {
-			newSnapshotRequired = false
+	if snapshotName := bids.Status.RunningParameters[longhorn.DataSourceTypeExportFromVolumeParameterSnapshotName]; snapshotName != "" {
+		// Do not silently fall back to exporting the current volume data if the specified snapshot is gone
+		if _, ok := e.Status.Snapshots[snapshotName]; !ok {
+			return fmt.Errorf("snapshot %v specified for exporting is not found in the current engine %v", snapshotName, e.Name)
 		}
+		newSnapshotRequired = false
 	}
 	if newSnapshotRequired {
 		engineClientProxy, err := c.getEngineClientProxy(e)
@@ -1074,6 +1169,9 @@ func (c *BackingImageDataSourceController) startMonitoring(bids *longhorn.Backin
 		log:          log,
 		ds:           c.ds,
 		backoff:      c.backoff,
+
+		eventRecorder:      c.eventRecorder,
+		preparationTracker: c.preparationTracker,
 	}
 	c.monitorMap[bids.Name] = stopCh
 
@@ -1123,6 +1221,11 @@ func (m *BackingImageDataSourceMonitor) sync() {
 		m.log.Warnf("Stopped monitoring since backing image data source %v current IP is empty", m.Name)
 		return
 	}
+	// The controller stops the monitor for the cancellation, which should not be overwritten by the file state
+	if bids.Spec.Cancel {
+		m.log.Debugf("Skipped syncing the file state since backing image data source %v is canceled", m.Name)
+		return
+	}
 
 	fileInfo, err := m.client.Get()
 	if err != nil {
@@ -1141,12 +1244,17 @@ func (m *BackingImageDataSourceMonitor) sync() {
 	bids.Status.Progress = fileInfo.Progress
 	bids.Status.Checksum = fileInfo.CurrentChecksum
 	bids.Status.Message = fileInfo.Message
+	if !verifyBackingImageDataSourceChecksum(bids) {
+		m.log.Errorf("Failed to verify the file for backing image data source, error message: %v", bids.Status.Message)
+	}
 	if !reflect.DeepEqual(bids.Status, existingBIDS.Status) {
 		if _, err := m.ds.UpdateBackingImageDataSourceStatus(bids); err != nil {
 			syncErr = errors.Wrapf(err, "failed to get %v info from backing image data source server", m.Name)
 			m.log.Error(syncErr)
 			return
 		}
+		recordBackingImageDataSourceEvents(m.eventRecorder, existingBIDS, bids)
+		m.preparationTracker.record(existingBIDS, bids)
 	}
 
 	if bids.Status.CurrentState == longhorn.BackingImageStateReady || bids.Status.CurrentState == longhorn.BackingImageStateReadyForTransfer {
@@ -1154,6 +1262,54 @@ func (m *BackingImageDataSourceMonitor) sync() {
 	}
 }
 
+// verifyBackingImageDataSourceChecksum marks the backing image data source as failed
+// if the file exported from the volume does not match the expected checksum.
+// The verification is skipped when the spec checksum is empty.
+func verifyBackingImageDataSourceChecksum(bids *longhorn.BackingImageDataSource) bool {
+	if bids.Spec.SourceType != longhorn.BackingImageDataSourceTypeExportFromVolume {
+		return true
+	}
+	if bids.Spec.Checksum == "" || bids.Status.Progress != 100 || bids.Status.Checksum == "" {
+		return true
+	}
+	if bids.Status.Checksum == bids.Spec.Checksum {
+		return true
+	}
+
+	bids.Status.CurrentState = longhorn.BackingImageStateFailed
+	bids.Status.Message = fmt.Sprintf("the checksum %v of the file exported from volume %v does not match the expected checksum %v",
+		bids.Status.Checksum, bids.Spec.Parameters[longhorn.DataSourceTypeExportFromVolumeParameterVolumeName], bids.Spec.Checksum)
+	return false
+}
+
+// backingImageDataSourceProgressMilestones are the progress percentages reported by events.
+// Reporting the milestones only rather than every progress update avoids flooding the events.
+var backingImageDataSourceProgressMilestones = []int{25, 50, 75, 100}
+
+// recordBackingImageDataSourceEvents records the state change and the highest progress milestone
+// crossed between the existing and the updated backing image data source status.
+func recordBackingImageDataSourceEvents(eventRecorder record.EventRecorder, existingBIDS, bids *longhorn.BackingImageDataSource) {
+	if existingBIDS.Status.CurrentState != bids.Status.CurrentState {
+		eventType := corev1.EventTypeNormal
+		if bids.Status.CurrentState == longhorn.BackingImageStateFailed {
+			eventType = corev1.EventTypeWarning
+		}
+		eventRecorder.Eventf(bids, eventType, constant.EventReasonStateChanged,
+			"Backing image data source %v state changed from %v to %v", bids.Name, existingBIDS.Status.CurrentState, bids.Status.CurrentState)
+	}
+
+	crossedMilestone := 0
+	for _, milestone := range backingImageDataSourceProgressMilestones {
+		if existingBIDS.Status.Progress < milestone && bids.Status.Progress >= milestone {
+			crossedMilestone = milestone
+		}
+	}
+	if crossedMilestone != 0 {
+		eventRecorder.Eventf(bids, corev1.EventTypeNormal, constant.EventReasonProgressing,
+			"Backing image data source %v progress reached %v%%", bids.Name, crossedMilestone)
+	}
+}
+
 func (c *BackingImageDataSourceController) isResponsibleFor(bids *longhorn.BackingImageDataSource) bool {
 	return isControllerResponsibleFor(c.controllerID, c.ds, bids.Name, bids.Spec.NodeID, bids.Status.OwnerID)
 }
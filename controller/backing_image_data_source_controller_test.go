@@ -0,0 +1,487 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/kubernetes/pkg/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/longhorn/longhorn-manager/constant"
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/longhorn/longhorn-manager/util"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+
+	. "gopkg.in/check.v1"
+)
+
+func newTestBackingImageDataSourceController(lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset,
+	informerFactories *util.InformerFactories, controllerID string) *BackingImageDataSourceController {
+	ds := datastore.NewDataStore(TestNamespace, lhClient, kubeClient, extensionsClient, informerFactories)
+
+	logger := logrus.StandardLogger()
+
+	c := NewBackingImageDataSourceController(logger, ds, scheme.Scheme, kubeClient, TestNamespace, controllerID, TestServiceAccount, TestBackingImageManagerImage, util.NewAtomicCounter())
+	c.eventRecorder = record.NewFakeRecorder(100)
+	for index := range c.cacheSyncs {
+		c.cacheSyncs[index] = alwaysReady
+	}
+
+	return c
+}
+
+func newTestDownloadBackingImageDataSource() *longhorn.BackingImageDataSource {
+	return &longhorn.BackingImageDataSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TestBackingImage,
+			Namespace: TestNamespace,
+		},
+		Spec: longhorn.BackingImageDataSourceSpec{
+			NodeID:     TestNode1,
+			UUID:       TestBackingImageUUID,
+			DiskUUID:   TestDiskID1,
+			DiskPath:   TestDefaultDataPath,
+			SourceType: longhorn.BackingImageDataSourceTypeDownload,
+			Parameters: map[string]string{
+				longhorn.DataSourceTypeDownloadParameterURL: "https://example.com/image.qcow2",
+			},
+		},
+		Status: longhorn.BackingImageDataSourceStatus{
+			OwnerID: TestNode1,
+		},
+	}
+}
+
+func newTestExportFromVolumeBackingImageDataSource(expectedChecksum, currentChecksum string, progress int) *longhorn.BackingImageDataSource {
+	return &longhorn.BackingImageDataSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TestBackingImage,
+			Namespace: TestNamespace,
+		},
+		Spec: longhorn.BackingImageDataSourceSpec{
+			NodeID:     TestNode1,
+			Checksum:   expectedChecksum,
+			SourceType: longhorn.BackingImageDataSourceTypeExportFromVolume,
+			Parameters: map[string]string{
+				longhorn.DataSourceTypeExportFromVolumeParameterVolumeName: TestVolumeName,
+			},
+		},
+		Status: longhorn.BackingImageDataSourceStatus{
+			CurrentState: longhorn.BackingImageStateReadyForTransfer,
+			Progress:     progress,
+			Checksum:     currentChecksum,
+		},
+	}
+}
+
+func (s *TestSuite) TestVerifyBackingImageDataSourceChecksum(c *C) {
+	// Mismatched checksum after the export completes
+	bids := newTestExportFromVolumeBackingImageDataSource("expected-checksum", "actual-checksum", 100)
+	c.Assert(verifyBackingImageDataSourceChecksum(bids), Equals, false)
+	c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateFailed)
+	c.Assert(bids.Status.Checksum, Equals, "actual-checksum")
+	c.Assert(bids.Status.Message, Matches, ".*actual-checksum.*"+TestVolumeName+".*expected-checksum.*")
+
+	// Matched checksum
+	bids = newTestExportFromVolumeBackingImageDataSource("expected-checksum", "expected-checksum", 100)
+	c.Assert(verifyBackingImageDataSourceChecksum(bids), Equals, true)
+	c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateReadyForTransfer)
+
+	// Export still in progress
+	bids = newTestExportFromVolumeBackingImageDataSource("expected-checksum", "actual-checksum", 50)
+	bids.Status.CurrentState = longhorn.BackingImageStateInProgress
+	c.Assert(verifyBackingImageDataSourceChecksum(bids), Equals, true)
+	c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateInProgress)
+
+	// Verification skipped without an expected checksum
+	bids = newTestExportFromVolumeBackingImageDataSource("", "actual-checksum", 100)
+	c.Assert(verifyBackingImageDataSourceChecksum(bids), Equals, true)
+	c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateReadyForTransfer)
+	c.Assert(bids.Status.Message, Equals, "")
+}
+
+func (s *TestSuite) TestBackingImageDataSourceDownloadURL(c *C) {
+	for name, tc := range map[string]struct {
+		url           string
+		expectPod     bool
+		expectState   longhorn.BackingImageState
+		expectMessage string
+	}{
+		"valid URL": {
+			url:         "https://example.com/image.qcow2",
+			expectPod:   true,
+			expectState: "",
+		},
+		"missing URL": {
+			expectPod:     false,
+			expectState:   longhorn.BackingImageStateFailed,
+			expectMessage: "missing parameter url .*",
+		},
+		"malformed URL": {
+			url:           "example.com/image.qcow2",
+			expectPod:     false,
+			expectState:   longhorn.BackingImageStateFailed,
+			expectMessage: "invalid parameter url example.com/image.qcow2.*",
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		lhClient := lhfake.NewSimpleClientset()
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+		informerFactories := util.NewInformerFactories(TestNamespace, kubeClient, lhClient, controller.NoResyncPeriodFunc())
+
+		sIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		biIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().BackingImages().Informer().GetIndexer()
+		nIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+
+		bidsc := newTestBackingImageDataSourceController(lhClient, kubeClient, extensionsClient, informerFactories, TestNode1)
+
+		setting, err := lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), newTolerationSetting(), metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(sIndexer.Add(setting), IsNil)
+		c.Assert(nIndexer.Add(newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusTrue, "")), IsNil)
+
+		bi := &longhorn.BackingImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      TestBackingImage,
+				Namespace: TestNamespace,
+			},
+			Status: longhorn.BackingImageStatus{
+				UUID: TestBackingImageUUID,
+			},
+		}
+		bi, err = lhClient.LonghornV1beta2().BackingImages(TestNamespace).Create(context.TODO(), bi, metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(biIndexer.Add(bi), IsNil)
+
+		bids := newTestDownloadBackingImageDataSource()
+		bids.Spec.Parameters[longhorn.DataSourceTypeDownloadParameterURL] = tc.url
+		err = bidsc.syncBackingImageDataSourcePod(bids)
+		c.Assert(err, IsNil)
+		c.Assert(bids.Status.CurrentState, Equals, tc.expectState)
+
+		_, err = kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), types.GetBackingImageDataSourcePodName(bids.Name), metav1.GetOptions{})
+		if tc.expectPod {
+			c.Assert(err, IsNil)
+			c.Assert(bids.Status.Message, Equals, "")
+			c.Assert(bids.Status.RunningParameters[longhorn.DataSourceTypeDownloadParameterURL], Equals, tc.url)
+		} else {
+			c.Assert(err, NotNil)
+			c.Assert(bids.Status.Message, Matches, tc.expectMessage)
+		}
+	}
+}
+
+func (s *TestSuite) TestBackingImageDataSourceDiskValidation(c *C) {
+	for name, tc := range map[string]struct {
+		diskUUID      string
+		diskPath      string
+		expectPod     bool
+		expectMessage string
+	}{
+		"consistent disk": {
+			diskUUID:  TestDiskID1,
+			diskPath:  TestDefaultDataPath,
+			expectPod: true,
+		},
+		"consistent disk with trailing slash": {
+			diskUUID:  TestDiskID1,
+			diskPath:  TestDefaultDataPath + "/",
+			expectPod: true,
+		},
+		"mismatched disk path": {
+			diskUUID:      TestDiskID1,
+			diskPath:      "/mnt/another-disk",
+			expectPod:     false,
+			expectMessage: ".*disk path /mnt/another-disk does not match path " + TestDefaultDataPath + ".*",
+		},
+		"unknown disk UUID": {
+			diskUUID:      "unknown-disk-uuid",
+			diskPath:      TestDefaultDataPath,
+			expectPod:     false,
+			expectMessage: ".*disk with UUID unknown-disk-uuid is not found on node " + TestNode1 + ".*",
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		lhClient := lhfake.NewSimpleClientset()
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+		informerFactories := util.NewInformerFactories(TestNamespace, kubeClient, lhClient, controller.NoResyncPeriodFunc())
+
+		sIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer()
+		biIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().BackingImages().Informer().GetIndexer()
+		nIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+
+		bidsc := newTestBackingImageDataSourceController(lhClient, kubeClient, extensionsClient, informerFactories, TestNode1)
+
+		setting, err := lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), newTolerationSetting(), metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(sIndexer.Add(setting), IsNil)
+		c.Assert(nIndexer.Add(newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusTrue, "")), IsNil)
+
+		bi := &longhorn.BackingImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      TestBackingImage,
+				Namespace: TestNamespace,
+			},
+			Status: longhorn.BackingImageStatus{
+				UUID: TestBackingImageUUID,
+			},
+		}
+		bi, err = lhClient.LonghornV1beta2().BackingImages(TestNamespace).Create(context.TODO(), bi, metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(biIndexer.Add(bi), IsNil)
+
+		bids := newTestDownloadBackingImageDataSource()
+		bids.Spec.DiskUUID = tc.diskUUID
+		bids.Spec.DiskPath = tc.diskPath
+		err = bidsc.syncBackingImageDataSourcePod(bids)
+		c.Assert(err, IsNil)
+
+		_, err = kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), types.GetBackingImageDataSourcePodName(bids.Name), metav1.GetOptions{})
+		if tc.expectPod {
+			c.Assert(err, IsNil)
+			c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageState(""))
+			c.Assert(bids.Status.Message, Equals, "")
+		} else {
+			// The inconsistent data source is failed without launching the pod
+			c.Assert(err, NotNil)
+			c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateFailed)
+			c.Assert(bids.Status.Message, Matches, tc.expectMessage)
+		}
+	}
+}
+
+func (s *TestSuite) TestBackingImageDataSourceProgressEvents(c *C) {
+	recorder := record.NewFakeRecorder(200)
+
+	// Only the milestone crossings are recorded when the progress goes from 0 to 100
+	bids := newTestDownloadBackingImageDataSource()
+	bids.Status.CurrentState = longhorn.BackingImageStateInProgress
+	for progress := 1; progress <= 100; progress++ {
+		existingBIDS := bids.DeepCopy()
+		bids.Status.Progress = progress
+		recordBackingImageDataSourceEvents(recorder, existingBIDS, bids)
+	}
+	c.Assert(recorder.Events, HasLen, len(backingImageDataSourceProgressMilestones))
+	for _, milestone := range backingImageDataSourceProgressMilestones {
+		event := <-recorder.Events
+		c.Assert(event, Equals, fmt.Sprintf("%v %v Backing image data source %v progress reached %v%%",
+			corev1.EventTypeNormal, constant.EventReasonProgressing, bids.Name, milestone))
+	}
+
+	// Only the highest milestone is recorded when several milestones are crossed at once
+	existingBIDS := bids.DeepCopy()
+	existingBIDS.Status.Progress = 0
+	recordBackingImageDataSourceEvents(recorder, existingBIDS, bids)
+	c.Assert(recorder.Events, HasLen, 1)
+	c.Assert(<-recorder.Events, Matches, ".* progress reached 100%")
+
+	// The state change is recorded, with a warning for failures
+	existingBIDS = bids.DeepCopy()
+	bids.Status.CurrentState = longhorn.BackingImageStateFailed
+	recordBackingImageDataSourceEvents(recorder, existingBIDS, bids)
+	c.Assert(recorder.Events, HasLen, 1)
+	c.Assert(<-recorder.Events, Equals, fmt.Sprintf("%v %v Backing image data source %v state changed from %v to %v",
+		corev1.EventTypeWarning, constant.EventReasonStateChanged, bids.Name, longhorn.BackingImageStateInProgress, longhorn.BackingImageStateFailed))
+}
+
+func (s *TestSuite) TestBackingImageDataSourceExportSnapshot(c *C) {
+	for name, tc := range map[string]struct {
+		snapshotName   string
+		snapshotVolume string
+		expectErr      bool
+	}{
+		"no snapshot specified": {
+			snapshotName: "",
+			expectErr:    false,
+		},
+		"valid snapshot": {
+			snapshotName:   "test-snapshot",
+			snapshotVolume: TestVolumeName,
+			expectErr:      false,
+		},
+		"nonexistent snapshot": {
+			snapshotName: "nonexistent-snapshot",
+			expectErr:    true,
+		},
+		"snapshot of another volume": {
+			snapshotName:   "test-snapshot",
+			snapshotVolume: "another-volume",
+			expectErr:      true,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		kubeClient := fake.NewSimpleClientset()
+		lhClient := lhfake.NewSimpleClientset()
+		extensionsClient := apiextensionsfake.NewSimpleClientset()
+		informerFactories := util.NewInformerFactories(TestNamespace, kubeClient, lhClient, controller.NoResyncPeriodFunc())
+
+		snapIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Snapshots().Informer().GetIndexer()
+
+		bidsc := newTestBackingImageDataSourceController(lhClient, kubeClient, extensionsClient, informerFactories, TestNode1)
+
+		if tc.snapshotVolume != "" {
+			snapshot := &longhorn.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tc.snapshotName,
+					Namespace: TestNamespace,
+				},
+				Spec: longhorn.SnapshotSpec{
+					Volume: tc.snapshotVolume,
+				},
+			}
+			c.Assert(snapIndexer.Add(snapshot), IsNil)
+		}
+
+		bids := newTestExportFromVolumeBackingImageDataSource("", "", 0)
+		if tc.snapshotName != "" {
+			bids.Spec.Parameters[longhorn.DataSourceTypeExportFromVolumeParameterSnapshotName] = tc.snapshotName
+		}
+		err := bidsc.validateBackingImageDataSourceParameters(bids)
+		if tc.expectErr {
+			c.Assert(err, NotNil)
+			c.Assert(err, ErrorMatches, ".*"+tc.snapshotName+".*"+TestVolumeName+".*")
+		} else {
+			c.Assert(err, IsNil)
+		}
+	}
+}
+
+func (s *TestSuite) TestBackingImageDataSourceCancel(c *C) {
+	kubeClient := fake.NewSimpleClientset()
+	lhClient := lhfake.NewSimpleClientset()
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+	informerFactories := util.NewInformerFactories(TestNamespace, kubeClient, lhClient, controller.NoResyncPeriodFunc())
+
+	biIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().BackingImages().Informer().GetIndexer()
+	bidsIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().BackingImageDataSources().Informer().GetIndexer()
+	pIndexer := informerFactories.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer()
+
+	bidsc := newTestBackingImageDataSourceController(lhClient, kubeClient, extensionsClient, informerFactories, TestNode1)
+
+	bi := &longhorn.BackingImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TestBackingImage,
+			Namespace: TestNamespace,
+		},
+		Status: longhorn.BackingImageStatus{
+			UUID: TestBackingImageUUID,
+		},
+	}
+	bi, err := lhClient.LonghornV1beta2().BackingImages(TestNamespace).Create(context.TODO(), bi, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(biIndexer.Add(bi), IsNil)
+
+	// The download is canceled in the middle
+	bids := newTestDownloadBackingImageDataSource()
+	bids.Spec.Cancel = true
+	bids.Status.CurrentState = longhorn.BackingImageStateInProgress
+	bids.Status.Progress = 40
+	bids.Status.IP = TestIP1
+	bids, err = lhClient.LonghornV1beta2().BackingImageDataSources(TestNamespace).Create(context.TODO(), bids, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(bidsIndexer.Add(bids), IsNil)
+
+	pod := newPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, types.GetBackingImageDataSourcePodName(bids.Name), TestNamespace, TestNode1)
+	pod, err = kubeClient.CoreV1().Pods(TestNamespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(pIndexer.Add(pod), IsNil)
+
+	key := TestNamespace + "/" + bids.Name
+	c.Assert(bidsc.syncBackingImageDataSource(key), IsNil)
+	bids, err = lhClient.LonghornV1beta2().BackingImageDataSources(TestNamespace).Get(context.TODO(), bids.Name, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateFailed)
+	c.Assert(bids.Status.Message, Matches, ".*download is canceled")
+	c.Assert(bids.Status.IP, Equals, "")
+	pods, err := kubeClient.CoreV1().Pods(TestNamespace).List(context.TODO(), metav1.ListOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(pods.Items, HasLen, 0)
+
+	// The pod is not recreated after the backing image manager cleans up the partial file
+	bids.Status.CurrentState = longhorn.BackingImageStateFailedAndCleanUp
+	bids, err = lhClient.LonghornV1beta2().BackingImageDataSources(TestNamespace).UpdateStatus(context.TODO(), bids, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(bidsIndexer.Update(bids), IsNil)
+	c.Assert(pIndexer.Delete(pod), IsNil)
+
+	c.Assert(bidsc.syncBackingImageDataSource(key), IsNil)
+	bids, err = lhClient.LonghornV1beta2().BackingImageDataSources(TestNamespace).Get(context.TODO(), bids.Name, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(bids.Status.CurrentState, Equals, longhorn.BackingImageStateFailedAndCleanUp)
+	pods, err = kubeClient.CoreV1().Pods(TestNamespace).List(context.TODO(), metav1.ListOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(pods.Items, HasLen, 0)
+}
+
+func (s *TestSuite) TestBackingImageDataSourcePreparationMetrics(c *C) {
+	backingImageDataSourcePreparationDuration.Reset()
+	backingImageDataSourcePreparationFailures.Reset()
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	tracker := newBackingImageDataSourcePreparationTracker(fakeClock)
+
+	updateState := func(bids *longhorn.BackingImageDataSource, state longhorn.BackingImageState) {
+		existingBIDS := bids.DeepCopy()
+		bids.Status.CurrentState = state
+		tracker.record(existingBIDS, bids)
+	}
+
+	// The download data source is prepared successfully
+	downloadBIDS := newTestDownloadBackingImageDataSource()
+	updateState(downloadBIDS, longhorn.BackingImageStateStarting)
+	fakeClock.Step(time.Minute)
+	updateState(downloadBIDS, longhorn.BackingImageStateInProgress)
+	fakeClock.Step(90 * time.Second)
+	updateState(downloadBIDS, longhorn.BackingImageStateInProgress)
+	updateState(downloadBIDS, longhorn.BackingImageStateReadyForTransfer)
+
+	// The export data source fails to be prepared
+	exportBIDS := newTestExportFromVolumeBackingImageDataSource("", "", 0)
+	exportBIDS.Name = "export-backing-image"
+	updateState(exportBIDS, longhorn.BackingImageStateInProgress)
+	fakeClock.Step(time.Minute)
+	updateState(exportBIDS, longhorn.BackingImageStateFailed)
+
+	// Only the successful preparation is observed, from entering the in-progress state
+	expected := `
+# HELP longhorn_backing_image_data_source_preparation_duration_seconds The duration of preparing the file of Longhorn backing image data sources from in progress to ready
+# TYPE longhorn_backing_image_data_source_preparation_duration_seconds histogram
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="30"} 0
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="60"} 0
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="120"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="300"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="600"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="1200"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="1800"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="3600"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="7200"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="14400"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_bucket{source_type="download",le="+Inf"} 1
+longhorn_backing_image_data_source_preparation_duration_seconds_sum{source_type="download"} 90
+longhorn_backing_image_data_source_preparation_duration_seconds_count{source_type="download"} 1
+`
+	c.Assert(testutil.CollectAndCompare(backingImageDataSourcePreparationDuration, strings.NewReader(expected)), IsNil)
+	c.Assert(testutil.ToFloat64(backingImageDataSourcePreparationFailures.WithLabelValues(string(longhorn.BackingImageDataSourceTypeExportFromVolume))), Equals, float64(1))
+	c.Assert(testutil.CollectAndCount(backingImageDataSourcePreparationFailures), Equals, 1)
+	c.Assert(tracker.startTimes, HasLen, 0)
+}
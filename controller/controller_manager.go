@@ -32,7 +32,7 @@ var (
 // StartControllers initiates all Longhorn component controllers and monitors to manage the creating, updating, and deletion of Longhorn resources
 func StartControllers(logger logrus.FieldLogger, clients *client.Clients,
 	controllerID, serviceAccount, managerImage, backingImageManagerImage, shareManagerImage,
-	kubeconfigPath, version string, proxyConnCounter util.Counter) (*WebsocketController, error) {
+	kubeconfigPath, version string, proxyConnCounter util.Counter) (*WebsocketController, *InstanceManagerController, error) {
 	namespace := clients.Namespace
 	kubeClient := clients.Clients.K8s
 	metricsClient := clients.MetricsClient
@@ -78,6 +78,8 @@ func StartControllers(logger logrus.FieldLogger, clients *client.Clients,
 	kubernetesSecretController := NewKubernetesSecretController(logger, ds, scheme, kubeClient, controllerID, namespace)
 	kubernetesPDBController := NewKubernetesPDBController(logger, ds, kubeClient, controllerID, namespace)
 
+	nodeController.instanceManagersCleaner = instanceManagerController.CleanupInstanceManagersForNode
+
 	// Start goroutines for Longhorn controllers
 	go replicaController.Run(Workers, stopCh)
 	go engineController.Run(Workers, stopCh)
@@ -116,7 +118,7 @@ func StartControllers(logger logrus.FieldLogger, clients *client.Clients,
 	go kubernetesSecretController.Run(Workers, stopCh)
 	go kubernetesPDBController.Run(Workers, stopCh)
 
-	return websocketController, nil
+	return websocketController, instanceManagerController, nil
 }
 
 func ParseResourceRequirement(val string) (*corev1.ResourceRequirements, error) {
@@ -163,8 +165,12 @@ func GetInstanceManagerCPURequirement(ds *datastore.DataStore, imName string) (*
 			if err != nil {
 				return nil, err
 			}
+			diskScale, err := getInstanceManagerCPUDiskScale(ds, im, lhNode)
+			if err != nil {
+				return nil, err
+			}
 			allocatableMilliCPU := float64(kubeNode.Status.Allocatable.Cpu().MilliValue())
-			cpuRequest = int(math.Round(allocatableMilliCPU * guaranteedCPUPercentage / 100.0))
+			cpuRequest = int(math.Min(math.Round(allocatableMilliCPU*guaranteedCPUPercentage/100.0*float64(diskScale)), allocatableMilliCPU))
 		}
 	case longhorn.DataEngineTypeV2:
 		// TODO: Support CPU request per node for v2 volumes
@@ -184,6 +190,27 @@ func GetInstanceManagerCPURequirement(ds *datastore.DataStore, imName string) (*
 	return ParseResourceRequirement(fmt.Sprintf("%dm", cpuRequest))
 }
 
+// getInstanceManagerCPUDiskScale returns the factor scaling the guaranteed CPU of the v1 instance manager by the number
+// of filesystem disks on the node, capped by the max disk scale setting. The engine instance manager is not scaled.
+func getInstanceManagerCPUDiskScale(ds *datastore.DataStore, im *longhorn.InstanceManager, node *longhorn.Node) (int, error) {
+	if im.Spec.Type == longhorn.InstanceManagerTypeEngine {
+		return 1, nil
+	}
+
+	maxDiskScale, err := ds.GetSettingAsInt(types.SettingNameGuaranteedInstanceManagerCPUMaxDiskScale)
+	if err != nil {
+		return 0, err
+	}
+
+	diskCount := 0
+	for _, disk := range node.Spec.Disks {
+		if disk.Type == longhorn.DiskTypeFilesystem {
+			diskCount++
+		}
+	}
+	return int(math.Max(1, math.Min(float64(diskCount), float64(maxDiskScale)))), nil
+}
+
 func isControllerResponsibleFor(controllerID string, ds *datastore.DataStore, name, preferredOwnerID, currentOwnerID string) bool {
 	// we use this approach so that if there is an issue with the data store
 	// we don't accidentally transfer ownership
@@ -44,9 +44,11 @@ const (
 	TestExtraInstanceManagerImage = "longhorn-instance-manager:upgraded"
 	TestManagerImage              = "longhorn-manager:latest"
 	TestShareManagerImage         = "longhorn-share-manager:latest"
+	TestBackingImageManagerImage  = "backing-image-manager:latest"
 	TestServiceAccount            = "longhorn-service-account"
 
-	TestBackingImage = "test-backing-image"
+	TestBackingImage     = "test-backing-image"
+	TestBackingImageUUID = "test-backing-image-uuid"
 
 	TestInstanceManagerName = "instance-manager-test-name"
 
@@ -283,6 +285,7 @@ func newInstanceManager(
 	if currentState == longhorn.InstanceManagerStateRunning {
 		im.Status.APIMinVersion = engineapi.MinInstanceManagerAPIVersion
 		im.Status.APIVersion = engineapi.CurrentInstanceManagerAPIVersion
+		im.Status.APIReady = true
 	}
 
 	if isDeleting {
@@ -447,7 +447,7 @@ func (ec *EngineController) CreateInstance(obj interface{}) (*longhorn.InstanceP
 		return nil, err
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(ec.ds, im)
 	if err != nil {
 		return nil, err
 	}
@@ -575,7 +575,7 @@ func (ec *EngineController) DeleteInstance(obj interface{}) (err error) {
 	// For the engine instance in instance manager v0.7.0, we need to use the cmdline to delete the instance
 	// and stop the iscsi
 	if im.Status.APIVersion == engineapi.IncompatibleInstanceManagerAPIVersion {
-		url := imutil.GetURL(im.Status.IP, engineapi.InstanceManagerProcessManagerServiceDefaultPort)
+		url := imutil.GetURL(im.Status.IP, engineapi.GetInstanceManagerProcessManagerServicePort(im))
 		args := []string{"--url", url, "engine", "delete", "--name", e.Name}
 
 		execute := lhexec.NewExecutor().Execute
@@ -593,7 +593,7 @@ func (ec *EngineController) DeleteInstance(obj interface{}) (err error) {
 		return nil
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(ec.ds, im)
 	if err != nil {
 		return err
 	}
@@ -683,7 +683,7 @@ func (ec *EngineController) GetInstance(obj interface{}) (*longhorn.InstanceProc
 			return nil, err
 		}
 	}
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(ec.ds, im)
 	if err != nil {
 		return nil, err
 	}
@@ -703,7 +703,7 @@ func (ec *EngineController) LogInstance(ctx context.Context, obj interface{}) (*
 		return nil, nil, err
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(ec.ds, im)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2052,7 +2052,7 @@ func (ec *EngineController) UpgradeEngineInstance(e *longhorn.Engine, log *logru
 		return err
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(ec.ds, im)
 	if err != nil {
 		return err
 	}
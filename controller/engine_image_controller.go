@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -387,6 +388,9 @@ func (ic *EngineImageController) syncNodeDeploymentMap(engineImage *longhorn.Eng
 		allContainerReady := true
 		for _, containerStatus := range pod.Status.ContainerStatuses {
 			allContainerReady = allContainerReady && containerStatus.Ready
+			if digest := getImageDigest(containerStatus.ImageID); containerStatus.Ready && digest != "" {
+				engineImage.Status.ImageDigest = digest
+			}
 		}
 		nodeDeploymentMap[pod.Spec.NodeName] = allContainerReady
 	}
@@ -396,6 +400,15 @@ func (ic *EngineImageController) syncNodeDeploymentMap(engineImage *longhorn.Eng
 	return nil
 }
 
+// getImageDigest returns the repository digest in the image ID reported by the container runtime,
+// e.g. "sha256:<hex>" for "docker.io/longhornio/longhorn-engine@sha256:<hex>".
+func getImageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return ""
+}
+
 // handleAutoUpgradeEngineImageToDefaultEngineImage automatically upgrades volume's engine image to default engine image when it is applicable
 func (ic *EngineImageController) handleAutoUpgradeEngineImageToDefaultEngineImage(currentProcessingImage string) error {
 	defaultEngineImage, err := ic.ds.GetSettingValueExisted(types.SettingNameDefaultEngineImage)
@@ -188,6 +188,15 @@ func generateEngineImageControllerTestCases() map[string]*EngineImageControllerT
 	tc.expectedEngineImage.Status.NodeDeploymentMap = map[string]bool{TestNode1: true}
 	testCases["Incompatible engine image"] = tc
 
+	// The image digest is resolved from the ready DaemonSet pod
+	tc = getEngineImageControllerTestTemplate()
+	tc.currentDaemonSetPod = createEngineImageDaemonSetPod(getTestEngineImageDaemonSetName()+TestPod1, true, TestNode1)
+	tc.currentDaemonSetPod.Status.ContainerStatuses[0].ImageID = "docker.io/longhornio/longhorn-engine@sha256:0123456789abcdef"
+	tc.copyCurrentToExpected()
+	tc.expectedEngineImage.Status.NodeDeploymentMap = map[string]bool{TestNode1: true}
+	tc.expectedEngineImage.Status.ImageDigest = "sha256:0123456789abcdef"
+	testCases["Engine image digest is resolved"] = tc
+
 	return testCases
 }
 
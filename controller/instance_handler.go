@@ -81,6 +81,12 @@ func (h *InstanceHandler) syncStatusWithInstanceManager(im *longhorn.InstanceMan
 				logrus.Warnf("Marking the instance as state ERROR since failed to find the instance manager for the running instance %v", instanceName)
 			}
 			status.CurrentState = longhorn.InstanceStateError
+			// Surface the failure reason of the instance manager, e.g., the pod is OOMKilled
+			if im.Status.CurrentState == longhorn.InstanceManagerStateError {
+				status.Conditions = types.SetCondition(status.Conditions,
+					longhorn.InstanceConditionTypeInstanceCreation, longhorn.ConditionStatusFalse,
+					longhorn.InstanceConditionReasonInstanceManagerError, getInstanceManagerErrorMessage(im))
+			}
 		} else {
 			status.CurrentState = longhorn.InstanceStateStopped
 		}
@@ -229,6 +235,15 @@ func (h *InstanceHandler) resetInstanceErrorCondition(status *longhorn.InstanceS
 	status.Conditions = types.SetCondition(status.Conditions, imtypes.EngineConditionFilesystemReadOnly, longhorn.ConditionStatusFalse, "", "")
 }
 
+// getInstanceManagerErrorMessage returns the failure reason recorded for the errored instance manager,
+// or the generic message if no reason is available.
+func getInstanceManagerErrorMessage(im *longhorn.InstanceManager) string {
+	if im.Status.Message != "" {
+		return im.Status.Message
+	}
+	return "Instance Manager errored"
+}
+
 // getNameFromObj will get the name from the object metadata, which will be used
 // as podName later
 func (h *InstanceHandler) getNameFromObj(obj runtime.Object) (string, error) {
@@ -317,6 +332,13 @@ func (h *InstanceHandler) ReconcileInstanceState(obj interface{}, spec *longhorn
 		longhorn.InstanceConditionTypeInstanceCreation, longhorn.ConditionStatusTrue,
 		"", "")
 
+	// The instance map of a running instance manager may be stale until its API is ready.
+	// Wait rather than creating, deleting, or updating the instance based on the stale status.
+	if im != nil && im.Status.CurrentState == longhorn.InstanceManagerStateRunning && !im.Status.APIReady {
+		logrus.Debugf("Waiting for the API of instance manager %v to be ready before reconciling instance %v", im.Name, instanceName)
+		return nil
+	}
+
 	instances := map[string]longhorn.InstanceProcess{}
 	if im != nil {
 		instances, err = h.getInstancesFromInstanceManager(runtimeObj, im)
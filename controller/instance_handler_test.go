@@ -571,6 +571,54 @@ func (s *TestSuite) TestReconcileInstanceState(c *C) {
 	}
 }
 
+func (s *TestSuite) TestSyncStatusWithErroredInstanceManager(c *C) {
+	pod := newPod(&corev1.PodStatus{
+		Phase: corev1.PodFailed,
+		ContainerStatuses: []corev1.ContainerStatus{
+			{
+				Name: "instance-manager",
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:   "OOMKilled",
+						ExitCode: 137,
+					},
+				},
+			},
+		},
+	}, TestInstanceManagerName, TestNamespace, TestNode1)
+
+	testCases := map[string]struct {
+		imMessage       string
+		expectedMessage string
+	}{
+		"instance manager pod failure reason": {
+			getInstanceManagerPodFailureMessage(pod),
+			"Instance manager pod " + TestInstanceManagerName + " failed: container instance-manager terminated with reason OOMKilled and exit code 137",
+		},
+		"no instance manager failure reason": {
+			"",
+			"Instance Manager errored",
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateError, TestOwnerID1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		im.Status.Message = tc.imMessage
+		e := newEngine(ExistingInstance, TestEngineImage, TestInstanceManagerName, TestNode1, TestIP1, TestPort1, true, longhorn.InstanceStateRunning, longhorn.InstanceStateRunning)
+
+		h := &InstanceHandler{}
+		h.syncStatusWithInstanceManager(im, e.Name, &e.Spec.InstanceSpec, &e.Status.InstanceStatus, nil)
+		c.Assert(e.Status.CurrentState, Equals, longhorn.InstanceStateError)
+
+		condition := types.GetCondition(e.Status.Conditions, longhorn.InstanceConditionTypeInstanceCreation)
+		c.Assert(condition.Status, Equals, longhorn.ConditionStatusFalse)
+		c.Assert(condition.Reason, Equals, longhorn.InstanceConditionReasonInstanceManagerError)
+		c.Assert(condition.Message, Equals, tc.expectedMessage)
+	}
+}
+
 func newTestInstanceHandler(lhClient *lhfake.Clientset, kubeClient *fake.Clientset, extensionsClient *apiextensionsfake.Clientset, informerFactories *util.InformerFactories) *InstanceHandler {
 	ds := datastore.NewDataStore(TestNamespace, lhClient, kubeClient, extensionsClient, informerFactories)
 	fakeRecorder := record.NewFakeRecorder(100)
@@ -1,10 +1,18 @@
 package controller
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,9 +24,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/kubernetes/pkg/controller"
+	"k8s.io/utils/clock"
 
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
@@ -30,6 +41,7 @@ import (
 
 	imapi "github.com/longhorn/longhorn-instance-manager/pkg/api"
 
+	"github.com/longhorn/longhorn-manager/constant"
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/types"
@@ -38,6 +50,64 @@ import (
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 )
 
+const (
+	// The retries of establishing the instance watch start slower than the retries of receiving items from an
+	// established watch, since the instance manager is likely down rather than hitting a transient stream error.
+	// The max durations are the fallbacks of the settings.
+	instanceManagerWatchEstablishmentBackoffInitialDuration = 5 * time.Second
+	instanceManagerWatchEstablishmentBackoffMaxDuration     = 2 * time.Minute
+	instanceManagerWatchReceiveBackoffInitialDuration       = time.Second
+	instanceManagerWatchReceiveBackoffMaxDuration           = 30 * time.Second
+
+	instanceManagerGracefulCleanupRequeueInterval = 5 * time.Second
+	instanceManagerEngineImageRequeueInterval     = 10 * time.Second
+	instanceManagerPodCreationRequeueInterval     = 5 * time.Second
+	instanceManagerAPIReadinessRequeueInterval    = 5 * time.Second
+
+	// The pod recreations of an instance manager in error state are delayed exponentially once the consecutive
+	// recreations reach the threshold, and the count is reset after the instance manager keeps running for a while.
+	instanceManagerPodRecreationBackoffThreshold       = 3
+	instanceManagerPodRecreationBackoffInitialDuration = 10 * time.Second
+	instanceManagerPodRecreationBackoffMaxDuration     = 5 * time.Minute
+	instanceManagerPodRecreationResetPeriod            = 5 * time.Minute
+
+	// The kubelet fails the pods evicted under the node pressure with this reason
+	instanceManagerPodEvictedReason = "Evicted"
+
+	// A new instance process reports the first versions while starting and becoming running
+	instanceProcessInitialResourceVersionMax = 2
+
+	// The warning events of the expired instance updates are emitted at most once per this period for each monitor
+	instanceManagerExpiredInstanceUpdateEventInterval = 5 * time.Minute
+
+	// The warning events of the instances duplicated in other instance managers are emitted at most once per this
+	// period for each monitor
+	instanceManagerDuplicateInstanceEventInterval = 5 * time.Minute
+
+	// An instance manager pod without the corresponding instance manager will be deleted after this period,
+	// which avoids racing with the instance manager creation that the informer cache is not aware of yet.
+	instanceManagerOrphanedPodCleanupGracePeriod = 1 * time.Minute
+
+	// The ownership of an instance manager is not transferred again within this period after the owner changes,
+	// unless the owner node is down or deleted, which prevents the controllers from taking the ownership back and
+	// forth when the manager pods are missing transiently, e.g., during the rolling update.
+	instanceManagerOwnershipHysteresis = 30 * time.Second
+
+	// The pod events of an instance manager within this period collapse into a single enqueue, since the delaying
+	// queue keeps the earliest ready time of a key waiting to be added.
+	instanceManagerPodEventDebounceInterval = 100 * time.Millisecond
+
+	// The monitors are reconciled against the instance managers periodically in case any of them is missed by the syncs
+	instanceManagerMonitorReconcileInterval = 1 * time.Minute
+
+	// The worker count setting is re-read periodically, so the workers are scaled without restarting the controller
+	instanceManagerWorkerCountSyncInterval = 30 * time.Second
+
+	// The instance map update conflicting with other updates is retried on the next ticks of the monitor, and is
+	// dropped after this many continuous conflicts so the monitor moves on to the regular polls
+	instanceManagerMonitorMaxConflictRetryCount = 5
+)
+
 var (
 	mountPropagationHostToContainer = corev1.MountPropagationHostToContainer
 	mountPropagationBidirectional   = corev1.MountPropagationBidirectional
@@ -49,6 +119,8 @@ type InstanceManagerController struct {
 	namespace      string
 	controllerID   string
 	serviceAccount string
+	// podName is the name of the longhorn-manager pod running the controller, recorded in the owned instance managers
+	podName string
 
 	kubeClient    clientset.Interface
 	eventRecorder record.EventRecorder
@@ -59,9 +131,53 @@ type InstanceManagerController struct {
 
 	instanceManagerMonitorMutex *sync.Mutex
 	instanceManagerMonitorMap   map[string]chan struct{}
+	// instanceManagerMonitors keeps the running monitors, so that the instance maps can be refreshed on demand
+	instanceManagerMonitors map[string]*InstanceManagerMonitor
+
+	// instanceManagerClientCache keeps the instance manager clients, so that the monitors don't need to
+	// reconnect to the instance managers every time they are restarted
+	instanceManagerClientCache *instanceManagerClientCache
+
+	podRecreationLock sync.Mutex
+	podRecreations    map[string]*instanceManagerPodRecreation
+
+	ownerChangeLock sync.Mutex
+	ownerChanges    map[string]*instanceManagerOwnerChange
+
+	// pausedInstanceManagers keeps the paused instance managers seen by the controller, so the pause is reported once
+	pausedLock             sync.Mutex
+	pausedInstanceManagers map[string]bool
+
+	// dryRunPodSpecs keeps the pod spec last logged in dry-run mode, so the pod spec is logged once per change
+	dryRunPodLock  sync.Mutex
+	dryRunPodSpecs map[string]string
+
+	// workerCancels stops the running workers one by one when the worker count is scaled down
+	workerLock    sync.Mutex
+	workerCancels []context.CancelFunc
 
 	// for unit test
-	versionUpdater func(*longhorn.InstanceManager) error
+	versionUpdater      func(*longhorn.InstanceManager) error
+	instancesStopper    func(*longhorn.InstanceManager, map[string]longhorn.InstanceProcess) error
+	apiReadinessChecker func(*longhorn.InstanceManager) error
+	clock               clock.PassiveClock
+}
+
+// instanceManagerOwnerChange tracks the last owner change of an instance manager observed by the controller.
+// The change time is unknown for the owner observed for the first time.
+type instanceManagerOwnerChange struct {
+	ownerID   string
+	changedAt time.Time
+}
+
+// instanceManagerPodRecreation tracks the consecutive pod recreations of an instance manager in error state
+type instanceManagerPodRecreation struct {
+	count            int
+	lastRecreateTime time.Time
+	throttled        bool
+	// evicted delays the next recreation regardless of the count, since the pod recreated on the pressured node
+	// right away is likely to be evicted again
+	evicted bool
 }
 
 type InstanceManagerMonitor struct {
@@ -69,22 +185,73 @@ type InstanceManagerMonitor struct {
 
 	Name         string
 	controllerID string
+	// ip is the address of the instance manager the monitor talks to
+	ip string
 
-	ds                 *datastore.DataStore
-	lock               *sync.RWMutex
+	ds   *datastore.DataStore
+	lock *sync.RWMutex
+	// pollLock serializes the polls of the monitor loop and the on-demand refreshes
+	pollLock           sync.Mutex
 	updateNotification bool
-	stopCh             chan struct{}
-	done               bool
+	// updateConflictCount is the number of the continuous conflicts updating the instance map
+	updateConflictCount int
+	// lastPollTime is used to throttle the polls not triggered by the instance watch notifications
+	lastPollTime time.Time
+	clock        clock.PassiveClock
+	stopCh       chan struct{}
+	done         bool
 	// used to notify the controller that monitoring has stopped
 	monitorVoluntaryStopCh chan struct{}
 
 	nodeCallback func(nodeName string)
 
 	client *engineapi.InstanceManagerClient
+	// for unit test
+	instanceLister func() (map[string]longhorn.InstanceProcess, error)
+	watcher        func(ctx context.Context) (interface{}, error)
+
+	// watchEstablishmentBackoff is used to delay the retry of establishing the instance watch stream after failures
+	watchEstablishmentBackoff *flowcontrol.Backoff
+	// watchBackoff is used to delay the retry of receiving items from the instance watch stream after failures
+	watchBackoff *flowcontrol.Backoff
+	// watchConnected and lastRecvTime track the instance watch health, starting from the watch establishment
+	watchConnected bool
+	lastRecvTime   time.Time
+	// watchCancel cancels the context of the instance watch stream, which interrupts the blocking receive
+	watchCancel context.CancelFunc
+
+	// levelLogger is the logger dedicated to the monitor, whose level follows the base logger unless the debug logs
+	// of the instance manager are enabled
+	levelLogger *logrus.Logger
+	baseLogger  *logrus.Logger
+
+	eventRecorder record.EventRecorder
+	// lastExpiredInstanceUpdateEventTime is used to rate limit the warning events of the expired instance updates
+	lastExpiredInstanceUpdateEventTime time.Time
+	// lastDuplicateInstanceEventTime is used to rate limit the warning events of the duplicate instances
+	lastDuplicateInstanceEventTime time.Time
+}
+
+// InstanceManagerWatchStatus is the health of the instance watch of a monitored instance manager
+type InstanceManagerWatchStatus struct {
+	Connected     bool   `json:"connected"`
+	LastRecvTime  string `json:"lastRecvTime"`
+	SinceLastRecv string `json:"sinceLastRecv"`
+	Stale         bool   `json:"stale"`
+}
+
+// InstanceManagerProcessList is the instance processes recorded in the status of an instance manager
+type InstanceManagerProcessList struct {
+	NodeID           string                              `json:"nodeID"`
+	CurrentState     longhorn.InstanceManagerState       `json:"currentState"`
+	InstanceEngines  map[string]longhorn.InstanceProcess `json:"instanceEngines,omitempty"`
+	InstanceReplicas map[string]longhorn.InstanceProcess `json:"instanceReplicas,omitempty"`
+	// Deprecated: Replaced by InstanceEngines and InstanceReplicas
+	Instances map[string]longhorn.InstanceProcess `json:"instances,omitempty"`
 }
 
-func updateInstanceManagerVersion(im *longhorn.InstanceManager) error {
-	cli, err := engineapi.NewInstanceManagerClient(im)
+func updateInstanceManagerVersion(ds *datastore.DataStore, im *longhorn.InstanceManager) error {
+	cli, err := newInstanceManagerClient(ds, im)
 	if err != nil {
 		return err
 	}
@@ -100,6 +267,32 @@ func updateInstanceManagerVersion(im *longhorn.InstanceManager) error {
 	return nil
 }
 
+// checkInstanceManagerAPIReadiness lists the instances, since the passed health probe only means the gRPC server is up.
+func checkInstanceManagerAPIReadiness(ds *datastore.DataStore, im *longhorn.InstanceManager) error {
+	cli, err := newInstanceManagerClient(ds, im)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	_, err = cli.InstanceList()
+	return err
+}
+
+func stopInstanceManagerInstances(ds *datastore.DataStore, im *longhorn.InstanceManager, instances map[string]longhorn.InstanceProcess) error {
+	cli, err := newInstanceManagerClient(ds, im)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	for name, instance := range instances {
+		if err := cli.InstanceDelete(instance.Spec.DataEngine, name, string(instance.Status.Type), "", false); err != nil && !types.ErrorIsNotFound(err) {
+			return errors.Wrapf(err, "failed to stop instance %v", name)
+		}
+	}
+	return nil
+}
+
 func NewInstanceManagerController(
 	logger logrus.FieldLogger,
 	ds *datastore.DataStore,
@@ -118,6 +311,7 @@ func NewInstanceManagerController(
 		namespace:      namespace,
 		controllerID:   controllerID,
 		serviceAccount: serviceAccount,
+		podName:        getManagerPodName(),
 
 		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "longhorn-instance-manager-controller"}),
@@ -126,8 +320,30 @@ func NewInstanceManagerController(
 
 		instanceManagerMonitorMutex: &sync.Mutex{},
 		instanceManagerMonitorMap:   map[string]chan struct{}{},
+		instanceManagerMonitors:     map[string]*InstanceManagerMonitor{},
+
+		instanceManagerClientCache: newInstanceManagerClientCache(func(im *longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error) {
+			return newInstanceManagerClient(ds, im)
+		}),
+
+		podRecreations: map[string]*instanceManagerPodRecreation{},
+
+		ownerChanges: map[string]*instanceManagerOwnerChange{},
+
+		pausedInstanceManagers: map[string]bool{},
+
+		dryRunPodSpecs: map[string]string{},
 
-		versionUpdater: updateInstanceManagerVersion,
+		versionUpdater: func(im *longhorn.InstanceManager) error {
+			return updateInstanceManagerVersion(ds, im)
+		},
+		instancesStopper: func(im *longhorn.InstanceManager, instances map[string]longhorn.InstanceProcess) error {
+			return stopInstanceManagerInstances(ds, im, instances)
+		},
+		apiReadinessChecker: func(im *longhorn.InstanceManager) error {
+			return checkInstanceManagerAPIReadiness(ds, im)
+		},
+		clock: clock.RealClock{},
 	}
 
 	ds.InstanceManagerInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -198,6 +414,11 @@ func isInstanceManagerPod(obj interface{}) bool {
 		}
 	}
 
+	if pod.Labels[types.GetLonghornLabelComponentKey()] == types.LonghornLabelInstanceManager {
+		return true
+	}
+
+	// The pods created by the older versions may not carry the component label
 	for _, container := range pod.Spec.Containers {
 		switch container.Name {
 		case "engine-manager", "replica-manager", "instance-manager":
@@ -218,18 +439,62 @@ func (imc *InstanceManagerController) Run(workers int, stopCh <-chan struct{}) {
 		return
 	}
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(imc.worker, time.Second, stopCh)
-	}
+	ctx := wait.ContextForChannel(stopCh)
+	imc.syncWorkerCount(ctx, workers)
+	go wait.Until(func() { imc.syncWorkerCount(ctx, workers) }, instanceManagerWorkerCountSyncInterval, stopCh)
+	go wait.Until(imc.reconcileMonitors, instanceManagerMonitorReconcileInterval, stopCh)
 
 	<-stopCh
+
+	// The monitors would otherwise keep polling the instance managers and updating the CRs during the teardown
+	imc.stopAllMonitors()
 }
 
-func (imc *InstanceManagerController) worker() {
-	for imc.processNextWorkItem() {
+func (imc *InstanceManagerController) worker(ctx context.Context) {
+	for ctx.Err() == nil && imc.processNextWorkItem() {
 	}
 }
 
+// syncWorkerCount scales the workers to the count specified by the setting, or to the default count if the setting
+// is 0. The worker stopped by the scale-down exits once it finishes the item in process.
+func (imc *InstanceManagerController) syncWorkerCount(ctx context.Context, defaultWorkers int) {
+	workers := defaultWorkers
+	count, err := imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerControllerWorkers)
+	if err != nil {
+		if imc.getWorkerCount() > 0 {
+			imc.logger.WithError(err).Warnf("Failed to get %v setting, will keep the current workers", types.SettingNameInstanceManagerControllerWorkers)
+			return
+		}
+		imc.logger.WithError(err).Warnf("Failed to get %v setting, will start %v workers", types.SettingNameInstanceManagerControllerWorkers, defaultWorkers)
+	} else if count > 0 {
+		workers = int(count)
+	}
+
+	imc.workerLock.Lock()
+	defer imc.workerLock.Unlock()
+
+	if len(imc.workerCancels) == workers {
+		return
+	}
+	imc.logger.Infof("Scaling instance manager controller workers from %v to %v", len(imc.workerCancels), workers)
+	for len(imc.workerCancels) < workers {
+		workerCtx, cancel := context.WithCancel(ctx)
+		imc.workerCancels = append(imc.workerCancels, cancel)
+		go wait.UntilWithContext(workerCtx, imc.worker, time.Second)
+	}
+	for len(imc.workerCancels) > workers {
+		last := len(imc.workerCancels) - 1
+		imc.workerCancels[last]()
+		imc.workerCancels = imc.workerCancels[:last]
+	}
+}
+
+func (imc *InstanceManagerController) getWorkerCount() int {
+	imc.workerLock.Lock()
+	defer imc.workerLock.Unlock()
+	return len(imc.workerCancels)
+}
+
 func (imc *InstanceManagerController) processNextWorkItem() bool {
 	key, quit := imc.queue.Get()
 
@@ -263,12 +528,170 @@ func (imc *InstanceManagerController) handleErr(err error, key interface{}) {
 }
 
 func getLoggerForInstanceManager(logger logrus.FieldLogger, im *longhorn.InstanceManager) *logrus.Entry {
-	return logger.WithFields(
+	log := logger.WithFields(
 		logrus.Fields{
 			"instanceManager": im.Name,
 			"nodeID":          im.Spec.NodeID,
 		},
 	)
+	if !isInstanceManagerDebugEnabled(im) || log.Logger.IsLevelEnabled(logrus.TraceLevel) {
+		return log
+	}
+	return getInstanceManagerDebugLogger(log.Logger).WithFields(log.Data)
+}
+
+// instanceManagerDebugLoggers caches the trace level loggers derived from the base loggers
+var instanceManagerDebugLoggers sync.Map
+
+func isInstanceManagerDebugEnabled(im *longhorn.InstanceManager) bool {
+	return im.Annotations[types.GetLonghornLabelKey(types.InstanceManagerDebugAnnotationKeySuffix)] == "true"
+}
+
+func isInstanceManagerPaused(im *longhorn.InstanceManager) bool {
+	return im.Annotations[types.GetLonghornLabelKey(types.InstanceManagerPausedAnnotationKeySuffix)] == "true"
+}
+
+// observeInstanceManagerPaused records whether the instance manager is paused, and returns true if it just becomes
+// paused.
+func (imc *InstanceManagerController) observeInstanceManagerPaused(imName string, paused bool) bool {
+	imc.pausedLock.Lock()
+	defer imc.pausedLock.Unlock()
+
+	if !paused {
+		delete(imc.pausedInstanceManagers, imName)
+		return false
+	}
+	if imc.pausedInstanceManagers[imName] {
+		return false
+	}
+	imc.pausedInstanceManagers[imName] = true
+	return true
+}
+
+// observeDryRunPodSpec records the pod spec of the instance manager in dry-run mode, and returns true if it differs
+// from the pod spec recorded last time. An empty pod spec forgets the instance manager.
+func (imc *InstanceManagerController) observeDryRunPodSpec(imName, podYAML string) bool {
+	imc.dryRunPodLock.Lock()
+	defer imc.dryRunPodLock.Unlock()
+
+	if podYAML == "" {
+		delete(imc.dryRunPodSpecs, imName)
+		return false
+	}
+	if imc.dryRunPodSpecs[imName] == podYAML {
+		return false
+	}
+	imc.dryRunPodSpecs[imName] = podYAML
+	return true
+}
+
+// getInstanceManagerDebugLogger returns the logger at the trace level writing to the same output as the base logger,
+// so the debug logs of a single instance manager can be enabled without raising the global log level.
+func getInstanceManagerDebugLogger(base *logrus.Logger) *logrus.Logger {
+	if logger, ok := instanceManagerDebugLoggers.Load(base); ok {
+		return logger.(*logrus.Logger)
+	}
+	logger, _ := instanceManagerDebugLoggers.LoadOrStore(base, newDerivedLogger(base, logrus.TraceLevel))
+	return logger.(*logrus.Logger)
+}
+
+// newDerivedLogger returns a logger sharing the output, the formatter and the hooks of the base logger, whose level
+// can be changed independently.
+func newDerivedLogger(base *logrus.Logger, level logrus.Level) *logrus.Logger {
+	logger := logrus.New()
+	logger.Out = base.Out
+	logger.Formatter = base.Formatter
+	logger.Hooks = base.Hooks
+	logger.ReportCaller = base.ReportCaller
+	logger.ExitFunc = base.ExitFunc
+	logger.SetLevel(level)
+	return logger
+}
+
+// getManagerPodName returns the name of the longhorn-manager pod, which is the host name of the pod unless it is
+// specified by the environment variable.
+func getManagerPodName() string {
+	if podName := os.Getenv(types.EnvPodName); podName != "" {
+		return podName
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get the host name as the longhorn-manager pod name")
+		return ""
+	}
+	return hostname
+}
+
+// getInstanceManagerOwnerChangeReason describes why the ownership was taken over, following the cases of
+// isControllerResponsibleFor.
+func getInstanceManagerOwnerChangeReason(im *longhorn.InstanceManager, previousOwnerID string) string {
+	switch {
+	case previousOwnerID == "":
+		return "the instance manager was unowned"
+	case im.Status.OwnerID == im.Spec.NodeID:
+		return "the preferred owner node recovered"
+	default:
+		return fmt.Sprintf("the owner node %v is down", previousOwnerID)
+	}
+}
+
+// observeInstanceManagerOwner records the owner change of the instance manager seen by the controller.
+func (imc *InstanceManagerController) observeInstanceManagerOwner(im *longhorn.InstanceManager) {
+	imc.ownerChangeLock.Lock()
+	defer imc.ownerChangeLock.Unlock()
+
+	ownerChange, ok := imc.ownerChanges[im.Name]
+	if !ok {
+		imc.ownerChanges[im.Name] = &instanceManagerOwnerChange{ownerID: im.Status.OwnerID}
+		return
+	}
+	if ownerChange.ownerID != im.Status.OwnerID {
+		ownerChange.ownerID = im.Status.OwnerID
+		ownerChange.changedAt = imc.clock.Now()
+	}
+}
+
+func (imc *InstanceManagerController) forgetInstanceManagerOwnerChange(imName string) {
+	imc.ownerChangeLock.Lock()
+	defer imc.ownerChangeLock.Unlock()
+
+	delete(imc.ownerChanges, imName)
+}
+
+// getInstanceManagerOwnershipHysteresis returns the remaining time before the controller can take the ownership of
+// the instance manager whose owner just changed. The ownership can be taken right away if the owner node is down or
+// deleted.
+func (imc *InstanceManagerController) getInstanceManagerOwnershipHysteresis(im *longhorn.InstanceManager) time.Duration {
+	if im.Status.OwnerID == "" {
+		return 0
+	}
+
+	imc.ownerChangeLock.Lock()
+	ownerChange, ok := imc.ownerChanges[im.Name]
+	var changedAt time.Time
+	if ok && ownerChange.ownerID == im.Status.OwnerID {
+		changedAt = ownerChange.changedAt
+	}
+	imc.ownerChangeLock.Unlock()
+
+	if changedAt.IsZero() {
+		return 0
+	}
+	remaining := instanceManagerOwnershipHysteresis - imc.clock.Since(changedAt)
+	if remaining <= 0 {
+		return 0
+	}
+
+	isDown, err := imc.ds.IsNodeDownOrDeleted(im.Status.OwnerID)
+	if err != nil {
+		log := getLoggerForInstanceManager(imc.logger, im)
+		log.WithError(err).Warnf("Failed to check if the owner node %v is down", im.Status.OwnerID)
+		return remaining
+	}
+	if isDown {
+		return 0
+	}
+	return remaining
 }
 
 func (imc *InstanceManagerController) syncInstanceManager(key string) (err error) {
@@ -286,19 +709,40 @@ func (imc *InstanceManagerController) syncInstanceManager(key string) (err error
 	im, err := imc.ds.GetInstanceManager(name)
 	if err != nil {
 		if datastore.ErrorIsNotFound(err) {
-			return imc.cleanupInstanceManager(name)
+			deleteInstanceManagerStateMetrics(name)
+			deleteInstanceManagerInstanceMetrics(name)
+			imc.instanceManagerClientCache.invalidate(name)
+			imc.resetInstanceManagerPodRecreation(name)
+			imc.forgetInstanceManagerOwnerChange(name)
+			imc.observeInstanceManagerPaused(name, false)
+			imc.observeDryRunPodSpec(name, "")
+			return imc.cleanupInstanceManager(name, false)
 		}
 		return errors.Wrap(err, "failed to get instance manager")
 	}
 
 	log := getLoggerForInstanceManager(imc.logger, im)
+	log.Tracef("Syncing instance manager in state %v with owner %v", im.Status.CurrentState, im.Status.OwnerID)
+
+	imc.observeInstanceManagerOwner(im)
 
 	if !imc.isResponsibleFor(im) {
+		// The series exported by the previous owner would otherwise stay unchanged along with the new owner's
+		deleteInstanceManagerStateMetrics(im.Name)
+		deleteInstanceManagerInstanceMetrics(im.Name)
 		return nil
 	}
 
 	if im.Status.OwnerID != imc.controllerID {
+		if remaining := imc.getInstanceManagerOwnershipHysteresis(im); remaining > 0 {
+			log.Infof("Postponed taking the ownership from %v for %v since the owner just changed", im.Status.OwnerID, remaining)
+			imc.enqueueInstanceManagerAfter(im, remaining)
+			return nil
+		}
+
+		previousOwnerID := im.Status.OwnerID
 		im.Status.OwnerID = imc.controllerID
+		im.Status.OwnerPodName = imc.podName
 		im, err = imc.ds.UpdateInstanceManagerStatus(im)
 		if err != nil {
 			// we don't mind others coming first
@@ -307,18 +751,60 @@ func (imc *InstanceManagerController) syncInstanceManager(key string) (err error
 			}
 			return err
 		}
+		imc.observeInstanceManagerOwner(im)
 		log.Infof("Instance Manager got new owner %v", imc.controllerID)
+		imc.eventRecorder.Eventf(im, corev1.EventTypeNormal, constant.EventReasonOwnerChanged,
+			"Owner changed from %q to %v since %v", previousOwnerID, imc.controllerID, getInstanceManagerOwnerChangeReason(im, previousOwnerID))
+	}
+
+	paused := isInstanceManagerPaused(im)
+	if imc.observeInstanceManagerPaused(im.Name, paused) {
+		imc.eventRecorder.Eventf(im, corev1.EventTypeNormal, constant.EventReasonPaused,
+			"Reconciliation is paused by the annotation %v", types.GetLonghornLabelKey(types.InstanceManagerPausedAnnotationKeySuffix))
+	}
+	if paused {
+		log.Debug("Skipped reconciling the paused instance manager")
+		return nil
 	}
 
 	if im.DeletionTimestamp != nil {
-		return imc.cleanupInstanceManager(im.Name)
+		drained, err := imc.drainInstanceManager(im)
+		if err != nil {
+			return err
+		}
+		if !drained {
+			imc.enqueueInstanceManagerAfter(im, instanceManagerGracefulCleanupRequeueInterval)
+			return nil
+		}
+		if err := imc.cleanupInstanceManager(im.Name, false); err != nil {
+			if forced, forceErr := imc.forceCleanupInstanceManager(im, err); forced || forceErr != nil {
+				return forceErr
+			}
+			return err
+		}
+		// The foreground deletion waits for the pod to be gone, which may be stuck terminating, e.g., the node is down
+		pod, err := imc.ds.GetPodRO(imc.namespace, im.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get pod for deleting instance manager %v", im.Name)
+		}
+		if pod != nil {
+			_, err := imc.forceCleanupInstanceManager(im, fmt.Errorf("pod %v is still terminating", pod.Name))
+			return err
+		}
+		return nil
 	}
 
 	existingIM := im.DeepCopy()
 	defer func() {
+		if err == nil && existingIM.Status.CurrentState != im.Status.CurrentState {
+			im.Status.CurrentStateTransitionTime = imc.clock.Now().UTC().Format(time.RFC3339)
+		}
 		if err == nil && !reflect.DeepEqual(existingIM.Status, im.Status) {
 			_, err = imc.ds.UpdateInstanceManagerStatus(im)
 		}
+		if err == nil {
+			recordInstanceManagerStateMetrics(im, existingIM.Status.CurrentState)
+		}
 		if apierrors.IsConflict(errors.Cause(err)) {
 			log.WithError(err).Debugf("Requeue %v due to conflict", key)
 			imc.enqueueInstanceManager(im)
@@ -326,6 +812,22 @@ func (imc *InstanceManagerController) syncInstanceManager(key string) (err error
 		}
 	}()
 
+	// The manager pod on the owner node is replaced after the restart or the upgrade
+	im.Status.OwnerPodName = imc.podName
+
+	// Every controller is responsible for the malformed instance manager without the node, and the pod can never be
+	// scheduled. It is marked as error by the owner rather than being silently left pending.
+	if im.Spec.NodeID == "" {
+		message := "Instance manager spec.nodeID is empty, cannot create the instance manager pod"
+		if im.Status.Message != message {
+			log.Warn(message)
+			imc.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonFailedStarting, message)
+		}
+		im.Status.CurrentState = longhorn.InstanceManagerStateError
+		im.Status.Message = message
+		return imc.syncInstanceManagerConditions(im)
+	}
+
 	if err := imc.syncStatusWithPod(im); err != nil {
 		return err
 	}
@@ -354,9 +856,71 @@ func (imc *InstanceManagerController) syncInstanceManager(key string) (err error
 		return err
 	}
 
+	if err := imc.syncInstanceManagerConditions(im); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// syncInstanceManagerConditions reports the pod scheduling, the readiness and the instance watch of the instance
+// manager as conditions, in addition to the current state.
+func (imc *InstanceManagerController) syncInstanceManagerConditions(im *longhorn.InstanceManager) error {
+	pod, err := imc.ds.GetPodRO(imc.namespace, im.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get pod for instance manager %v conditions", im.Name)
+	}
+
+	if pod == nil {
+		imc.setInstanceManagerCondition(im, longhorn.InstanceManagerConditionTypePodScheduled, longhorn.ConditionStatusFalse,
+			longhorn.InstanceManagerConditionReasonPodNotFound, fmt.Sprintf("Instance manager pod %v is not found", im.Name))
+	} else {
+		scheduledStatus, reason, message := longhorn.ConditionStatusTrue, "", ""
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				scheduledStatus = longhorn.ConditionStatusFalse
+				reason = longhorn.InstanceManagerConditionReasonPodUnschedulable
+				message = fmt.Sprintf("Instance manager pod %v is not scheduled: %v %v", pod.Name, condition.Reason, condition.Message)
+			}
+		}
+		imc.setInstanceManagerCondition(im, longhorn.InstanceManagerConditionTypePodScheduled, scheduledStatus, reason, message)
+	}
+
+	if im.Status.CurrentState == longhorn.InstanceManagerStateRunning {
+		imc.setInstanceManagerCondition(im, longhorn.InstanceManagerConditionTypeReady, longhorn.ConditionStatusTrue, "", "")
+	} else {
+		message := fmt.Sprintf("Instance manager is in state %v", im.Status.CurrentState)
+		if im.Status.Message != "" {
+			message = fmt.Sprintf("%v: %v", message, im.Status.Message)
+		}
+		imc.setInstanceManagerCondition(im, longhorn.InstanceManagerConditionTypeReady, longhorn.ConditionStatusFalse,
+			longhorn.InstanceManagerConditionReasonNotRunning, message)
+	}
+
+	if im.Status.APIReady {
+		imc.setInstanceManagerCondition(im, longhorn.InstanceManagerConditionTypeWatchEstablished, longhorn.ConditionStatusTrue, "", "")
+	} else {
+		imc.setInstanceManagerCondition(im, longhorn.InstanceManagerConditionTypeWatchEstablished, longhorn.ConditionStatusFalse,
+			longhorn.InstanceManagerConditionReasonWatchNotEstablished, "The instance watch is not established or the initial poll of the instances is not done")
+	}
+
 	return nil
 }
 
+// setInstanceManagerCondition sets the condition with the transition time from the controller clock
+func (imc *InstanceManagerController) setInstanceManagerCondition(im *longhorn.InstanceManager, conditionType string, conditionValue longhorn.ConditionStatus, reason, message string) {
+	isTransitioned := types.GetCondition(im.Status.Conditions, conditionType).Status != conditionValue
+	im.Status.Conditions = types.SetConditionWithoutTimestamp(im.Status.Conditions, conditionType, conditionValue, reason, message)
+	if !isTransitioned {
+		return
+	}
+	for i := range im.Status.Conditions {
+		if im.Status.Conditions[i].Type == conditionType {
+			im.Status.Conditions[i].LastTransitionTime = imc.clock.Now().UTC().Format(time.RFC3339)
+		}
+	}
+}
+
 // syncStatusWithPod updates the InstanceManager based on the pod current phase only,
 // regardless of the InstanceManager previous status.
 func (imc *InstanceManagerController) syncStatusWithPod(im *longhorn.InstanceManager) error {
@@ -374,6 +938,12 @@ func (imc *InstanceManagerController) syncStatusWithPod(im *longhorn.InstanceMan
 		return errors.Wrapf(err, "failed get pod for instance manager %v", im.Name)
 	}
 
+	// The pod of the instance manager held stopped is deleted by handlePod if it still exists.
+	if isInstanceManagerHeldStopped(im) {
+		im.Status.CurrentState = longhorn.InstanceManagerStateStopped
+		return nil
+	}
+
 	if pod == nil {
 		if im.Status.CurrentState == "" || im.Status.CurrentState == longhorn.InstanceManagerStateStopped {
 			// This state is for newly created InstanceManagers only.
@@ -390,10 +960,22 @@ func (imc *InstanceManagerController) syncStatusWithPod(im *longhorn.InstanceMan
 		return nil
 	}
 
+	// The pod may be created right before the controller crashes, without the starting state persisted. Adopt the
+	// pod rather than creating another one, unless it is left by a previous instance manager with the same name.
+	if im.Status.CurrentState == "" || im.Status.CurrentState == longhorn.InstanceManagerStateStopped {
+		if !isInstanceManagerPodAdoptable(im, pod) {
+			log.Warnf("Found pod %v owned by another instance manager with the same name, will recreate the pod", pod.Name)
+			im.Status.CurrentState = longhorn.InstanceManagerStateStopped
+			return nil
+		}
+		log.Infof("Adopting the existing pod %v in phase %v", pod.Name, pod.Status.Phase)
+	}
+
 	// Blindly update the state based on the pod phase.
 	switch pod.Status.Phase {
 	case corev1.PodPending:
 		im.Status.CurrentState = longhorn.InstanceManagerStateStarting
+		imc.syncInstanceManagerPodWaitingMessage(im, pod)
 	case corev1.PodRunning:
 		isReady := true
 		// Make sure readiness probe has passed.
@@ -402,73 +984,314 @@ func (imc *InstanceManagerController) syncStatusWithPod(im *longhorn.InstanceMan
 		}
 
 		if isReady {
+			ip, err := imc.getInstanceManagerPodIP(pod)
+			if err != nil {
+				return err
+			}
+			if ip == "" {
+				log.Warnf("Instance manager pod %v is ready but its IP is not assigned yet, will retry", pod.Name)
+				im.Status.CurrentState = longhorn.InstanceManagerStateStarting
+				break
+			}
+			// The monitor would keep talking to the stale address of the recreated pod. Replace the monitor and
+			// verify the API at the new address like a newly started instance manager.
+			if previousState == longhorn.InstanceManagerStateRunning && im.Status.IP != "" && im.Status.IP != ip {
+				log.Warnf("Instance manager pod %v IP changed from %v to %v, will re-establish the instance watch", pod.Name, im.Status.IP, ip)
+				imc.detachMonitoring(im.Name)
+				im.Status.IP = ""
+			}
+			// The running state may be persisted without the IP before the controller crashes. Re-derive the IP
+			// from the pod and verify the API like a newly started instance manager.
+			if previousState == longhorn.InstanceManagerStateRunning && im.Status.IP == "" {
+				log.Infof("Recovering the IP %v of the running instance manager from pod %v", ip, pod.Name)
+			}
+			if previousState != longhorn.InstanceManagerStateRunning || im.Status.IP == "" {
+				if err := imc.checkInstanceManagerAPIReadiness(im, ip); err != nil {
+					log.WithError(err).Warnf("Instance manager pod %v is ready but the instances cannot be listed yet, will retry", pod.Name)
+					im.Status.CurrentState = longhorn.InstanceManagerStateStarting
+					imc.enqueueInstanceManagerAfter(im, instanceManagerAPIReadinessRequeueInterval)
+					break
+				}
+			}
 			im.Status.CurrentState = longhorn.InstanceManagerStateRunning
-			im.Status.IP = pod.Status.PodIP
+			im.Status.IP = ip
+			im.Status.Message = ""
 		} else {
 			im.Status.CurrentState = longhorn.InstanceManagerStateStarting
 		}
+	case corev1.PodFailed:
+		if im.Status.CurrentState != longhorn.InstanceManagerStateError {
+			// The message is propagated to the instances by the instance handler
+			im.Status.Message = getInstanceManagerPodFailureMessage(pod)
+			imc.recordInstanceManagerPodFailure(im)
+			if pod.Status.Reason == instanceManagerPodEvictedReason {
+				if err := imc.handleInstanceManagerPodEviction(im); err != nil {
+					return err
+				}
+			}
+		}
+		im.Status.CurrentState = longhorn.InstanceManagerStateError
 	default:
 		im.Status.CurrentState = longhorn.InstanceManagerStateError
 	}
 
+	if previousState == longhorn.InstanceManagerStateStarting && im.Status.CurrentState == longhorn.InstanceManagerStateStarting {
+		return imc.checkStartingTimeout(im, pod)
+	}
+
 	return nil
 }
 
-func (imc *InstanceManagerController) syncStatusWithNode(im *longhorn.InstanceManager) error {
-	log := getLoggerForInstanceManager(imc.logger, im).WithField("node", im.Spec.NodeID)
+// checkInstanceManagerAPIReadiness checks the instance manager API with the pod IP before declaring the instance
+// manager running.
+func (imc *InstanceManagerController) checkInstanceManagerAPIReadiness(im *longhorn.InstanceManager, ip string) error {
+	runningIM := im.DeepCopy()
+	runningIM.Status.CurrentState = longhorn.InstanceManagerStateRunning
+	runningIM.Status.IP = ip
+	return imc.apiReadinessChecker(runningIM)
+}
 
-	isDown, err := imc.ds.IsNodeDownOrDeleted(im.Spec.NodeID)
+// checkStartingTimeout marks the instance manager staying in starting state for too long as error,
+// so that the instance manager pod that can never become ready will be recreated.
+func (imc *InstanceManagerController) checkStartingTimeout(im *longhorn.InstanceManager, pod *corev1.Pod) error {
+	timeoutSeconds, err := imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerStartingTimeout)
 	if err != nil {
 		return err
 	}
-	if isDown {
-		if im.Status.CurrentState != longhorn.InstanceManagerStateError && im.Status.CurrentState != longhorn.InstanceManagerStateUnknown {
-			im.Status.CurrentState = longhorn.InstanceManagerStateUnknown
-			log.Infof("Updated the non-error instance manager to state %v due to node down or deleted", longhorn.InstanceManagerStateUnknown)
-		}
+	if timeoutSeconds == 0 {
+		return nil
 	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
 
-	return nil
-}
+	if im.Status.CurrentStateTransitionTime == "" {
+		// The instance manager became starting before the transition time was tracked
+		im.Status.CurrentStateTransitionTime = imc.clock.Now().UTC().Format(time.RFC3339)
+		imc.enqueueInstanceManagerAfter(im, timeout)
+		return nil
+	}
+	startingTime, err := time.Parse(time.RFC3339, im.Status.CurrentStateTransitionTime)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the state transition time %v of instance manager %v", im.Status.CurrentStateTransitionTime, im.Name)
+	}
 
-// syncInstanceStatus sets the status of instances in special cases independent of InstanceManagerMonitor (e.g. when
-// InstanceManagerMonitor isn't running yet).
-func (imc *InstanceManagerController) syncInstanceStatus(im *longhorn.InstanceManager) error {
-	if im.Status.CurrentState == longhorn.InstanceManagerStateStopped ||
-		im.Status.CurrentState == longhorn.InstanceManagerStateError ||
-		im.Status.CurrentState == longhorn.InstanceManagerStateStarting {
-		// In these states, instance processes either are not running or will soon not be running.
-		// This step prevents other controllers from being confused by stale information.
-		// InstanceManagerMonitor will change this when/if it polls.
-		im.Status.Instances = nil
-		im.Status.InstanceEngines = nil
-		im.Status.InstanceReplicas = nil
+	startingDuration := imc.clock.Since(startingTime)
+	if startingDuration < timeout {
+		// The pending pod may not trigger any sync before timing out
+		imc.enqueueInstanceManagerAfter(im, timeout-startingDuration)
+		return nil
 	}
+
+	im.Status.Message = fmt.Sprintf("instance manager pod %v with phase %v never became ready in %v", pod.Name, pod.Status.Phase, timeout)
+	im.Status.CurrentState = longhorn.InstanceManagerStateError
+	getLoggerForInstanceManager(imc.logger, im).Warnf("Instance manager starting timed out: %v", im.Status.Message)
+	imc.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonFailedStarting, im.Status.Message)
 	return nil
 }
 
-func (imc *InstanceManagerController) syncLogSettingsToIMPod(im *longhorn.InstanceManager) error {
-	if types.IsDataEngineV1(im.Spec.DataEngine) {
-		return nil
-	}
+// instanceManagerPodWaitingProblemReasons are the reasons of the pending pod containers that won't start without the
+// intervention, unlike the ContainerCreating reason during the normal startup
+var instanceManagerPodWaitingProblemReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"ErrImageNeverPull":          true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
 
-	client, err := engineapi.NewInstanceManagerClient(im)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create instance manager client for %v", im.Name)
+// syncInstanceManagerPodWaitingMessage surfaces the problem reasons of the pending pod containers in the status
+// message and the event, so the operators don't have to describe the pod. The message is cleared once the problem is
+// gone.
+func (imc *InstanceManagerController) syncInstanceManagerPodWaitingMessage(im *longhorn.InstanceManager, pod *corev1.Pod) {
+	messagePrefix := fmt.Sprintf("Instance manager pod %v is pending", pod.Name)
+
+	var problems []string
+	for _, st := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		waiting := st.State.Waiting
+		if waiting == nil || !instanceManagerPodWaitingProblemReasons[waiting.Reason] {
+			continue
+		}
+		problem := fmt.Sprintf("container %v is waiting with reason %v", st.Name, waiting.Reason)
+		if waiting.Message != "" {
+			problem = fmt.Sprintf("%v: %v", problem, waiting.Message)
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) == 0 {
+		if strings.HasPrefix(im.Status.Message, messagePrefix) {
+			im.Status.Message = ""
+		}
+		return
 	}
 
-	settingNames := []types.SettingName{
-		types.SettingNameV2DataEngineLogLevel,
-		types.SettingNameV2DataEngineLogFlags,
+	message := fmt.Sprintf("%v: %v", messagePrefix, strings.Join(problems, "; "))
+	if im.Status.Message == message {
+		return
 	}
+	im.Status.Message = message
+	getLoggerForInstanceManager(imc.logger, im).Warn(message)
+	imc.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonFailedStarting, message)
+}
 
-	for _, settingName := range settingNames {
-		setting, err := imc.ds.GetSettingWithAutoFillingRO(settingName)
-		if err != nil {
-			return err
+// getInstanceManagerPodFailureMessage returns the termination reasons and exit codes of the failed pod containers,
+// so that the operators can tell if the instance manager is OOMKilled, crashed, or evicted.
+func getInstanceManagerPodFailureMessage(pod *corev1.Pod) string {
+	var terminations []string
+	for _, st := range pod.Status.ContainerStatuses {
+		terminated := st.State.Terminated
+		if terminated == nil {
+			continue
 		}
-
-		switch settingName {
+		termination := fmt.Sprintf("container %v terminated with reason %v and exit code %v", st.Name, terminated.Reason, terminated.ExitCode)
+		if terminated.Message != "" {
+			termination = fmt.Sprintf("%v: %v", termination, terminated.Message)
+		}
+		terminations = append(terminations, termination)
+	}
+	// An evicted pod has no container termination states
+	if len(terminations) == 0 && pod.Status.Reason != "" {
+		terminations = append(terminations, fmt.Sprintf("pod failed with reason %v: %v", pod.Status.Reason, pod.Status.Message))
+	}
+	if len(terminations) == 0 {
+		terminations = append(terminations, "no container termination state found")
+	}
+
+	return fmt.Sprintf("Instance manager pod %v failed: %v", pod.Name, strings.Join(terminations, "; "))
+}
+
+func (imc *InstanceManagerController) recordInstanceManagerPodFailure(im *longhorn.InstanceManager) {
+	getLoggerForInstanceManager(imc.logger, im).Warn(im.Status.Message)
+	imc.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonFailed, im.Status.Message)
+}
+
+// handleInstanceManagerPodEviction delays the pod recreation on the pressured node. For the instance manager running
+// replicas, it recommends rescheduling the replicas if there are other nodes to rebuild them on.
+func (imc *InstanceManagerController) handleInstanceManagerPodEviction(im *longhorn.InstanceManager) error {
+	imc.recordInstanceManagerPodEviction(im.Name)
+
+	if im.Spec.Type != longhorn.InstanceManagerTypeReplica && im.Spec.Type != longhorn.InstanceManagerTypeAllInOne {
+		return nil
+	}
+	replicas := []string{}
+	for name := range im.Status.InstanceReplicas {
+		replicas = append(replicas, name)
+	}
+	// nolint:all
+	for name, instance := range im.Status.Instances {
+		if instance.Status.Type == longhorn.InstanceTypeReplica {
+			replicas = append(replicas, name)
+		}
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	nodes, err := imc.ds.ListReadyAndSchedulableNodesRO()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list ready and schedulable nodes for evicted instance manager %v", im.Name)
+	}
+	delete(nodes, im.Spec.NodeID)
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	sort.Strings(replicas)
+	imc.eventRecorder.Eventf(im, corev1.EventTypeWarning, constant.EventReasonEvicted,
+		"Instance manager pod was evicted from node %v under the node pressure, consider evicting replicas %v to the other nodes rather than restarting them on the node",
+		im.Spec.NodeID, strings.Join(replicas, ", "))
+	return nil
+}
+
+func (imc *InstanceManagerController) syncStatusWithNode(im *longhorn.InstanceManager) error {
+	log := getLoggerForInstanceManager(imc.logger, im).WithField("node", im.Spec.NodeID)
+
+	isDown, err := imc.ds.IsNodeDownOrDeleted(im.Spec.NodeID)
+	if err != nil {
+		return err
+	}
+	if isDown {
+		if im.Status.CurrentState != longhorn.InstanceManagerStateError && im.Status.CurrentState != longhorn.InstanceManagerStateUnknown {
+			im.Status.CurrentState = longhorn.InstanceManagerStateUnknown
+			log.Infof("Updated the non-error instance manager to state %v due to node down or deleted", longhorn.InstanceManagerStateUnknown)
+		}
+		return nil
+	}
+
+	// The boot ID will be filled in by a later sync, hence there is no need to block the state transition here.
+	kubeNode, err := imc.ds.GetKubernetesNodeRO(im.Spec.NodeID)
+	if err != nil {
+		log.WithError(err).Warn("Failed to get Kubernetes node for checking the node boot ID")
+		return nil
+	}
+	bootID := kubeNode.Status.NodeInfo.BootID
+	if bootID == "" {
+		// The reboot cannot be detected, and the previously recorded boot ID is kept in case the node reports it again
+		if im.Status.CurrentState == longhorn.InstanceManagerStateRunning && im.Status.NodeBootID == "" {
+			log.Warn("Node doesn't report the boot ID, the node reboot cannot be detected")
+			im.Status.NodeBootID = longhorn.InstanceManagerNodeBootIDUnknown
+		}
+		return nil
+	}
+
+	if im.Status.NodeBootID != "" && im.Status.NodeBootID != longhorn.InstanceManagerNodeBootIDUnknown && im.Status.NodeBootID != bootID {
+		log.Warnf("Node boot ID changed from %v to %v, the instances are considered errored since the node rebooted", im.Status.NodeBootID, bootID)
+		imc.eventRecorder.Eventf(im, corev1.EventTypeWarning, constant.EventReasonNodeRebooted,
+			"Node %v rebooted (boot ID changed from %v to %v), all instances of instance manager %v are stopped", im.Spec.NodeID, im.Status.NodeBootID, bootID, im.Name)
+		for _, instances := range []map[string]longhorn.InstanceProcess{im.Status.InstanceEngines, im.Status.InstanceReplicas, im.Status.Instances} {
+			for name, instance := range instances {
+				instance.Status.State = longhorn.InstanceStateError
+				instance.Status.ErrorMsg = fmt.Sprintf("node %v rebooted", im.Spec.NodeID)
+				instances[name] = instance
+			}
+		}
+		im.Status.NodeBootID = bootID
+	}
+
+	if im.Status.CurrentState == longhorn.InstanceManagerStateRunning {
+		im.Status.NodeBootID = bootID
+	}
+
+	return nil
+}
+
+// syncInstanceStatus sets the status of instances in special cases independent of InstanceManagerMonitor (e.g. when
+// InstanceManagerMonitor isn't running yet).
+func (imc *InstanceManagerController) syncInstanceStatus(im *longhorn.InstanceManager) error {
+	if im.Status.CurrentState == longhorn.InstanceManagerStateStopped ||
+		im.Status.CurrentState == longhorn.InstanceManagerStateError ||
+		im.Status.CurrentState == longhorn.InstanceManagerStateStarting {
+		// In these states, instance processes either are not running or will soon not be running.
+		// This step prevents other controllers from being confused by stale information.
+		// InstanceManagerMonitor will change this when/if it polls.
+		im.Status.Instances = nil
+		im.Status.InstanceEngines = nil
+		im.Status.InstanceReplicas = nil
+	}
+	return nil
+}
+
+func (imc *InstanceManagerController) syncLogSettingsToIMPod(im *longhorn.InstanceManager) error {
+	if types.IsDataEngineV1(im.Spec.DataEngine) {
+		return nil
+	}
+
+	client, err := newInstanceManagerClient(imc.ds, im)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create instance manager client for %v", im.Name)
+	}
+
+	settingNames := []types.SettingName{
+		types.SettingNameV2DataEngineLogLevel,
+		types.SettingNameV2DataEngineLogFlags,
+	}
+
+	for _, settingName := range settingNames {
+		setting, err := imc.ds.GetSettingWithAutoFillingRO(settingName)
+		if err != nil {
+			return err
+		}
+
+		switch settingName {
 		case types.SettingNameV2DataEngineLogLevel:
 			err = client.LogSetLevel(longhorn.DataEngineTypeV2, "spdk_tgt", setting.Value)
 			if err != nil {
@@ -488,6 +1311,20 @@ func (imc *InstanceManagerController) syncLogSettingsToIMPod(im *longhorn.Instan
 func (imc *InstanceManagerController) handlePod(im *longhorn.InstanceManager) error {
 	log := getLoggerForInstanceManager(imc.logger, im)
 
+	if isInstanceManagerHeldStopped(im) {
+		// Tear down the pod and the monitor without recreating the pod
+		return imc.cleanupInstanceManager(im.Name, false)
+	}
+	if im.Spec.DesiredState == longhorn.InstanceManagerStateStopped {
+		imc.eventRecorder.Eventf(im, corev1.EventTypeNormal, constant.EventReasonStopping,
+			"Waiting for the running instances %v to be gone before stopping, unless it is annotated with %v: \"true\"",
+			types.GetRunningInstanceNames(im), types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix))
+	}
+
+	if err := imc.resetStableInstanceManagerPodRecreation(im); err != nil {
+		return err
+	}
+
 	err := imc.annotateCASafeToEvict(im)
 	if err != nil {
 		return err
@@ -510,7 +1347,16 @@ func (imc *InstanceManagerController) handlePod(im *longhorn.InstanceManager) er
 		return nil
 	}
 
-	if err := imc.cleanupInstanceManager(im.Name); err != nil {
+	if im.Status.CurrentState == longhorn.InstanceManagerStateError {
+		if retained, err := imc.retainFailedInstanceManagerPod(im); retained || err != nil {
+			return err
+		}
+		if imc.throttleInstanceManagerPodRecreation(im) {
+			return nil
+		}
+	}
+
+	if err := imc.cleanupInstanceManager(im.Name, imc.shouldForceDeleteInstanceManagerPod(im)); err != nil {
 		return err
 	}
 	// The instance manager pod should be created on the preferred node only.
@@ -524,13 +1370,257 @@ func (imc *InstanceManagerController) handlePod(im *longhorn.InstanceManager) er
 		return err
 	}
 
-	if err := imc.createInstanceManagerPod(im); err != nil {
+	if isImageReady, err := imc.checkInstanceManagerEngineImage(im); !isImageReady || err != nil {
 		return err
 	}
 
+	if canCreate, err := imc.canCreateInstanceManagerPod(im); !canCreate || err != nil {
+		return err
+	}
+
+	return imc.createInstanceManagerPod(im)
+}
+
+// retainFailedInstanceManagerPod returns true and requeues the instance manager in error state if its pod is retained
+// for the post-mortem. The pod is labeled once it starts being retained, and it is deleted after the retention period.
+func (imc *InstanceManagerController) retainFailedInstanceManagerPod(im *longhorn.InstanceManager) (bool, error) {
+	log := getLoggerForInstanceManager(imc.logger, im)
+
+	retentionSeconds, err := imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerFailedPodRetentionPeriod)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to get %v setting, will not retain the failed pod", types.SettingNameInstanceManagerFailedPodRetentionPeriod)
+		return false, nil
+	}
+	if retentionSeconds <= 0 {
+		return false, nil
+	}
+	retention := time.Duration(retentionSeconds) * time.Second
+
+	pod, err := imc.ds.GetPod(im.Name)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get pod for instance manager %v", im.Name)
+	}
+	if pod == nil || pod.DeletionTimestamp != nil {
+		return false, nil
+	}
+
+	retainedAtKey := types.GetLonghornLabelKey(types.InstanceManagerPodRetainedAtAnnotationKeySuffix)
+	retainedAt, err := time.Parse(time.RFC3339, pod.Annotations[retainedAtKey])
+	if err != nil {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Labels[types.GetLonghornLabelKey(types.LonghornLabelPostMortemRetained)] = "true"
+		pod.Annotations[retainedAtKey] = imc.clock.Now().UTC().Format(time.RFC3339)
+		if _, err := imc.ds.UpdatePod(pod); err != nil {
+			return false, errors.Wrapf(err, "failed to label the failed pod %v for the post-mortem", pod.Name)
+		}
+		log.Warnf("Retaining the failed pod %v for the post-mortem for %v before recreating it", pod.Name, retention)
+		imc.enqueueInstanceManagerAfter(im, retention)
+		return true, nil
+	}
+
+	if remaining := retention - imc.clock.Since(retainedAt); remaining > 0 {
+		imc.enqueueInstanceManagerAfter(im, remaining)
+		return true, nil
+	}
+	log.Infof("Deleting the failed pod %v retained for the post-mortem since %v", pod.Name, retainedAt)
+	return false, nil
+}
+
+// throttleInstanceManagerPodRecreation returns true and requeues the instance manager in error state if its pod
+// recreation is still in backoff, so a persistently broken node doesn't run into a tight create-crash loop.
+func (imc *InstanceManagerController) throttleInstanceManagerPodRecreation(im *longhorn.InstanceManager) bool {
+	imc.podRecreationLock.Lock()
+	defer imc.podRecreationLock.Unlock()
+
+	recreation, ok := imc.podRecreations[im.Name]
+	if !ok || (recreation.count < instanceManagerPodRecreationBackoffThreshold && !recreation.evicted) {
+		return false
+	}
+
+	backoff := instanceManagerPodRecreationBackoffInitialDuration
+	for i := instanceManagerPodRecreationBackoffThreshold; i < recreation.count && backoff < instanceManagerPodRecreationBackoffMaxDuration; i++ {
+		backoff *= 2
+	}
+	if backoff > instanceManagerPodRecreationBackoffMaxDuration {
+		backoff = instanceManagerPodRecreationBackoffMaxDuration
+	}
+	remaining := backoff - imc.clock.Since(recreation.lastRecreateTime)
+	if remaining <= 0 {
+		return false
+	}
+
+	if !recreation.throttled {
+		recreation.throttled = true
+		if recreation.evicted {
+			imc.eventRecorder.Eventf(im, corev1.EventTypeWarning, constant.EventReasonFailedStarting,
+				"Delaying the pod recreation for %v since the pod was evicted", backoff)
+		} else {
+			imc.eventRecorder.Eventf(im, corev1.EventTypeWarning, constant.EventReasonFailedStarting,
+				"Delaying the pod recreation for %v after %v consecutive failures", backoff, recreation.count)
+		}
+	}
+	imc.enqueueInstanceManagerAfter(im, remaining)
+	return true
+}
+
+func (imc *InstanceManagerController) recordInstanceManagerPodRecreation(imName string) {
+	imc.podRecreationLock.Lock()
+	defer imc.podRecreationLock.Unlock()
+
+	recreation, ok := imc.podRecreations[imName]
+	if !ok {
+		recreation = &instanceManagerPodRecreation{}
+		imc.podRecreations[imName] = recreation
+	}
+	recreation.count++
+	recreation.lastRecreateTime = imc.clock.Now()
+	recreation.throttled = false
+	recreation.evicted = false
+}
+
+// recordInstanceManagerPodEviction delays the next pod recreation from the time the eviction is found.
+func (imc *InstanceManagerController) recordInstanceManagerPodEviction(imName string) {
+	imc.podRecreationLock.Lock()
+	defer imc.podRecreationLock.Unlock()
+
+	recreation, ok := imc.podRecreations[imName]
+	if !ok {
+		recreation = &instanceManagerPodRecreation{}
+		imc.podRecreations[imName] = recreation
+	}
+	recreation.lastRecreateTime = imc.clock.Now()
+	recreation.throttled = false
+	recreation.evicted = true
+}
+
+func (imc *InstanceManagerController) resetInstanceManagerPodRecreation(imName string) {
+	imc.podRecreationLock.Lock()
+	defer imc.podRecreationLock.Unlock()
+
+	delete(imc.podRecreations, imName)
+}
+
+// resetStableInstanceManagerPodRecreation resets the pod recreation count once the instance manager keeps running
+// for the reset period.
+func (imc *InstanceManagerController) resetStableInstanceManagerPodRecreation(im *longhorn.InstanceManager) error {
+	if im.Status.CurrentState != longhorn.InstanceManagerStateRunning || im.Status.CurrentStateTransitionTime == "" {
+		return nil
+	}
+
+	imc.podRecreationLock.Lock()
+	_, ok := imc.podRecreations[im.Name]
+	imc.podRecreationLock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	runningTime, err := time.Parse(time.RFC3339, im.Status.CurrentStateTransitionTime)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the state transition time %v of instance manager %v", im.Status.CurrentStateTransitionTime, im.Name)
+	}
+	runningDuration := imc.clock.Since(runningTime)
+	if runningDuration < instanceManagerPodRecreationResetPeriod {
+		imc.enqueueInstanceManagerAfter(im, instanceManagerPodRecreationResetPeriod-runningDuration)
+		return nil
+	}
+	imc.resetInstanceManagerPodRecreation(im.Name)
 	return nil
 }
 
+// checkInstanceManagerEngineImage checks the engine image deployed with the instance manager image, so a pod doomed
+// to crash is not created. The instance manager is set to error if the engine image is incompatible, and the pod
+// creation is retried later if the engine image is not deployed yet. The images not deployed as engine images and the
+// v2 data engine instance managers are not checked.
+func (imc *InstanceManagerController) checkInstanceManagerEngineImage(im *longhorn.InstanceManager) (isImageReady bool, err error) {
+	if types.IsDataEngineV2(im.Spec.DataEngine) {
+		return true, nil
+	}
+
+	engineImages, err := imc.ds.ListEngineImages()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list engine images before creating instance manager pod")
+	}
+	for _, ei := range engineImages {
+		if ei.Spec.Image != im.Spec.Image {
+			continue
+		}
+
+		switch {
+		case ei.Status.Incompatible || ei.Status.State == longhorn.EngineImageStateIncompatible:
+			message := fmt.Sprintf("Engine image %v of image %v is incompatible", ei.Name, ei.Spec.Image)
+			if im.Status.Message != message {
+				imc.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonFailedStarting, message)
+			}
+			im.Status.CurrentState = longhorn.InstanceManagerStateError
+			im.Status.Message = message
+			return false, nil
+		case ei.Status.State != longhorn.EngineImageStateDeployed:
+			getLoggerForInstanceManager(imc.logger, im).Infof("Waiting for engine image %v in state %v to be deployed before creating instance manager pod", ei.Name, ei.Status.State)
+			imc.enqueueInstanceManagerAfter(im, instanceManagerEngineImageRequeueInterval)
+			return false, nil
+		}
+		return true, nil
+	}
+	return true, nil
+}
+
+// canCreateInstanceManagerPod returns false and requeues the instance manager if the number of the other starting
+// instance managers on the node reaches the concurrent pod creation limit.
+func (imc *InstanceManagerController) canCreateInstanceManagerPod(im *longhorn.InstanceManager) (bool, error) {
+	limit, err := imc.ds.GetSettingAsInt(types.SettingNameConcurrentInstanceManagerPodCreationPerNodeLimit)
+	if err != nil {
+		return false, err
+	}
+	if limit == 0 {
+		return true, nil
+	}
+
+	ims, err := imc.ds.ListInstanceManagersRO()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list instance managers before creating instance manager pod")
+	}
+	startingCount := int64(0)
+	for _, otherIM := range ims {
+		if otherIM.Name != im.Name && otherIM.Spec.NodeID == im.Spec.NodeID &&
+			otherIM.Status.CurrentState == longhorn.InstanceManagerStateStarting {
+			startingCount++
+		}
+	}
+	if startingCount >= limit {
+		getLoggerForInstanceManager(imc.logger, im).Infof("Deferring instance manager pod creation since %v instance manager pods are starting on node %v", startingCount, im.Spec.NodeID)
+		imc.enqueueInstanceManagerAfter(im, instanceManagerPodCreationRequeueInterval)
+		return false, nil
+	}
+	return true, nil
+}
+
+// isInstanceManagerPodAdoptable returns false if the pod is owned by another instance manager with the same name.
+func isInstanceManagerPodAdoptable(im *longhorn.InstanceManager, pod *corev1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == types.LonghornKindInstanceManager && ref.Name == im.Name {
+			return ref.UID == im.UID
+		}
+	}
+	return true
+}
+
+// isInstanceManagerHeldStopped returns true if the instance manager is deliberately held stopped for maintenance. The
+// instance manager keeps running until its instances are gone, unless it is annotated with the force deletion.
+func isInstanceManagerHeldStopped(im *longhorn.InstanceManager) bool {
+	if im.Spec.DesiredState != longhorn.InstanceManagerStateStopped {
+		return false
+	}
+	if im.Annotations[types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix)] == "true" {
+		return true
+	}
+	return len(types.GetRunningInstanceNames(im)) == 0
+}
+
 func (imc *InstanceManagerController) annotateCASafeToEvict(im *longhorn.InstanceManager) error {
 	pod, err := imc.ds.GetPod(im.Name)
 	if err != nil {
@@ -587,6 +1677,15 @@ func (imc *InstanceManagerController) areDangerZoneSettingsSyncedToIMPod(im *lon
 		return false, true, false, nil
 	}
 
+	// Like the danger zone settings, the pod running an outdated image is recreated only if no instance is running.
+	isImageSynced, err := imc.isInstanceManagerPodImageSynced(im, pod)
+	if err != nil {
+		return false, false, false, err
+	}
+	if !isImageSynced {
+		return false, false, false, nil
+	}
+
 	for settingName := range types.GetDangerZoneSettings() {
 		isSettingSynced := true
 		setting, err := imc.ds.GetSettingWithAutoFillingRO(settingName)
@@ -598,7 +1697,8 @@ func (imc *InstanceManagerController) areDangerZoneSettingsSyncedToIMPod(im *lon
 			isSettingSynced, err = imc.isSettingTaintTolerationSynced(setting, pod)
 		case types.SettingNameSystemManagedComponentsNodeSelector:
 			isSettingSynced, err = imc.isSettingNodeSelectorSynced(setting, pod)
-		case types.SettingNameGuaranteedInstanceManagerCPU, types.SettingNameV2DataEngineGuaranteedInstanceManagerCPU:
+		case types.SettingNameGuaranteedInstanceManagerCPU, types.SettingNameV2DataEngineGuaranteedInstanceManagerCPU,
+			types.SettingNameGuaranteedInstanceManagerCPUMaxDiskScale:
 			isSettingSynced, err = imc.isSettingGuaranteedInstanceManagerCPUSynced(setting, pod)
 		case types.SettingNamePriorityClass:
 			isSettingSynced, err = imc.isSettingPriorityClassSynced(setting, pod)
@@ -618,6 +1718,47 @@ func (imc *InstanceManagerController) areDangerZoneSettingsSyncedToIMPod(im *lon
 	return true, false, false, nil
 }
 
+// isInstanceManagerPodImageSynced checks if the pod runs the desired image, which may be updated in place after the
+// pod creation.
+func (imc *InstanceManagerController) isInstanceManagerPodImageSynced(im *longhorn.InstanceManager, pod *corev1.Pod) (bool, error) {
+	if len(pod.Spec.Containers) == 0 {
+		return true, nil
+	}
+	image, err := imc.getInstanceManagerPodImage(im)
+	if err != nil {
+		return false, err
+	}
+	if pod.Spec.Containers[0].Image == image {
+		return true, nil
+	}
+	getLoggerForInstanceManager(imc.logger, im).Infof("Instance manager pod image %v is different from the desired image %v",
+		pod.Spec.Containers[0].Image, image)
+	return false, nil
+}
+
+// getInstanceManagerPodImage returns the image of the first override matching the labels of the instance manager
+// node, or the instance manager image if no override matches.
+func (imc *InstanceManagerController) getInstanceManagerPodImage(im *longhorn.InstanceManager) (string, error) {
+	overrides, err := imc.ds.GetSettingInstanceManagerImageNodeOverrides()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %v setting", types.SettingNameInstanceManagerImageNodeOverrides)
+	}
+	if len(overrides) == 0 {
+		return im.Spec.Image, nil
+	}
+
+	kubeNode, err := imc.ds.GetKubernetesNodeRO(im.Spec.NodeID)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get Kubernetes node %v for the instance manager image override", im.Spec.NodeID)
+	}
+	for _, override := range overrides {
+		if value, ok := kubeNode.Labels[override.LabelKey]; ok && value == override.LabelValue {
+			return override.Image, nil
+		}
+	}
+	return im.Spec.Image, nil
+}
+
 func (imc *InstanceManagerController) isSettingTaintTolerationSynced(setting *longhorn.Setting, pod *corev1.Pod) (bool, error) {
 	newTolerationsList, err := types.UnmarshalTolerations(setting.Value)
 	if err != nil {
@@ -719,9 +1860,19 @@ func (imc *InstanceManagerController) syncMonitor(im *longhorn.InstanceManager)
 		engineapi.CheckInstanceManagerCompatibility(im.Status.APIMinVersion, im.Status.APIVersion) == nil
 
 	if isMonitorRequired {
+		// The instance map cannot be trusted until the new monitor establishes the instance watch and completes
+		// the initial poll.
+		if !imc.isMonitoring(im.Name) {
+			im.Status.APIReady = false
+		}
 		imc.startMonitoring(im)
 	} else {
+		im.Status.APIReady = false
 		imc.stopMonitoring(im.Name)
+		// The instances are gone or will soon be gone once the instance manager is no longer running
+		recordInstanceManagerInstanceMetrics(im, nil)
+		// The cached client is useless once the instance manager is no longer running, e.g., in error state.
+		imc.instanceManagerClientCache.invalidate(im.Name)
 	}
 
 	return nil
@@ -795,7 +1946,7 @@ func (imc *InstanceManagerController) syncInstanceManagerPDB(im *longhorn.Instan
 			if imPDB == nil {
 				return imc.createInstanceManagerPDB(im)
 			}
-			return nil
+			return imc.syncInstanceManagerPDBSelector(im, imPDB)
 		}
 
 		if imPDB != nil {
@@ -810,7 +1961,23 @@ func (imc *InstanceManagerController) syncInstanceManagerPDB(im *longhorn.Instan
 		return imc.createInstanceManagerPDB(im)
 	}
 
-	return nil
+	return imc.syncInstanceManagerPDBSelector(im, imPDB)
+}
+
+// syncInstanceManagerPDBSelector updates the selector of an existing PDB
+// created by an older version, so that it only matches the pod of this
+// instance manager.
+func (imc *InstanceManagerController) syncInstanceManagerPDBSelector(im *longhorn.InstanceManager, imPDB *policyv1.PodDisruptionBudget) error {
+	selector := getInstanceManagerPodSelector(im)
+	if reflect.DeepEqual(imPDB.Spec.Selector, selector) {
+		return nil
+	}
+
+	pdb := imPDB.DeepCopy()
+	pdb.Spec.Selector = selector
+	imc.logger.Infof("Updating the selector of %v PDB", pdb.Name)
+	_, err := imc.ds.UpdatePDB(pdb)
+	return err
 }
 
 func (imc *InstanceManagerController) cleanUpPDBForNonExistingIM() error {
@@ -1009,17 +2176,35 @@ func (imc *InstanceManagerController) generateInstanceManagerPDBManifest(im *lon
 			Namespace: imc.namespace,
 		},
 		Spec: policyv1.PodDisruptionBudgetSpec{
-			Selector: &metav1.LabelSelector{
-				MatchLabels: types.GetInstanceManagerLabels(im.Spec.NodeID, im.Spec.Image, im.Spec.Type, im.Spec.DataEngine),
-			},
+			Selector:     getInstanceManagerPodSelector(im),
 			MinAvailable: &intstr.IntOrString{IntVal: 1},
 		},
 	}
 }
 
-func (imc *InstanceManagerController) enqueueInstanceManager(instanceManager interface{}) {
-	key, err := controller.KeyFunc(instanceManager)
-	if err != nil {
+// getInstanceManagerPodSelector returns the selector matching only the pod of the instance manager,
+// and not the pods of the instance managers with other indexes on the same node.
+func getInstanceManagerPodSelector(im *longhorn.InstanceManager) *metav1.LabelSelector {
+	selector := &metav1.LabelSelector{
+		MatchLabels: types.GetInstanceManagerLabels(im.Spec.NodeID, im.Spec.Image, im.Spec.Type, im.Spec.DataEngine),
+	}
+	indexLabelKey := types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerIndex)
+	if im.Spec.Index > 0 {
+		selector.MatchLabels[indexLabelKey] = strconv.Itoa(im.Spec.Index)
+	} else {
+		selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+			{
+				Key:      indexLabelKey,
+				Operator: metav1.LabelSelectorOpDoesNotExist,
+			},
+		}
+	}
+	return selector
+}
+
+func (imc *InstanceManagerController) enqueueInstanceManager(instanceManager interface{}) {
+	key, err := controller.KeyFunc(instanceManager)
+	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("failed to get key for object %#v: %v", instanceManager, err))
 		return
 	}
@@ -1027,6 +2212,16 @@ func (imc *InstanceManagerController) enqueueInstanceManager(instanceManager int
 	imc.queue.Add(key)
 }
 
+func (imc *InstanceManagerController) enqueueInstanceManagerAfter(obj interface{}, duration time.Duration) {
+	key, err := controller.KeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get key for object %#v: %v", obj, err))
+		return
+	}
+
+	imc.queue.AddAfter(key, duration)
+}
+
 func (imc *InstanceManagerController) enqueueInstanceManagerPod(obj interface{}) {
 	pod, ok := obj.(*corev1.Pod)
 	if !ok {
@@ -1047,12 +2242,63 @@ func (imc *InstanceManagerController) enqueueInstanceManagerPod(obj interface{})
 	im, err := imc.ds.GetInstanceManagerRO(pod.Name)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			imc.enqueueOrphanedInstanceManagerPod(pod)
 			return
 		}
 		utilruntime.HandleError(fmt.Errorf("failed to get instance manager: %v", err))
 		return
 	}
-	imc.enqueueInstanceManager(im)
+	imc.enqueueInstanceManagerWithJitter(im)
+}
+
+// enqueueInstanceManagerWithJitter delays the enqueue by the debounce interval plus a random duration within the
+// configured jitter. The rapid pod events of a busy pod are collapsed into a single sync, and the instance managers
+// on a node are not synced in lockstep after a node-wide event changes all their pods.
+func (imc *InstanceManagerController) enqueueInstanceManagerWithJitter(im *longhorn.InstanceManager) {
+	maxJitter, err := imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerPodEventRequeueJitter)
+	if err != nil {
+		utilruntime.HandleError(errors.Wrapf(err, "failed to get %v setting, will enqueue instance manager %v without jitter", types.SettingNameInstanceManagerPodEventRequeueJitter, im.Name))
+		maxJitter = 0
+	}
+	delay := instanceManagerPodEventDebounceInterval
+	if maxJitter > 0 {
+		delay += time.Duration(rand.Int63n(maxJitter * int64(time.Millisecond)))
+	}
+
+	imc.enqueueInstanceManagerAfter(im, delay)
+}
+
+// enqueueOrphanedInstanceManagerPod enqueues the pod whose instance manager no longer exists.
+// The sync of the key then finds no instance manager and deletes the pod.
+func (imc *InstanceManagerController) enqueueOrphanedInstanceManagerPod(pod *corev1.Pod) {
+	if pod.DeletionTimestamp != nil || pod.Spec.NodeName != imc.controllerID {
+		return
+	}
+
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind != types.LonghornKindInstanceManager {
+			continue
+		}
+		im, err := imc.ds.GetInstanceManagerRO(ref.Name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				utilruntime.HandleError(fmt.Errorf("failed to get instance manager %v owning pod %v: %v", ref.Name, pod.Name, err))
+			}
+			continue
+		}
+		if im.UID == ref.UID {
+			imc.enqueueInstanceManager(im)
+			return
+		}
+	}
+
+	age := imc.clock.Since(pod.CreationTimestamp.Time)
+	if age < instanceManagerOrphanedPodCleanupGracePeriod {
+		imc.enqueueInstanceManagerAfter(pod, instanceManagerOrphanedPodCleanupGracePeriod-age)
+		return
+	}
+	imc.logger.WithField("pod", pod.Name).Info("Cannot find instance manager for pod, will clean up the orphaned pod")
+	imc.enqueueInstanceManager(pod)
 }
 
 func (imc *InstanceManagerController) enqueueKubernetesNode(obj interface{}) {
@@ -1107,14 +2353,76 @@ func (imc *InstanceManagerController) enqueueSettingChange(obj interface{}) {
 	imc.enqueueInstanceManagersForNode(imc.controllerID)
 }
 
-func (imc *InstanceManagerController) cleanupInstanceManager(imName string) error {
+// drainInstanceManager gracefully stops the instances of the deleting instance manager before the pod is deleted.
+// It is enabled by the graceful cleanup timeout annotation, and it only happens when the node is still healthy.
+// Once the timeout is exceeded, the instance manager is considered drained so that the pod will be deleted forcibly.
+func (imc *InstanceManagerController) drainInstanceManager(im *longhorn.InstanceManager) (drained bool, err error) {
+	log := getLoggerForInstanceManager(imc.logger, im)
+
+	timeoutValue, ok := im.Annotations[types.GetLonghornLabelKey(types.InstanceManagerGracefulCleanupTimeoutAnnotationKeySuffix)]
+	if !ok {
+		return true, nil
+	}
+	timeout, err := time.ParseDuration(timeoutValue)
+	if err != nil {
+		log.WithError(err).Warnf("Ignored the invalid graceful cleanup timeout %v", timeoutValue)
+		return true, nil
+	}
+
+	if im.Status.CurrentState != longhorn.InstanceManagerStateRunning {
+		return true, nil
+	}
+	isDown, err := imc.ds.IsNodeDownOrDeleted(im.Spec.NodeID)
+	if err != nil {
+		return false, err
+	}
+	if isDown {
+		return true, nil
+	}
+
+	if imc.clock.Since(im.DeletionTimestamp.Time) > timeout {
+		log.Warnf("Failed to gracefully stop the instances within %v, will forcibly clean up the instance manager", timeout)
+		return true, nil
+	}
+
+	instances := map[string]longhorn.InstanceProcess{}
+	// nolint:all
+	for name, instance := range types.ConsolidateInstances(im.Status.InstanceEngines, im.Status.InstanceReplicas, im.Status.Instances) {
+		if instance.Status.State != longhorn.InstanceStateStopped && instance.Status.State != longhorn.InstanceStateError {
+			instances[name] = instance
+		}
+	}
+	if len(instances) == 0 {
+		return true, nil
+	}
+
+	log.Infof("Gracefully stopping %v instances before cleaning up the instance manager", len(instances))
+	if err := imc.instancesStopper(im, instances); err != nil {
+		log.WithError(err).Warn("Failed to gracefully stop instances, will retry until the graceful cleanup timeout is exceeded")
+	}
+	return false, nil
+}
+
+// cleanupInstanceManager stops the monitor and deletes the instance manager pod. The pod is deleted without the
+// termination grace period if force is set, including the pod that is already terminating.
+func (imc *InstanceManagerController) cleanupInstanceManager(imName string, force bool) error {
 	imc.stopMonitoring(imName)
 
 	pod, err := imc.ds.GetPodRO(imc.namespace, imName)
 	if err != nil {
 		return err
 	}
-	if pod != nil && pod.DeletionTimestamp == nil {
+	if pod == nil {
+		return nil
+	}
+	if force {
+		if pod.DeletionTimestamp != nil && pod.DeletionGracePeriodSeconds != nil && *pod.DeletionGracePeriodSeconds == 0 {
+			return nil
+		}
+		imc.logger.Warnf("Forcibly deleting instance manager pod %v for instance manager %v", pod.Name, imName)
+		return imc.ds.DeletePodWithGracePeriod(pod.Name, 0)
+	}
+	if pod.DeletionTimestamp == nil {
 		imc.logger.Infof("Deleting instance manager pod %v for instance manager %v", pod.Name, imName)
 		if err := imc.ds.DeletePod(pod.Name); err != nil {
 			return err
@@ -1124,35 +2432,250 @@ func (imc *InstanceManagerController) cleanupInstanceManager(imName string) erro
 	return nil
 }
 
+// shouldForceDeleteInstanceManagerPod returns true if the pod of the instance manager in error state should be
+// deleted without the termination grace period for faster recovery.
+func (imc *InstanceManagerController) shouldForceDeleteInstanceManagerPod(im *longhorn.InstanceManager) bool {
+	if im.Status.CurrentState != longhorn.InstanceManagerStateError {
+		return false
+	}
+	forceDeletion, err := imc.ds.GetSettingAsBool(types.SettingNameInstanceManagerPodForceDeletionOnError)
+	if err != nil {
+		imc.logger.WithError(err).Warnf("Failed to get %v setting, will delete the pod of instance manager %v gracefully",
+			types.SettingNameInstanceManagerPodForceDeletionOnError, im.Name)
+		return false
+	}
+	return forceDeletion
+}
+
+// forceCleanupInstanceManager removes the finalizer of the deleting instance manager once it cannot be cleaned up for
+// longer than the force cleanup timeout since the deletion, e.g., the pod cannot be deleted or is stuck terminating
+// since the node is gone. Otherwise, the instance manager is requeued at the timeout and false is returned.
+func (imc *InstanceManagerController) forceCleanupInstanceManager(im *longhorn.InstanceManager, cause error) (bool, error) {
+	log := getLoggerForInstanceManager(imc.logger, im)
+
+	timeoutSeconds, err := imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerForceCleanupTimeout)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to get %v setting, will not forcibly clean up the instance manager", types.SettingNameInstanceManagerForceCleanupTimeout)
+		return false, nil
+	}
+	if timeoutSeconds <= 0 {
+		return false, nil
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if elapsed := imc.clock.Since(im.DeletionTimestamp.Time); elapsed <= timeout {
+		imc.enqueueInstanceManagerAfter(im, timeout-elapsed+time.Second)
+		return false, nil
+	}
+
+	log.WithError(cause).Warnf("Failed to clean up the instance manager within %v, will forcibly remove the finalizer", timeout)
+	if err := imc.ds.RemoveFinalizerForInstanceManager(im); err != nil {
+		return false, errors.Wrapf(err, "failed to forcibly remove the finalizer after the cleanup failure: %v", cause)
+	}
+	return true, nil
+}
+
+// CleanupInstanceManagersForNode tears down all instance managers of the node that is being decommissioned, i.e., the
+// Longhorn node is deleted. The pods are deleted without the termination grace period, then the instance managers are
+// annotated with the force deletion, their finalizers are removed, and they are deleted. The cleanup continues with the
+// remaining instance managers if any of them fails.
+func (imc *InstanceManagerController) CleanupInstanceManagersForNode(nodeID string) error {
+	ims, err := imc.ds.ListInstanceManagers()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list instance managers for node %v", nodeID)
+	}
+
+	names := []string{}
+	for name, im := range ims {
+		if im.Spec.NodeID == nodeID {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	cleanupErrs := util.NewMultiError()
+	for _, name := range names {
+		if err := imc.cleanupInstanceManagerForNode(ims[name]); err != nil {
+			cleanupErrs.Append(util.NewMultiError(err.Error()))
+		}
+	}
+	if len(cleanupErrs) > 0 {
+		return fmt.Errorf("failed to clean up instance managers for node %v: %v", nodeID, cleanupErrs.Join())
+	}
+	return nil
+}
+
+func (imc *InstanceManagerController) cleanupInstanceManagerForNode(im *longhorn.InstanceManager) error {
+	log := getLoggerForInstanceManager(imc.logger, im)
+	log.Infof("Cleaning up instance manager for the decommissioned node %v", im.Spec.NodeID)
+
+	if err := imc.cleanupInstanceManager(im.Name, true); err != nil {
+		return errors.Wrapf(err, "failed to clean up instance manager %v", im.Name)
+	}
+
+	// The webhook rejects the deletion of the instance manager with the running instances otherwise
+	forceDeletionKey := types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix)
+	if im.Annotations[forceDeletionKey] != "true" {
+		if im.Annotations == nil {
+			im.Annotations = map[string]string{}
+		}
+		im.Annotations[forceDeletionKey] = "true"
+		updatedIM, err := imc.ds.UpdateInstanceManager(im)
+		if err != nil {
+			return errors.Wrapf(err, "failed to annotate instance manager %v with the force deletion", im.Name)
+		}
+		im = updatedIM
+	}
+
+	if err := imc.ds.RemoveFinalizerForInstanceManager(im); err != nil {
+		return err
+	}
+	if im.DeletionTimestamp == nil {
+		if err := imc.ds.DeleteInstanceManager(im.Name); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete instance manager %v", im.Name)
+		}
+	}
+	return nil
+}
+
+// getInstanceManagerPodIP returns the IP the instance manager is reached by. The node IP is used for the pod on the
+// host network, in case the pod IP is not reported or differs from the node IP on multi-homed nodes.
+func (imc *InstanceManagerController) getInstanceManagerPodIP(pod *corev1.Pod) (string, error) {
+	if !pod.Spec.HostNetwork {
+		return pod.Status.PodIP, nil
+	}
+
+	kubeNode, err := imc.ds.GetKubernetesNodeRO(pod.Spec.NodeName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get node %v for instance manager pod %v on host network", pod.Spec.NodeName, pod.Name)
+	}
+	for _, address := range kubeNode.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP && address.Address != "" {
+			return address.Address, nil
+		}
+	}
+	if pod.Status.HostIP != "" {
+		return pod.Status.HostIP, nil
+	}
+	return "", fmt.Errorf("failed to find the IP of node %v for instance manager pod %v on host network", pod.Spec.NodeName, pod.Name)
+}
+
 func (imc *InstanceManagerController) createInstanceManagerPod(im *longhorn.InstanceManager) error {
 	log := getLoggerForInstanceManager(imc.logger, im)
 
+	podSpec, err := imc.BuildInstanceManagerPodSpec(im)
+	if err != nil {
+		return err
+	}
+	// The pod labels keep the instance manager image, so only the container image is overridden
+	image, err := imc.getInstanceManagerPodImage(im)
+	if err != nil {
+		return err
+	}
+	podSpec.Spec.Containers[0].Image = image
+
+	dryRun, err := imc.ds.GetSettingAsBool(types.SettingNameInstanceManagerPodCreationDryRun)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %v setting before creating instance manager pod", types.SettingNameInstanceManagerPodCreationDryRun)
+	}
+	if dryRun {
+		podYAML, err := getPodYAML(podSpec)
+		if err != nil {
+			return errors.Wrap(err, "failed to print instance manager pod in dry-run mode")
+		}
+		if imc.observeDryRunPodSpec(im.Name, podYAML) {
+			log.Infof("Skipped creating instance manager pod in dry-run mode, pod:\n%v", podYAML)
+		} else {
+			log.Debug("Skipped creating instance manager pod in dry-run mode, the pod is unchanged")
+		}
+		return nil
+	}
+	imc.observeDryRunPodSpec(im.Name, "")
+
+	log.Info("Creating instance manager pod")
+	if _, err := imc.ds.CreatePod(podSpec); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		if err := imc.adoptOrReplaceLeftoverInstanceManagerPod(im, podSpec); err != nil {
+			return err
+		}
+	}
+	if im.Status.CurrentState == longhorn.InstanceManagerStateError {
+		imc.recordInstanceManagerPodRecreation(im.Name)
+	}
+
+	return nil
+}
+
+// adoptOrReplaceLeftoverInstanceManagerPod handles the pod with the instance manager name left over from a prior
+// crash, which the informer cache may not have caught up with. The healthy pod is adopted so the instance manager
+// transitions toward running with the next sync, otherwise the pod is deleted and recreated.
+func (imc *InstanceManagerController) adoptOrReplaceLeftoverInstanceManagerPod(im *longhorn.InstanceManager, podSpec *corev1.Pod) error {
+	log := getLoggerForInstanceManager(imc.logger, im)
+
+	pod, err := imc.ds.GetPodFromAPIServer(podSpec.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// The leftover pod is gone in the meantime
+			_, err = imc.ds.CreatePod(podSpec)
+		}
+		return errors.Wrapf(err, "failed to handle the existing instance manager pod %v", podSpec.Name)
+	}
+
+	if isLeftoverInstanceManagerPodHealthy(im, pod) {
+		log.Infof("Adopted the existing instance manager pod in phase %v", pod.Status.Phase)
+		imc.enqueueInstanceManager(im)
+		return nil
+	}
+
+	log.Infof("Replacing the existing instance manager pod in phase %v on node %v", pod.Status.Phase, pod.Spec.NodeName)
+	if pod.DeletionTimestamp == nil {
+		if err := imc.ds.DeletePod(pod.Name); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the existing instance manager pod %v", pod.Name)
+		}
+	}
+	if _, err := imc.ds.CreatePod(podSpec); err != nil {
+		// The pod is recreated with the retry once the deletion completes
+		return errors.Wrapf(err, "failed to recreate instance manager pod %v", podSpec.Name)
+	}
+	return nil
+}
+
+func isLeftoverInstanceManagerPodHealthy(im *longhorn.InstanceManager, pod *corev1.Pod) bool {
+	if !isInstanceManagerPodAdoptable(im, pod) || pod.DeletionTimestamp != nil || pod.Spec.NodeName != im.Spec.NodeID {
+		return false
+	}
+	return pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodRunning
+}
+
+// BuildInstanceManagerPodSpec builds the pod the controller creates for the instance manager. This helps validate the
+// settings applied to the pod.
+func (imc *InstanceManagerController) BuildInstanceManagerPodSpec(im *longhorn.InstanceManager) (*corev1.Pod, error) {
 	tolerations, err := imc.ds.GetSettingTaintToleration()
 	if err != nil {
-		return errors.Wrap(err, "failed to get taint toleration setting before creating instance manager pod")
+		return nil, errors.Wrap(err, "failed to get taint toleration setting before creating instance manager pod")
 	}
 
 	nodeSelector, err := imc.ds.GetSettingSystemManagedComponentsNodeSelector()
 	if err != nil {
-		return errors.Wrap(err, "failed to get node selector setting before creating instance manager pod")
+		return nil, errors.Wrap(err, "failed to get node selector setting before creating instance manager pod")
 	}
 
 	registrySecretSetting, err := imc.ds.GetSettingWithAutoFillingRO(types.SettingNameRegistrySecret)
 	if err != nil {
-		return errors.Wrap(err, "failed to get registry secret setting before creating instance manager pod")
+		return nil, errors.Wrap(err, "failed to get registry secret setting before creating instance manager pod")
 	}
 
 	registrySecret := registrySecretSetting.Value
 
-	var podSpec *corev1.Pod
-	podSpec, err = imc.createInstanceManagerPodSpec(im, tolerations, registrySecret, nodeSelector, im.Spec.DataEngine)
+	podSpec, err := imc.createInstanceManagerPodSpec(im, tolerations, registrySecret, nodeSelector, im.Spec.DataEngine)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	storageNetwork, err := imc.ds.GetSettingWithAutoFillingRO(types.SettingNameStorageNetwork)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	nadAnnot := string(types.CNIAnnotationNetworks)
@@ -1160,14 +2683,53 @@ func (imc *InstanceManagerController) createInstanceManagerPod(im *longhorn.Inst
 		podSpec.Annotations[nadAnnot] = types.CreateCniAnnotationFromSetting(storageNetwork)
 	}
 
-	log.Info("Creating instance manager pod")
-	if _, err := imc.ds.CreatePod(podSpec); err != nil {
-		if apierrors.IsAlreadyExists(err) {
-			return nil
-		}
+	if err := imc.addInstanceManagerPodCustomMetadata(podSpec); err != nil {
+		return nil, errors.Wrap(err, "failed to add custom metadata before creating instance manager pod")
+	}
+
+	return podSpec, nil
+}
+
+func getPodYAML(pod *corev1.Pod) (string, error) {
+	pod = pod.DeepCopy()
+	pod.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+
+	buf := &bytes.Buffer{}
+	printer := printers.YAMLPrinter{}
+	if err := printer.PrintObj(pod, buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// addInstanceManagerPodCustomMetadata adds the labels and annotations specified by the settings to the pod,
+// without overwriting the ones set by Longhorn.
+func (imc *InstanceManagerController) addInstanceManagerPodCustomMetadata(pod *corev1.Pod) error {
+	labels, err := imc.ds.GetSettingInstanceManagerPodLabels()
+	if err != nil {
+		return err
+	}
+	annotations, err := imc.ds.GetSettingInstanceManagerPodAnnotations()
+	if err != nil {
 		return err
 	}
 
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	for key, value := range labels {
+		if _, exists := pod.Labels[key]; !exists {
+			pod.Labels[key] = value
+		}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		if _, exists := pod.Annotations[key]; !exists {
+			pod.Annotations[key] = value
+		}
+	}
 	return nil
 }
 
@@ -1187,6 +2749,11 @@ func (imc *InstanceManagerController) createGenericManagerPodSpec(im *longhorn.I
 		return nil, err
 	}
 
+	terminationGracePeriodSeconds, err := imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerPodTerminationGracePeriod)
+	if err != nil {
+		return nil, err
+	}
+
 	privileged := true
 	podSpec := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1209,8 +2776,9 @@ func (imc *InstanceManagerController) createGenericManagerPodSpec(im *longhorn.I
 					},
 				},
 			},
-			NodeName:      im.Spec.NodeID,
-			RestartPolicy: corev1.RestartPolicyNever,
+			NodeName:                      im.Spec.NodeID,
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
 		},
 	}
 
@@ -1222,6 +2790,57 @@ func (imc *InstanceManagerController) createGenericManagerPodSpec(im *longhorn.I
 		}
 	}
 
+	if err := imc.addEngineImageDigestAnnotation(im, podSpec); err != nil {
+		return nil, err
+	}
+
+	hostNetwork, err := imc.ds.GetSettingAsBool(types.SettingNameInstanceManagerHostNetwork)
+	if err != nil {
+		return nil, err
+	}
+	if hostNetwork {
+		podSpec.Spec.HostNetwork = true
+		// Keep resolving the cluster services with the host network
+		podSpec.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	dnsPolicy, dnsConfig, err := imc.ds.GetSettingInstanceManagerPodDNSConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %v setting", types.SettingNameInstanceManagerPodDNSConfig)
+	}
+	if dnsPolicy != "" {
+		podSpec.Spec.DNSPolicy = dnsPolicy
+	}
+	podSpec.Spec.DNSConfig = dnsConfig
+
+	// The pods of the same instance manager type are spread across the failure domains
+	topologySpreadConstraints, err := imc.ds.GetSettingInstanceManagerPodTopologySpreadConstraints()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %v setting", types.SettingNameInstanceManagerPodTopologySpreadConstraints)
+	}
+	for i := range topologySpreadConstraints {
+		topologySpreadConstraints[i].LabelSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				types.GetLonghornLabelComponentKey():                              types.LonghornLabelInstanceManager,
+				types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerType): string(im.Spec.Type),
+			},
+		}
+	}
+	podSpec.Spec.TopologySpreadConstraints = topologySpreadConstraints
+
+	// The v2 data engine requires the privileged mode for accessing the devices directly
+	capabilities, err := imc.ds.GetSettingInstanceManagerPodCapabilities()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %v setting", types.SettingNameInstanceManagerPodCapabilities)
+	}
+	if len(capabilities) != 0 && !types.IsDataEngineV2(im.Spec.DataEngine) {
+		unprivileged := false
+		podSpec.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+			Privileged:   &unprivileged,
+			Capabilities: &corev1.Capabilities{Add: capabilities},
+		}
+	}
+
 	// Apply resource requirements to newly created Instance Manager Pods.
 	cpuResourceReq, err := GetInstanceManagerCPURequirement(imc.ds, im.Name)
 	if err != nil {
@@ -1235,14 +2854,39 @@ func (imc *InstanceManagerController) createGenericManagerPodSpec(im *longhorn.I
 	return podSpec, nil
 }
 
+// addEngineImageDigestAnnotation annotates the pod with the digest of the image for auditing. The annotation is skipped
+// if the image is not deployed as an engine image or the digest is not resolved yet.
+func (imc *InstanceManagerController) addEngineImageDigestAnnotation(im *longhorn.InstanceManager, pod *corev1.Pod) error {
+	engineImages, err := imc.ds.ListEngineImages()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list engine images for the image digest of instance manager %v", im.Name)
+	}
+	for _, ei := range engineImages {
+		if ei.Spec.Image == im.Spec.Image && ei.Status.ImageDigest != "" {
+			pod.Annotations[types.GetLonghornLabelKey(types.EngineImageDigestAnnotationKeySuffix)] = ei.Status.ImageDigest
+			return nil
+		}
+	}
+	return nil
+}
+
 func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.InstanceManager, tolerations []corev1.Toleration, registrySecret string, nodeSelector map[string]string, dataEngine longhorn.DataEngineType) (*corev1.Pod, error) {
 	podSpec, err := imc.createGenericManagerPodSpec(im, tolerations, registrySecret, nodeSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	secretIsOptional := true
+	// The pod doesn't start without the TLS secret if the TLS connection is required
+	tlsRequired, err := imc.ds.GetSettingAsBool(types.SettingNameInstanceManagerGRPCTLSRequired)
+	if err != nil {
+		return nil, err
+	}
+	secretIsOptional := !tlsRequired
+	port := engineapi.GetInstanceManagerProcessManagerServicePort(im)
 	podSpec.ObjectMeta.Labels = types.GetInstanceManagerLabels(imc.controllerID, im.Spec.Image, longhorn.InstanceManagerTypeAllInOne, dataEngine)
+	if im.Spec.Index > 0 {
+		podSpec.ObjectMeta.Labels[types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerIndex)] = strconv.Itoa(im.Spec.Index)
+	}
 	podSpec.Spec.Containers[0].Name = "instance-manager"
 
 	if types.IsDataEngineV2(dataEngine) {
@@ -1260,7 +2904,7 @@ func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.
 
 		args := []string{
 			"instance-manager", "--spdk-log", logFlags, "--enable-spdk", "--debug",
-			"daemon", "--spdk-enabled", "--listen", fmt.Sprintf("0.0.0.0:%d", engineapi.InstanceManagerProcessManagerServiceDefaultPort)}
+			"daemon", "--spdk-enabled", "--listen", fmt.Sprintf("0.0.0.0:%d", port)}
 
 		podSpec.Spec.Containers[0].Args = args
 
@@ -1280,44 +2924,57 @@ func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.
 		podSpec.Spec.Containers[0].Resources.Limits[corev1.ResourceName("hugepages-2Mi")] = resource.MustParse(fmt.Sprintf("%vMi", hugepage))
 	} else {
 		podSpec.Spec.Containers[0].Args = []string{
-			"instance-manager", "--debug", "daemon", "--listen", fmt.Sprintf("0.0.0.0:%d", engineapi.InstanceManagerProcessManagerServiceDefaultPort),
+			"instance-manager", "--debug", "daemon", "--listen", fmt.Sprintf("0.0.0.0:%d", port),
 		}
 	}
 
-	// Create a liveness probe to check if all the required ports and processes are open.
+	extraArgs, err := imc.ds.GetSettingInstanceManagerExtraArgs()
+	if err != nil {
+		return nil, err
+	}
+	podSpec.Spec.Containers[0].Args = append(podSpec.Spec.Containers[0].Args, extraArgs...)
+
+	// Create a liveness probe to check if all the required ports and processes are open.
 	var livenessProbes []string
 	ports := []int{
-		engineapi.InstanceManagerProcessManagerServiceDefaultPort,
-		engineapi.InstanceManagerProxyServiceDefaultPort,
-		engineapi.InstanceManagerDiskServiceDefaultPort,
-		engineapi.InstanceManagerInstanceServiceDefaultPort,
+		port,
+		engineapi.GetInstanceManagerProxyServicePort(im),
+		engineapi.GetInstanceManagerDiskServicePort(im),
+		engineapi.GetInstanceManagerInstanceServicePort(im),
 	}
 	for _, port := range ports {
 		livenessProbes = append(livenessProbes, fmt.Sprintf("nc -zv localhost %d > /dev/null 2>&1", port))
 	}
 	if types.IsDataEngineV2(dataEngine) {
-		livenessProbes = append(livenessProbes, fmt.Sprintf("nc -zv localhost %d > /dev/null 2>&1", engineapi.InstanceManagerSpdkServiceDefaultPort))
+		livenessProbes = append(livenessProbes, fmt.Sprintf("nc -zv localhost %d > /dev/null 2>&1", engineapi.GetInstanceManagerSpdkServicePort(im)))
 
 		processProbe := "[ $(ps aux | grep 'spdk_tgt' | grep -v 'grep' | grep -v 'tee' | wc -l) != 0 ]"
 		livenessProbes = append(livenessProbes, processProbe)
 	}
 	livenessProbeCommand := fmt.Sprintf("test $(%s; echo $?) -eq 0", strings.Join(livenessProbes, " && "))
 
-	podSpec.Spec.Containers[0].LivenessProbe = &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			Exec: &corev1.ExecAction{
-				Command: []string{
-					"/bin/sh",
-					"-c",
-					livenessProbeCommand,
-				},
+	livenessProbe, err := imc.ds.GetSettingInstanceManagerPodLivenessProbe()
+	if err != nil {
+		return nil, err
+	}
+	livenessProbe.ProbeHandler = corev1.ProbeHandler{
+		Exec: &corev1.ExecAction{
+			Command: []string{
+				"/bin/sh",
+				"-c",
+				livenessProbeCommand,
 			},
 		},
-		InitialDelaySeconds: datastore.PodProbeInitialDelay,
-		TimeoutSeconds:      datastore.PodProbeTimeoutSeconds,
-		PeriodSeconds:       datastore.PodProbePeriodSeconds,
-		FailureThreshold:    datastore.PodLivenessProbeFailureThreshold,
 	}
+	podSpec.Spec.Containers[0].LivenessProbe = livenessProbe
+
+	// The startup probe checks the same services, and suspends the liveness probe until the instance manager starts
+	startupProbe, err := imc.ds.GetSettingInstanceManagerPodStartupProbe()
+	if err != nil {
+		return nil, err
+	}
+	startupProbe.ProbeHandler = *livenessProbe.ProbeHandler.DeepCopy()
+	podSpec.Spec.Containers[0].StartupProbe = startupProbe
 
 	// Set environment variables
 	podSpec.Spec.Containers[0].Env = []corev1.EnvVar{
@@ -1334,10 +2991,15 @@ func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.
 			},
 		},
 	}
+	// The narrower host path keeps the same path under /host, where the instance manager accesses the host files
+	hostMountPath, err := imc.ds.GetSettingInstanceManagerPodHostMountPath()
+	if err != nil {
+		return nil, err
+	}
 	// Set volume mounts
 	podSpec.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
 		{
-			MountPath:        "/host",
+			MountPath:        filepath.Join("/host", hostMountPath),
 			Name:             "host",
 			MountPropagation: &mountPropagationHostToContainer,
 		},
@@ -1360,7 +3022,7 @@ func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.
 			Name: "host",
 			VolumeSource: corev1.VolumeSource{
 				HostPath: &corev1.HostPathVolumeSource{
-					Path: "/",
+					Path: hostMountPath,
 				},
 			},
 		},
@@ -1391,6 +3053,27 @@ func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.
 		},
 	}
 
+	if hostMountPath != "/" {
+		// The engines access the host namespaces via /host/proc and the devices via /host/dev,
+		// which are out of the narrower host path.
+		for _, dir := range types.InstanceManagerPodRequiredHostDirectories {
+			name := "host" + strings.ReplaceAll(dir, "/", "-")
+			podSpec.Spec.Containers[0].VolumeMounts = append(podSpec.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+				MountPath:        filepath.Join("/host", dir),
+				Name:             name,
+				MountPropagation: &mountPropagationHostToContainer,
+			})
+			podSpec.Spec.Volumes = append(podSpec.Spec.Volumes, corev1.Volume{
+				Name: name,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: dir,
+					},
+				},
+			})
+		}
+	}
+
 	if types.IsDataEngineV2(dataEngine) {
 		podSpec.Spec.Containers[0].VolumeMounts = append(podSpec.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
 			MountPath: "/hugepages",
@@ -1406,13 +3089,58 @@ func (imc *InstanceManagerController) createInstanceManagerPodSpec(im *longhorn.
 			},
 		})
 	}
+
+	if err := imc.addInstanceManagerPodExtraHostPathMounts(im, podSpec); err != nil {
+		return nil, err
+	}
 	types.AddGoCoverDirToPod(podSpec)
 
 	return podSpec, nil
 }
 
+// addInstanceManagerPodExtraHostPathMounts appends the host path mounts configured by the setting to the instance
+// manager container. The mounts conflicting with the ones required by the instance manager are skipped.
+func (imc *InstanceManagerController) addInstanceManagerPodExtraHostPathMounts(im *longhorn.InstanceManager, pod *corev1.Pod) error {
+	mounts, err := imc.ds.GetSettingInstanceManagerPodExtraHostPathMounts()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %v setting", types.SettingNameInstanceManagerPodExtraHostPathMounts)
+	}
+
+	container := &pod.Spec.Containers[0]
+	mountPaths := map[string]struct{}{}
+	for _, volumeMount := range container.VolumeMounts {
+		mountPaths[volumeMount.MountPath] = struct{}{}
+	}
+	for i, mount := range mounts {
+		if _, exists := mountPaths[mount.MountPath]; exists {
+			getLoggerForInstanceManager(imc.logger, im).Warnf("Skipped extra host path mount %v:%v since the mount path is used by the instance manager", mount.HostPath, mount.MountPath)
+			continue
+		}
+
+		name := fmt.Sprintf("extra-host-path-%d", i)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			MountPath:        mount.MountPath,
+			Name:             name,
+			MountPropagation: &mountPropagationHostToContainer,
+		})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: mount.HostPath,
+				},
+			},
+		})
+	}
+	return nil
+}
+
 func (imc *InstanceManagerController) startMonitoring(im *longhorn.InstanceManager) {
 	log := imc.logger.WithField("instance manager", im.Name)
+	// The monitor logs with its own logger, so the level can be raised for the instance manager only
+	baseLogger := log.Logger
+	levelLogger := newDerivedLogger(baseLogger, getInstanceManagerLogLevel(baseLogger, im))
+	log = levelLogger.WithFields(log.Data)
 
 	if im.Status.IP == "" {
 		log.Errorf("IP is not set before monitoring")
@@ -1427,7 +3155,7 @@ func (imc *InstanceManagerController) startMonitoring(im *longhorn.InstanceManag
 	}
 
 	// TODO: #2441 refactor this when we do the resource monitoring refactor
-	client, err := engineapi.NewInstanceManagerClient(im)
+	client, err := imc.instanceManagerClientCache.get(im)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to initialize im client to %v before monitoring", im.Name)
 		return
@@ -1438,6 +3166,7 @@ func (imc *InstanceManagerController) startMonitoring(im *longhorn.InstanceManag
 	monitor := &InstanceManagerMonitor{
 		logger:                 log,
 		Name:                   im.Name,
+		ip:                     im.Status.IP,
 		controllerID:           imc.controllerID,
 		ds:                     imc.ds,
 		lock:                   &sync.RWMutex{},
@@ -1447,23 +3176,90 @@ func (imc *InstanceManagerController) startMonitoring(im *longhorn.InstanceManag
 		// notify monitor to update the instance map
 		updateNotification: true,
 		client:             client,
+		instanceLister:     client.InstanceList,
+		watcher:            client.InstanceWatch,
+		clock:              imc.clock,
+		eventRecorder:      imc.eventRecorder,
+		levelLogger:        levelLogger,
+		baseLogger:         baseLogger,
 
 		nodeCallback: imc.enqueueInstanceManagersForNode,
+
+		watchEstablishmentBackoff: flowcontrol.NewBackOff(instanceManagerWatchEstablishmentBackoffInitialDuration,
+			imc.getInstanceManagerWatchMaxBackoff(types.SettingNameInstanceManagerWatchEstablishmentMaxBackoff, instanceManagerWatchEstablishmentBackoffMaxDuration)),
+		watchBackoff: flowcontrol.NewBackOff(instanceManagerWatchReceiveBackoffInitialDuration,
+			imc.getInstanceManagerWatchMaxBackoff(types.SettingNameInstanceManagerWatchReceiveMaxBackoff, instanceManagerWatchReceiveBackoffMaxDuration)),
 	}
 
 	imc.instanceManagerMonitorMap[im.Name] = stopCh
+	imc.instanceManagerMonitors[im.Name] = monitor
 
 	go monitor.Run()
 
 	go func() {
 		<-monitorVoluntaryStopCh
-		client.Close()
 		imc.instanceManagerMonitorMutex.Lock()
-		delete(imc.instanceManagerMonitorMap, im.Name)
+		// The detached monitor is replaced by a new one already
+		if imc.instanceManagerMonitors[im.Name] == monitor {
+			delete(imc.instanceManagerMonitorMap, im.Name)
+			delete(imc.instanceManagerMonitors, im.Name)
+		}
 		imc.instanceManagerMonitorMutex.Unlock()
 	}()
 }
 
+// getInstanceManagerWatchMaxBackoff returns the max backoff duration of the instance watch configured by the setting.
+func (imc *InstanceManagerController) getInstanceManagerWatchMaxBackoff(settingName types.SettingName, defaultDuration time.Duration) time.Duration {
+	seconds, err := imc.ds.GetSettingAsInt(settingName)
+	if err != nil {
+		imc.logger.WithError(err).Warnf("Failed to get %v setting, will use the default max backoff %v", settingName, defaultDuration)
+		return defaultDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// reconcileMonitors stops the monitors of the instance managers that no longer exist or are no longer owned by
+// this controller, and enqueues the running instance managers that are not monitored so the syncs restart monitoring.
+func (imc *InstanceManagerController) reconcileMonitors() {
+	ims, err := imc.ds.ListInstanceManagersRO()
+	if err != nil {
+		imc.logger.WithError(err).Warn("Failed to list instance managers for reconciling the monitors")
+		return
+	}
+
+	imc.instanceManagerMonitorMutex.Lock()
+	monitoredIMs := map[string]bool{}
+	for imName := range imc.instanceManagerMonitorMap {
+		monitoredIMs[imName] = true
+	}
+	imc.instanceManagerMonitorMutex.Unlock()
+
+	for imName := range monitoredIMs {
+		if im, ok := ims[imName]; !ok || im.Status.OwnerID != imc.controllerID {
+			imc.logger.WithField("instanceManager", imName).Info("Stopping the stale instance manager monitor")
+			imc.stopMonitoring(imName)
+		}
+	}
+
+	for imName, im := range ims {
+		if im.Status.OwnerID != imc.controllerID || im.Status.CurrentState != longhorn.InstanceManagerStateRunning {
+			continue
+		}
+		if !monitoredIMs[imName] {
+			imc.logger.WithField("instanceManager", imName).Info("Enqueuing the running instance manager without a monitor")
+			imc.enqueueInstanceManager(im)
+		}
+	}
+}
+
+func (imc *InstanceManagerController) isMonitoring(imName string) bool {
+	imc.instanceManagerMonitorMutex.Lock()
+	defer imc.instanceManagerMonitorMutex.Unlock()
+
+	_, ok := imc.instanceManagerMonitorMap[imName]
+	return ok
+}
+
 func (imc *InstanceManagerController) stopMonitoring(imName string) {
 	imc.instanceManagerMonitorMutex.Lock()
 	defer imc.instanceManagerMonitorMutex.Unlock()
@@ -1482,13 +3278,203 @@ func (imc *InstanceManagerController) stopMonitoring(imName string) {
 
 }
 
+// detachMonitoring stops the monitor and forgets it right away rather than after it exits, so that a new monitor can be
+// started before the stopped one exits.
+func (imc *InstanceManagerController) detachMonitoring(imName string) {
+	imc.stopMonitoring(imName)
+
+	imc.instanceManagerMonitorMutex.Lock()
+	defer imc.instanceManagerMonitorMutex.Unlock()
+
+	delete(imc.instanceManagerMonitorMap, imName)
+	delete(imc.instanceManagerMonitors, imName)
+}
+
+// GetInstanceManagerWatchStatuses returns the instance watch health of the instance managers monitored by this
+// controller, which surfaces the disconnected watches of the running monitors.
+func (imc *InstanceManagerController) GetInstanceManagerWatchStatuses() map[string]InstanceManagerWatchStatus {
+	imc.instanceManagerMonitorMutex.Lock()
+	defer imc.instanceManagerMonitorMutex.Unlock()
+
+	now := imc.clock.Now()
+	statuses := map[string]InstanceManagerWatchStatus{}
+	for name, monitor := range imc.instanceManagerMonitors {
+		statuses[name] = monitor.getWatchStatus(now)
+	}
+	return statuses
+}
+
+// InstanceManagerWatchHealthHandler reports the instance watch health in JSON. The status code is 503 if any watch
+// is disconnected and not re-established yet.
+func (imc *InstanceManagerController) InstanceManagerWatchHealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		statuses := imc.GetInstanceManagerWatchStatuses()
+		statusCode := http.StatusOK
+		for _, status := range statuses {
+			if status.Stale {
+				statusCode = http.StatusServiceUnavailable
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		if err := json.NewEncoder(w).Encode(statuses); err != nil {
+			imc.logger.WithError(err).Warn("Failed to write instance manager watch statuses")
+		}
+	})
+}
+
+// GetInstanceManagerProcessLists returns the instance processes of the instance managers owned by this controller.
+// They're read from the informer cache, so the monitoring tools don't have to hit the Kubernetes API.
+func (imc *InstanceManagerController) GetInstanceManagerProcessLists() (map[string]InstanceManagerProcessList, error) {
+	ims, err := imc.ds.ListInstanceManagersRO()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list instance managers")
+	}
+
+	processLists := map[string]InstanceManagerProcessList{}
+	for name, im := range ims {
+		if im.Status.OwnerID != imc.controllerID {
+			continue
+		}
+		processLists[name] = InstanceManagerProcessList{
+			NodeID:           im.Spec.NodeID,
+			CurrentState:     im.Status.CurrentState,
+			InstanceEngines:  im.Status.InstanceEngines,
+			InstanceReplicas: im.Status.InstanceReplicas,
+			Instances:        im.Status.Instances,
+		}
+	}
+	return processLists, nil
+}
+
+// InstanceManagerProcessListHandler reports the instance processes of the instance managers owned by this controller
+// in JSON.
+func (imc *InstanceManagerController) InstanceManagerProcessListHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		processLists, err := imc.GetInstanceManagerProcessLists()
+		if err != nil {
+			imc.logger.WithError(err).Warn("Failed to get instance manager process lists")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(processLists); err != nil {
+			imc.logger.WithError(err).Warn("Failed to write instance manager process lists")
+		}
+	})
+}
+
+// RefreshInstanceManager enqueues the instance manager and polls its instances immediately if it's being monitored,
+// so that the instance map is refreshed without waiting for the next poll or the informer resync.
+// It's safe to call concurrently with the monitor since the polls are serialized.
+func (imc *InstanceManagerController) RefreshInstanceManager(name string) error {
+	im, err := imc.ds.GetInstanceManagerRO(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get instance manager %v for refreshing", name)
+	}
+	if im.Status.OwnerID != imc.controllerID {
+		return fmt.Errorf("cannot refresh instance manager %v owned by %v on node %v", name, im.Status.OwnerID, imc.controllerID)
+	}
+
+	imc.enqueueInstanceManager(im)
+
+	imc.instanceManagerMonitorMutex.Lock()
+	monitor, ok := imc.instanceManagerMonitors[name]
+	imc.instanceManagerMonitorMutex.Unlock()
+	if !ok || monitor.CheckMonitorStoppedWithLock() {
+		// The enqueued sync will start monitoring if the instance manager is running
+		return nil
+	}
+
+	if needStop := monitor.pollAndUpdateInstanceMap(); needStop {
+		monitor.StopMonitorWithLock()
+	}
+	return nil
+}
+
+func (imc *InstanceManagerController) stopAllMonitors() {
+	imc.instanceManagerMonitorMutex.Lock()
+	defer imc.instanceManagerMonitorMutex.Unlock()
+
+	for imName, stopCh := range imc.instanceManagerMonitorMap {
+		select {
+		case <-stopCh:
+			// stopCh channel is already closed
+		default:
+			imc.logger.WithField("instanceManager", imName).Info("Stopping the instance manager monitor since the controller is shutting down")
+			close(stopCh)
+		}
+	}
+}
+
+type cachedInstanceManagerClient struct {
+	endpoint string
+	client   *engineapi.InstanceManagerClient
+}
+
+// instanceManagerClientCache caches one client per instance manager. A cached client is evicted once the
+// endpoint of the instance manager changes, or when it is explicitly invalidated.
+type instanceManagerClientCache struct {
+	lock    sync.Mutex
+	clients map[string]*cachedInstanceManagerClient
+
+	// for unit test
+	newClient func(*longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error)
+}
+
+func newInstanceManagerClientCache(newClient func(*longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error)) *instanceManagerClientCache {
+	return &instanceManagerClientCache{
+		clients:   map[string]*cachedInstanceManagerClient{},
+		newClient: newClient,
+	}
+}
+
+func (c *instanceManagerClientCache) get(im *longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	endpoint := engineapi.GetInstanceManagerInstanceServiceEndpoint(im)
+	if cached, ok := c.clients[im.Name]; ok {
+		if cached.endpoint == endpoint {
+			instanceManagerClientCacheHits.Inc()
+			return cached.client, nil
+		}
+		cached.client.Close()
+		delete(c.clients, im.Name)
+	}
+
+	instanceManagerClientCacheMisses.Inc()
+	client, err := c.newClient(im)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[im.Name] = &cachedInstanceManagerClient{
+		endpoint: endpoint,
+		client:   client,
+	}
+	return client, nil
+}
+
+func (c *instanceManagerClientCache) invalidate(imName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cached, ok := c.clients[imName]; ok {
+		cached.client.Close()
+		delete(c.clients, imName)
+	}
+}
+
 func (m *InstanceManagerMonitor) Run() {
 	m.logger.Infof("Start monitoring instance manager %v", m.Name)
 
 	// TODO: this function will error out in unit tests. Need to find a way to skip this for unit tests.
 	// TODO: #2441 refactor this when we do the resource monitoring refactor
-	ctx, cancel := context.WithCancel(context.TODO())
-	notifier, err := m.client.InstanceWatch(ctx)
+	ctx, cancel := m.newWatchContext()
+	notifier, err := m.establishWatch(ctx)
 	if err != nil {
 		m.logger.WithError(err).Errorf("Failed to get the notifier for monitoring")
 		cancel()
@@ -1503,37 +3489,20 @@ func (m *InstanceManagerMonitor) Run() {
 		close(m.monitorVoluntaryStopCh)
 	}()
 
-	go func() {
-		continuousFailureCount := 0
-		for {
-			if continuousFailureCount >= engineapi.MaxMonitorRetryCount {
-				m.logger.Errorf("Instance manager monitor streaming continuously errors receiving items for %v times, will stop the monitor itself", engineapi.MaxMonitorRetryCount)
-				m.StopMonitorWithLock()
-			}
-
-			if m.CheckMonitorStoppedWithLock() {
-				return
-			}
+	m.lock.Lock()
+	m.watchConnected = true
+	m.lastRecvTime = m.clock.Now()
+	m.lock.Unlock()
 
-			var err error
-			if m.client.GetAPIVersion() < 4 {
-				_, err = notifier.(*imapi.ProcessStream).Recv()
-			} else {
-				_, err = notifier.(*imapi.InstanceStream).Recv()
-			}
-			if err != nil {
-				m.logger.WithError(err).Error("Failed to receive next item in instance watch")
-				continuousFailureCount++
-				time.Sleep(engineapi.MinPollCount * engineapi.PollInterval)
-			} else {
-				m.lock.Lock()
-				m.updateNotification = true
-				m.lock.Unlock()
-			}
+	go m.receiveNotifications(func() (err error) {
+		if m.client.GetAPIVersion() < 4 {
+			_, err = notifier.(*imapi.ProcessStream).Recv()
+		} else {
+			_, err = notifier.(*imapi.InstanceStream).Recv()
 		}
-	}()
+		return err
+	})
 
-	timer := 0
 	ticker := time.NewTicker(engineapi.MinPollCount * engineapi.PollInterval)
 	defer ticker.Stop()
 	for {
@@ -1543,18 +3512,7 @@ func (m *InstanceManagerMonitor) Run() {
 				return
 			}
 
-			needUpdate := false
-
-			m.lock.Lock()
-			timer++
-			if timer >= engineapi.MaxPollCount || m.updateNotification {
-				needUpdate = true
-				m.updateNotification = false
-				timer = 0
-			}
-			m.lock.Unlock()
-
-			if !needUpdate {
+			if !m.shouldPoll(m.clock.Now(), m.getPollInterval()) {
 				continue
 			}
 			if needStop := m.pollAndUpdateInstanceMap(); needStop {
@@ -1566,7 +3524,161 @@ func (m *InstanceManagerMonitor) Run() {
 	}
 }
 
+// establishWatch keeps establishing the instance watch stream until it succeeds, the monitor is stopped, or the
+// failures reach the max retry count. The failures are retried with the establishment backoff, which is independent
+// of the backoff of the failures in the middle of the stream.
+func (m *InstanceManagerMonitor) establishWatch(ctx context.Context) (interface{}, error) {
+	defer m.watchEstablishmentBackoff.DeleteEntry(m.Name)
+
+	for failureCount := 1; ; failureCount++ {
+		notifier, err := m.watcher(ctx)
+		if err == nil {
+			return notifier, nil
+		}
+		if failureCount >= engineapi.MaxMonitorRetryCount {
+			return nil, errors.Wrapf(err, "failed to establish the instance watch for %v times", failureCount)
+		}
+
+		m.watchEstablishmentBackoff.Next(m.Name, m.watchEstablishmentBackoff.Clock.Now())
+		delay := m.watchEstablishmentBackoff.Get(m.Name)
+		m.logger.WithError(err).Warnf("Failed to establish the instance watch, will retry after %v", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, errors.Wrap(err, "stopped establishing the instance watch")
+		}
+	}
+}
+
+// newWatchContext returns the context of the instance watch stream. It is canceled once the monitor is stopped by
+// either the controller or itself, so the blocking receive returns immediately rather than on the next item.
+func (m *InstanceManagerMonitor) newWatchContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	m.lock.Lock()
+	m.watchCancel = cancel
+	m.lock.Unlock()
+
+	go func() {
+		select {
+		case <-m.stopCh:
+			m.StopMonitorWithLock()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// shouldPoll returns true if the instance watch notified the changes, or the poll interval has passed since the last poll.
+// The poll triggered by the notifications is never throttled.
+func (m *InstanceManagerMonitor) shouldPoll(now time.Time, pollInterval time.Duration) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.updateNotification && now.Sub(m.lastPollTime) < pollInterval {
+		return false
+	}
+	m.updateNotification = false
+	m.lastPollTime = now
+	return true
+}
+
+func (m *InstanceManagerMonitor) getPollInterval() time.Duration {
+	interval, err := m.ds.GetSettingAsInt(types.SettingNameInstanceManagerPollInterval)
+	if err != nil {
+		m.logger.WithError(err).Warnf("Failed to get %v setting, will use the default poll interval", types.SettingNameInstanceManagerPollInterval)
+		return engineapi.MaxPollCount * engineapi.PollInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// receiveNotifications keeps receiving items from the instance watch stream and notifies the monitor to update the
+// instance map. Continuous failures are retried with an exponential backoff, which is reset once an item is received.
+// An io.EOF means the instance manager closed the stream gracefully, e.g., it is shutting down, so the monitor stops
+// itself rather than retrying unless the instance manager is still supposed to be running.
+func (m *InstanceManagerMonitor) receiveNotifications(recv func() error) {
+	defer m.watchBackoff.DeleteEntry(m.Name)
+
+	continuousFailureCount := 0
+	for {
+		if continuousFailureCount >= engineapi.MaxMonitorRetryCount {
+			m.logger.Errorf("Instance manager monitor streaming continuously errors receiving items for %v times, will stop the monitor itself", engineapi.MaxMonitorRetryCount)
+			m.StopMonitorWithLock()
+		}
+
+		if m.CheckMonitorStoppedWithLock() {
+			return
+		}
+
+		if err := recv(); err != nil {
+			m.lock.Lock()
+			m.watchConnected = false
+			m.lock.Unlock()
+
+			// The receive is interrupted by the stop rather than failed
+			if m.CheckMonitorStoppedWithLock() {
+				return
+			}
+
+			if errors.Is(err, io.EOF) && !m.isInstanceManagerExpectedRunning() {
+				m.logger.Info("Instance watch stream is closed by the instance manager that is no longer running, will stop the monitor itself")
+				m.StopMonitorWithLock()
+				return
+			}
+
+			continuousFailureCount++
+			m.watchBackoff.Next(m.Name, m.watchBackoff.Clock.Now())
+			delay := m.watchBackoff.Get(m.Name)
+			m.logger.WithError(err).Errorf("Failed to receive next item in instance watch, will retry after %v", delay)
+			time.Sleep(delay)
+		} else {
+			continuousFailureCount = 0
+			m.watchBackoff.Reset(m.Name)
+
+			m.lock.Lock()
+			m.updateNotification = true
+			m.watchConnected = true
+			m.lastRecvTime = m.clock.Now()
+			m.lock.Unlock()
+			m.logger.Debug("Received an instance update from the instance watch")
+		}
+	}
+}
+
+// isInstanceManagerExpectedRunning returns false if the instance manager is gone, taken over by another node, or no
+// longer running. A transient failure of getting the instance manager is considered running so the watch is retried.
+func (m *InstanceManagerMonitor) isInstanceManagerExpectedRunning() bool {
+	im, err := m.ds.GetInstanceManagerRO(m.Name)
+	if err != nil {
+		if datastore.ErrorIsNotFound(err) {
+			return false
+		}
+		m.logger.WithError(err).Warn("Failed to get instance manager after the instance watch stream is closed")
+		return true
+	}
+	return im.Status.OwnerID == m.controllerID && im.Status.CurrentState == longhorn.InstanceManagerStateRunning
+}
+
+func (m *InstanceManagerMonitor) getWatchStatus(now time.Time) InstanceManagerWatchStatus {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	// The instances may not change for a long time, so an idle watch is healthy as long as the stream is connected
+	status := InstanceManagerWatchStatus{
+		Connected: m.watchConnected,
+		Stale:     !m.watchConnected,
+	}
+	if !m.lastRecvTime.IsZero() {
+		status.LastRecvTime = m.lastRecvTime.UTC().Format(time.RFC3339)
+		status.SinceLastRecv = now.Sub(m.lastRecvTime).Round(time.Second).String()
+	}
+	return status
+}
+
 func (m *InstanceManagerMonitor) pollAndUpdateInstanceMap() (needStop bool) {
+	m.pollLock.Lock()
+	defer m.pollLock.Unlock()
+
 	im, err := m.ds.GetInstanceManager(m.Name)
 	if err != nil {
 		if datastore.ErrorIsNotFound(err) {
@@ -1579,21 +3691,47 @@ func (m *InstanceManagerMonitor) pollAndUpdateInstanceMap() (needStop bool) {
 
 	if im.Status.OwnerID != m.controllerID {
 		m.logger.Warnf("stop monitoring the instance manager on this node (%v) because the instance manager has new ownerID %v", m.controllerID, im.Status.OwnerID)
+		// The poll racing with the ownership change may have recorded the instances after the controller dropped them
+		deleteInstanceManagerInstanceMetrics(m.Name)
 		return true
 	}
 
-	resp, err := m.client.InstanceList()
+	m.updateLogLevel(im)
+
+	resp, err := m.instanceLister()
 	if err != nil {
 		utilruntime.HandleError(errors.Wrapf(err, "failed to poll instance info to update instance manager %v", m.Name))
 		return false
 	}
-	if !m.updateInstanceMap(im, resp) {
+	m.logger.Debugf("Polled %v instances", len(resp))
+	recordInstanceManagerInstanceMetrics(im, resp)
+	m.recordDuplicateInstances(im, resp)
+	updated := m.updateInstanceMap(im, resp)
+	// The instance watch is established before the polls start, so the first successful poll makes the API ready
+	apiReadyUpdated := !im.Status.APIReady
+	im.Status.APIReady = true
+	// The timestamp is refreshed by the successful polls only, so a stalled monitor can be detected
+	lastPollTimestamp := m.clock.Now().UTC().Format(time.RFC3339)
+	lastPollTimestampUpdated := im.Status.LastPollTimestamp != lastPollTimestamp
+	im.Status.LastPollTimestamp = lastPollTimestamp
+	if !updated && !apiReadyUpdated && !lastPollTimestampUpdated {
 		return false
 	}
 	if _, err := m.ds.UpdateInstanceManagerStatus(im); err != nil {
+		if apierrors.IsConflict(errors.Cause(err)) {
+			m.handleUpdateConflict(err)
+			return false
+		}
 		utilruntime.HandleError(errors.Wrapf(err, "failed to update instance map for instance manager %v", m.Name))
+		if apiReadyUpdated {
+			// Retry on the next tick rather than waiting for the poll interval
+			m.lock.Lock()
+			m.updateNotification = true
+			m.lock.Unlock()
+		}
 		return false
 	}
+	m.updateConflictCount = 0
 
 	clusterAutoscalerEnabled, err := m.ds.GetSettingAsBool(types.SettingNameKubernetesClusterAutoscalerEnabled)
 	if err != nil {
@@ -1614,13 +3752,64 @@ func (m *InstanceManagerMonitor) pollAndUpdateInstanceMap() (needStop bool) {
 	return false
 }
 
+// updateLogLevel applies the debug annotation of the instance manager and the latest level of the base logger to the
+// monitor logger.
+func (m *InstanceManagerMonitor) updateLogLevel(im *longhorn.InstanceManager) {
+	if m.levelLogger == nil || m.baseLogger == nil {
+		return
+	}
+	m.levelLogger.SetLevel(getInstanceManagerLogLevel(m.baseLogger, im))
+}
+
+// getInstanceManagerLogLevel returns the trace level for the instance manager with the debug annotation, or the level
+// of the base logger otherwise.
+func getInstanceManagerLogLevel(base *logrus.Logger, im *longhorn.InstanceManager) logrus.Level {
+	if isInstanceManagerDebugEnabled(im) {
+		return logrus.TraceLevel
+	}
+	return base.GetLevel()
+}
+
+// handleUpdateConflict retries the conflicting instance map update on the next tick with the latest instance manager.
+// After the continuous conflicts reach the limit, the update is dropped and left to the next regular poll, so a hot
+// instance manager cannot keep the monitor retrying the same update.
+func (m *InstanceManagerMonitor) handleUpdateConflict(err error) {
+	m.updateConflictCount++
+	if m.updateConflictCount >= instanceManagerMonitorMaxConflictRetryCount {
+		m.logger.WithError(err).Warnf("Dropped the instance map update after %v continuous conflicts, will update on the next poll", m.updateConflictCount)
+		m.updateConflictCount = 0
+		return
+	}
+
+	m.logger.WithError(err).Debugf("Will retry the instance map update due to conflict (%v/%v)", m.updateConflictCount, instanceManagerMonitorMaxConflictRetryCount)
+	m.lock.Lock()
+	m.updateNotification = true
+	m.lock.Unlock()
+}
+
 func (m *InstanceManagerMonitor) updateInstanceMap(im *longhorn.InstanceManager, resp map[string]longhorn.InstanceProcess) bool {
+	existingProcess := map[string]longhorn.InstanceProcess{}
+	if im.Status.APIVersion < 4 {
+		existingProcess = im.Status.Instances
+	} else {
+		for name, process := range im.Status.InstanceEngines {
+			existingProcess[name] = process
+		}
+		for name, process := range im.Status.InstanceReplicas {
+			existingProcess[name] = process
+		}
+	}
+	m.recordExpiredInstanceUpdates(im, existingProcess, resp)
+	setInstanceProcessTimestamps(existingProcess, resp, util.Now())
+	setInstanceProcessRestartCounts(existingProcess, resp)
+
 	switch {
 	case im.Status.APIVersion < 4:
-		if reflect.DeepEqual(im.Status.Instances, resp) {
+		if isInstanceMapEqual(im.Status.Instances, resp) {
 			return false
 		}
 
+		m.logInstanceMapDiff(im.Status.Instances, resp)
 		im.Status.Instances = resp
 	default:
 		engineProcess := map[string]longhorn.InstanceProcess{}
@@ -1633,16 +3822,199 @@ func (m *InstanceManagerMonitor) updateInstanceMap(im *longhorn.InstanceManager,
 				replicaProcess[name] = process
 			}
 		}
-		if reflect.DeepEqual(im.Status.InstanceEngines, engineProcess) && reflect.DeepEqual(im.Status.InstanceReplicas, replicaProcess) {
+		if isInstanceMapEqual(im.Status.InstanceEngines, engineProcess) && isInstanceMapEqual(im.Status.InstanceReplicas, replicaProcess) {
 			return false
 		}
 
+		m.logInstanceMapDiff(existingProcess, resp)
+
 		im.Status.InstanceEngines = engineProcess
 		im.Status.InstanceReplicas = replicaProcess
 	}
 	return true
 }
 
+// recordExpiredInstanceUpdates warns about the instance updates older than the existing ones, whose state transitions
+// are ignored. They may indicate a stale instance watch or an instance started twice, so a warning event is emitted
+// besides the log, which is rate limited to avoid flooding the events.
+func (m *InstanceManagerMonitor) recordExpiredInstanceUpdates(im *longhorn.InstanceManager, existing, current map[string]longhorn.InstanceProcess) {
+	expiredUpdates := []string{}
+	for name, process := range current {
+		existingProcess, ok := existing[name]
+		if !ok || process.Status.ResourceVersion >= existingProcess.Status.ResourceVersion ||
+			isInstanceProcessResourceVersionReset(existingProcess, process) {
+			continue
+		}
+		expiredUpdates = append(expiredUpdates, fmt.Sprintf("%v (resource version %v, existing %v)",
+			name, process.Status.ResourceVersion, existingProcess.Status.ResourceVersion))
+	}
+	if len(expiredUpdates) == 0 {
+		return
+	}
+	sort.Strings(expiredUpdates)
+
+	message := fmt.Sprintf("Ignored the state transitions of the expired instance updates: %v", strings.Join(expiredUpdates, ", "))
+	m.logger.Warn(message)
+
+	now := m.clock.Now()
+	if !m.lastExpiredInstanceUpdateEventTime.IsZero() && now.Sub(m.lastExpiredInstanceUpdateEventTime) < instanceManagerExpiredInstanceUpdateEventInterval {
+		return
+	}
+	m.lastExpiredInstanceUpdateEventTime = now
+	m.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonExpiredInstanceUpdate, message)
+}
+
+// recordDuplicateInstances warns about the active instances also claimed by other running instance managers, which
+// would feed the conflicting instance status to the engine and replica controllers. The warning event is rate limited
+// to avoid flooding the events.
+func (m *InstanceManagerMonitor) recordDuplicateInstances(im *longhorn.InstanceManager, current map[string]longhorn.InstanceProcess) {
+	ims, err := m.ds.ListInstanceManagersRO()
+	if err != nil {
+		m.logger.WithError(err).Warn("Failed to list instance managers to check the duplicate instances")
+		return
+	}
+
+	duplicates := []string{}
+	for _, otherIM := range ims {
+		if otherIM.Name == im.Name || otherIM.Status.CurrentState != longhorn.InstanceManagerStateRunning {
+			continue
+		}
+		otherInstances := types.ConsolidateInstances(otherIM.Status.InstanceEngines, otherIM.Status.InstanceReplicas, otherIM.Status.Instances)
+		for name, process := range current {
+			otherProcess, ok := otherInstances[name]
+			if !ok || !isInstanceProcessActive(process) || !isInstanceProcessActive(otherProcess) {
+				continue
+			}
+			duplicates = append(duplicates, fmt.Sprintf("%v (also in %v)", name, otherIM.Name))
+		}
+	}
+	if len(duplicates) == 0 {
+		return
+	}
+	sort.Strings(duplicates)
+
+	message := fmt.Sprintf("Found the instances claimed by other instance managers: %v", strings.Join(duplicates, ", "))
+	m.logger.Warn(message)
+
+	now := m.clock.Now()
+	if !m.lastDuplicateInstanceEventTime.IsZero() && now.Sub(m.lastDuplicateInstanceEventTime) < instanceManagerDuplicateInstanceEventInterval {
+		return
+	}
+	m.lastDuplicateInstanceEventTime = now
+	m.eventRecorder.Event(im, corev1.EventTypeWarning, constant.EventReasonDuplicateInstance, message)
+}
+
+// isInstanceProcessActive returns true if the instance process is starting or running. The stopped or failed instance
+// left in a previous instance manager is not a conflict, e.g., for the engine moved to another node.
+func isInstanceProcessActive(process longhorn.InstanceProcess) bool {
+	return process.Status.State == longhorn.InstanceStateStarting || process.Status.State == longhorn.InstanceStateRunning
+}
+
+// isInstanceMapEqual treats the nil and empty instance maps as equal, since the empty maps are omitted from the status
+// and read back as nil, e.g., for a freshly created instance manager.
+func isInstanceMapEqual(existing, current map[string]longhorn.InstanceProcess) bool {
+	if len(existing) == 0 && len(current) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(existing, current)
+}
+
+// setInstanceProcessTimestamps carries over the start and stop timestamps of the existing instances,
+// then records the current time for the instances transitioning into running or stopped.
+func setInstanceProcessTimestamps(existing, current map[string]longhorn.InstanceProcess, now string) {
+	for name, process := range current {
+		existingProcess, ok := existing[name]
+		if ok {
+			process.Status.StartedAt = existingProcess.Status.StartedAt
+			process.Status.StoppedAt = existingProcess.Status.StoppedAt
+		}
+		// An expired update cannot be a new transition, unless the version is reset by a restarted process
+		if !ok || process.Status.ResourceVersion >= existingProcess.Status.ResourceVersion ||
+			isInstanceProcessResourceVersionReset(existingProcess, process) {
+			if !ok || process.Status.State != existingProcess.Status.State {
+				switch process.Status.State {
+				case longhorn.InstanceStateRunning:
+					process.Status.StartedAt = now
+				case longhorn.InstanceStateStopped:
+					process.Status.StoppedAt = now
+				}
+			}
+		}
+		current[name] = process
+	}
+}
+
+// setInstanceProcessRestartCounts carries over the restart counts of the existing instances, and increments the count
+// of the instance whose process is restarted with the same name, which is observed by the reset resource version.
+func setInstanceProcessRestartCounts(existing, current map[string]longhorn.InstanceProcess) {
+	for name, process := range current {
+		existingProcess, ok := existing[name]
+		if !ok {
+			continue
+		}
+		process.Status.RestartCount = existingProcess.Status.RestartCount
+		if isInstanceProcessResourceVersionReset(existingProcess, process) {
+			process.Status.RestartCount++
+		}
+		current[name] = process
+	}
+}
+
+// isInstanceProcessResourceVersionReset returns true if the instance process with the same name reports a lower
+// resource version within the initial versions of a process, along with a state change. The resource version of a
+// restarted process starts over, which would otherwise be mistaken for an expired update.
+func isInstanceProcessResourceVersionReset(existing, current longhorn.InstanceProcess) bool {
+	return current.Status.ResourceVersion < existing.Status.ResourceVersion &&
+		current.Status.ResourceVersion <= instanceProcessInitialResourceVersionMax &&
+		current.Status.State != existing.Status.State
+}
+
+// instanceMapDiff summarizes the instances added, updated, and removed between two polls
+type instanceMapDiff struct {
+	added   []string
+	updated []string
+	removed []string
+}
+
+func (d *instanceMapDiff) isEmpty() bool {
+	return len(d.added) == 0 && len(d.updated) == 0 && len(d.removed) == 0
+}
+
+func getInstanceMapDiff(existing, current map[string]longhorn.InstanceProcess) *instanceMapDiff {
+	diff := &instanceMapDiff{}
+	for name, process := range current {
+		existingProcess, ok := existing[name]
+		if !ok {
+			diff.added = append(diff.added, name)
+			continue
+		}
+		if !reflect.DeepEqual(existingProcess, process) {
+			diff.updated = append(diff.updated, fmt.Sprintf("%v(%v->%v)", name, existingProcess.Status.State, process.Status.State))
+		}
+	}
+	for name := range existing {
+		if _, ok := current[name]; !ok {
+			diff.removed = append(diff.removed, name)
+		}
+	}
+	sort.Strings(diff.added)
+	sort.Strings(diff.updated)
+	sort.Strings(diff.removed)
+	return diff
+}
+
+func (m *InstanceManagerMonitor) logInstanceMapDiff(existing, current map[string]longhorn.InstanceProcess) {
+	diff := getInstanceMapDiff(existing, current)
+	if diff.isEmpty() {
+		return
+	}
+	m.logger.WithFields(logrus.Fields{
+		"added":   diff.added,
+		"updated": diff.updated,
+		"removed": diff.removed,
+	}).Info("Instances of instance manager changed")
+}
+
 func (m *InstanceManagerMonitor) CheckMonitorStoppedWithLock() bool {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -1653,6 +4025,9 @@ func (m *InstanceManagerMonitor) StopMonitorWithLock() {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	m.done = true
+	if m.watchCancel != nil {
+		m.watchCancel()
+	}
 }
 
 func (imc *InstanceManagerController) isResponsibleFor(im *longhorn.InstanceManager) bool {
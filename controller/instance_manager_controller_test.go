@@ -1,25 +1,51 @@
 package controller
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/kubernetes/pkg/controller"
 
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
 
+	"github.com/longhorn/longhorn-manager/constant"
 	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/util"
+	"github.com/longhorn/longhorn-manager/webhook/resources/instancemanager"
 
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
@@ -70,6 +96,8 @@ func newTestInstanceManagerController(lhClient *lhfake.Clientset, kubeClient *fa
 		imc.cacheSyncs[index] = alwaysReady
 	}
 	imc.versionUpdater = fakeInstanceManagerVersionUpdater
+	imc.apiReadinessChecker = func(im *longhorn.InstanceManager) error { return nil }
+	imc.podName = "longhorn-manager-" + controllerID
 
 	return imc
 }
@@ -125,6 +153,7 @@ func (s *TestSuite) TestSyncInstanceManager(c *C) {
 				APIVersion:       0,
 				InstanceEngines:  nil, // Transition to InstanceManagerStateError erases process information.
 				InstanceReplicas: nil, // Transition to InstanceManagerStateError erases process information.
+				Message:          "Instance manager pod " + TestInstanceManagerName + " failed: no container termination state found",
 			},
 		},
 		"instance manager node down": {
@@ -137,6 +166,7 @@ func (s *TestSuite) TestSyncInstanceManager(c *C) {
 				IP:            TestIP1,
 				APIMinVersion: engineapi.MinInstanceManagerAPIVersion,
 				APIVersion:    engineapi.CurrentInstanceManagerAPIVersion,
+				APIReady:      true, // The monitor is left untouched for the unknown instance manager.
 			},
 		},
 		"instance manager restarting after error": {
@@ -215,6 +245,8 @@ func (s *TestSuite) TestSyncInstanceManager(c *C) {
 		lhNodeIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
 
 		imc := newTestInstanceManagerController(lhClient, kubeClient, extensionsClient, informerFactories, tc.controllerID)
+		fakeClock := testingclock.NewFakeClock(time.Now())
+		imc.clock = fakeClock
 
 		// Controller logic depends on the existence of DefaultInstanceManagerImage Setting and Toleration Setting.
 		tolerationSetting := newTolerationSetting()
@@ -300,8 +332,4233 @@ func (s *TestSuite) TestSyncInstanceManager(c *C) {
 
 		// Skip checking imc.instanceManagerMonitorMap since the monitor doesn't work in the unit test.
 
+		if tc.expectedStatus.CurrentState != tc.currentState {
+			tc.expectedStatus.CurrentStateTransitionTime = fakeClock.Now().UTC().Format(time.RFC3339)
+		}
+		tc.expectedStatus.OwnerPodName = imc.podName
+		// The test nodes don't report the boot ID
+		if tc.expectedStatus.CurrentState == longhorn.InstanceManagerStateRunning {
+			tc.expectedStatus.NodeBootID = longhorn.InstanceManagerNodeBootIDUnknown
+		}
 		updatedIM, err := lhClient.LonghornV1beta2().InstanceManagers(im.Namespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
 		c.Assert(err, IsNil)
+		// The conditions are verified in TestInstanceManagerConditions
+		expectedReadyStatus := longhorn.ConditionStatusFalse
+		if tc.expectedStatus.CurrentState == longhorn.InstanceManagerStateRunning {
+			expectedReadyStatus = longhorn.ConditionStatusTrue
+		}
+		c.Assert(types.GetCondition(updatedIM.Status.Conditions, longhorn.InstanceManagerConditionTypeReady).Status, Equals, expectedReadyStatus)
+		updatedIM.Status.Conditions = nil
 		c.Assert(updatedIM.Status, DeepEquals, tc.expectedStatus)
 	}
 }
+
+type instanceManagerTestFixture struct {
+	imc *InstanceManagerController
+
+	kubeClient        *fake.Clientset
+	lhClient          *lhfake.Clientset
+	extensionsClient  *apiextensionsfake.Clientset
+	informerFactories *util.InformerFactories
+
+	pIndexer        cache.Indexer
+	kubeNodeIndexer cache.Indexer
+	imIndexer       cache.Indexer
+	sIndexer        cache.Indexer
+	lhNodeIndexer   cache.Indexer
+	pcIndexer       cache.Indexer
+	eiIndexer       cache.Indexer
+}
+
+func newInstanceManagerTestFixture(c *C, controllerID string) *instanceManagerTestFixture {
+	kubeClient := fake.NewSimpleClientset()
+	lhClient := lhfake.NewSimpleClientset()
+	extensionsClient := apiextensionsfake.NewSimpleClientset()
+
+	informerFactories := util.NewInformerFactories(TestNamespace, kubeClient, lhClient, controller.NoResyncPeriodFunc())
+
+	f := &instanceManagerTestFixture{
+		imc: newTestInstanceManagerController(lhClient, kubeClient, extensionsClient, informerFactories, controllerID),
+
+		kubeClient:        kubeClient,
+		lhClient:          lhClient,
+		extensionsClient:  extensionsClient,
+		informerFactories: informerFactories,
+
+		pIndexer:        informerFactories.KubeInformerFactory.Core().V1().Pods().Informer().GetIndexer(),
+		kubeNodeIndexer: informerFactories.KubeInformerFactory.Core().V1().Nodes().Informer().GetIndexer(),
+		imIndexer:       informerFactories.LhInformerFactory.Longhorn().V1beta2().InstanceManagers().Informer().GetIndexer(),
+		sIndexer:        informerFactories.LhInformerFactory.Longhorn().V1beta2().Settings().Informer().GetIndexer(),
+		lhNodeIndexer:   informerFactories.LhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer(),
+		pcIndexer:       informerFactories.KubeInformerFactory.Scheduling().V1().PriorityClasses().Informer().GetIndexer(),
+		eiIndexer:       informerFactories.LhInformerFactory.Longhorn().V1beta2().EngineImages().Informer().GetIndexer(),
+	}
+
+	f.addSetting(c, newTolerationSetting())
+	f.addSetting(c, newDefaultInstanceManagerImageSetting())
+
+	return f
+}
+
+func (f *instanceManagerTestFixture) addSetting(c *C, setting *longhorn.Setting) {
+	setting, err := f.lhClient.LonghornV1beta2().Settings(TestNamespace).Create(context.TODO(), setting, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	err = f.sIndexer.Add(setting)
+	c.Assert(err, IsNil)
+}
+
+func (f *instanceManagerTestFixture) updateSetting(c *C, setting *longhorn.Setting) {
+	setting, err := f.lhClient.LonghornV1beta2().Settings(TestNamespace).Update(context.TODO(), setting, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	err = f.sIndexer.Update(setting)
+	c.Assert(err, IsNil)
+}
+
+func (f *instanceManagerTestFixture) addNode(c *C, name string) {
+	kubeNode := newKubernetesNode(name, corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionFalse, corev1.ConditionFalse, corev1.ConditionFalse, corev1.ConditionTrue)
+	kubeNode, err := f.kubeClient.CoreV1().Nodes().Create(context.TODO(), kubeNode, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	err = f.kubeNodeIndexer.Add(kubeNode)
+	c.Assert(err, IsNil)
+
+	lhNode := newNode(name, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+	lhNode, err = f.lhClient.LonghornV1beta2().Nodes(lhNode.Namespace).Create(context.TODO(), lhNode, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	err = f.lhNodeIndexer.Add(lhNode)
+	c.Assert(err, IsNil)
+}
+
+func (f *instanceManagerTestFixture) addInstanceManager(c *C, im *longhorn.InstanceManager) {
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(im.Namespace).Create(context.TODO(), im, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	err = f.imIndexer.Add(im)
+	c.Assert(err, IsNil)
+}
+
+func (f *instanceManagerTestFixture) addPod(c *C, pod *corev1.Pod) {
+	pod, err := f.kubeClient.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	err = f.pIndexer.Add(pod)
+	c.Assert(err, IsNil)
+}
+
+func (f *instanceManagerTestFixture) updatePod(c *C, pod *corev1.Pod) {
+	pod, err := f.kubeClient.CoreV1().Pods(pod.Namespace).Update(context.TODO(), pod, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	err = f.pIndexer.Update(pod)
+	c.Assert(err, IsNil)
+}
+
+// syncInstanceManager syncs the instance manager and refreshes the indexer with the updated instance manager
+func (f *instanceManagerTestFixture) syncInstanceManager(c *C, name string) *longhorn.InstanceManager {
+	err := f.imc.syncInstanceManager(TestNamespace + "/" + name)
+	c.Assert(err, IsNil)
+	im := f.getInstanceManager(c, name)
+	err = f.imIndexer.Update(im)
+	c.Assert(err, IsNil)
+	return im
+}
+
+func newInstanceManagerTestPod(status *corev1.PodStatus, im *longhorn.InstanceManager) *corev1.Pod {
+	pod := newPod(status, im.Name, im.Namespace, im.Spec.NodeID)
+	pod.Spec.Containers = []corev1.Container{
+		{
+			Name:      "instance-manager",
+			Image:     im.Spec.Image,
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{"cpu": resource.MustParse("480m")}},
+		},
+	}
+	return pod
+}
+
+func (f *instanceManagerTestFixture) getInstanceManager(c *C, name string) *longhorn.InstanceManager {
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	return im
+}
+
+func (f *instanceManagerTestFixture) listPods(c *C) []corev1.Pod {
+	podList, err := f.kubeClient.CoreV1().Pods(TestNamespace).List(context.TODO(), metav1.ListOptions{})
+	c.Assert(err, IsNil)
+	return podList.Items
+}
+
+func (s *TestSuite) TestInstanceManagerCustomPort(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.Spec.Port = 9500
+	f.addInstanceManager(c, im)
+
+	pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Join(pod.Spec.Containers[0].Args, " "), Matches, ".*--listen 0.0.0.0:9500.*")
+	livenessProbeCommand := pod.Spec.Containers[0].LivenessProbe.Exec.Command[2]
+	for _, port := range []string{"9500", "9501", "9502", "9503"} {
+		c.Assert(livenessProbeCommand, Matches, ".*nc -zv localhost "+port+" .*")
+	}
+	c.Assert(livenessProbeCommand, Not(Matches), ".*localhost 8500.*")
+
+	c.Assert(engineapi.GetInstanceManagerProcessManagerServiceEndpoint(im), Equals, "tcp://"+TestIP1+":9500")
+	c.Assert(engineapi.GetInstanceManagerInstanceServiceEndpoint(im), Equals, "tcp://"+TestIP1+":9503")
+
+	im.Spec.Port = 0
+	c.Assert(engineapi.GetInstanceManagerInstanceServiceEndpoint(im), Equals, "tcp://"+TestIP1+":8503")
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorWatchEstablishmentBackoff(c *C) {
+	newMonitor := func() *InstanceManagerMonitor {
+		return &InstanceManagerMonitor{
+			logger:                    logrus.StandardLogger().WithField("instance manager", TestInstanceManagerName),
+			Name:                      TestInstanceManagerName,
+			lock:                      &sync.RWMutex{},
+			clock:                     clock.RealClock{},
+			watchEstablishmentBackoff: flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+			watchBackoff:              flowcontrol.NewBackOff(time.Hour, time.Hour),
+		}
+	}
+
+	// The establishment is retried with its own backoff until it succeeds
+	monitor := newMonitor()
+	var delays []time.Duration
+	monitor.watcher = func(ctx context.Context) (interface{}, error) {
+		delays = append(delays, monitor.watchEstablishmentBackoff.Get(monitor.Name))
+		if len(delays) < 5 {
+			return nil, fmt.Errorf("failed to connect")
+		}
+		return "notifier", nil
+	}
+	notifier, err := monitor.establishWatch(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(notifier, Equals, "notifier")
+	c.Assert(delays, DeepEquals, []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond})
+	c.Assert(monitor.watchEstablishmentBackoff.Get(monitor.Name), Equals, time.Duration(0))
+	c.Assert(monitor.watchBackoff.Get(monitor.Name), Equals, time.Duration(0))
+
+	// The establishment gives up after continuously failing
+	monitor = newMonitor()
+	attempts := 0
+	monitor.watcher = func(ctx context.Context) (interface{}, error) {
+		attempts++
+		return nil, fmt.Errorf("failed to connect")
+	}
+	_, err = monitor.establishWatch(context.TODO())
+	c.Assert(err, NotNil)
+	c.Assert(attempts, Equals, engineapi.MaxMonitorRetryCount)
+
+	// The establishment stops waiting for the retry once the monitor is stopped
+	monitor = newMonitor()
+	monitor.watchEstablishmentBackoff = flowcontrol.NewBackOff(time.Hour, time.Hour)
+	ctx, cancel := context.WithCancel(context.TODO())
+	attempts = 0
+	monitor.watcher = func(ctx context.Context) (interface{}, error) {
+		attempts++
+		cancel()
+		return nil, fmt.Errorf("failed to connect")
+	}
+	_, err = monitor.establishWatch(ctx)
+	c.Assert(err, NotNil)
+	c.Assert(attempts, Equals, 1)
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorWatchReceiveBackoffIndependence(c *C) {
+	monitor := &InstanceManagerMonitor{
+		logger:                    logrus.StandardLogger().WithField("instance manager", TestInstanceManagerName),
+		Name:                      TestInstanceManagerName,
+		lock:                      &sync.RWMutex{},
+		clock:                     clock.RealClock{},
+		watchEstablishmentBackoff: flowcontrol.NewBackOff(time.Hour, time.Hour),
+		watchBackoff:              flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+	}
+
+	var delays []time.Duration
+	monitor.receiveNotifications(func() error {
+		delays = append(delays, monitor.watchBackoff.Get(monitor.Name))
+		c.Assert(monitor.watchEstablishmentBackoff.Get(monitor.Name), Equals, time.Duration(0))
+		if len(delays) > 3 {
+			monitor.StopMonitorWithLock()
+		}
+		return fmt.Errorf("failed to receive")
+	})
+	c.Assert(delays, DeepEquals, []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond})
+}
+
+func (s *TestSuite) TestInstanceManagerWatchMaxBackoffSettings(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+
+	// The defaults are used if the settings are not available
+	c.Assert(f.imc.getInstanceManagerWatchMaxBackoff(types.SettingNameInstanceManagerWatchEstablishmentMaxBackoff, instanceManagerWatchEstablishmentBackoffMaxDuration),
+		Equals, instanceManagerWatchEstablishmentBackoffMaxDuration)
+
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerWatchEstablishmentMaxBackoff), "300"))
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerWatchReceiveMaxBackoff), "10"))
+	c.Assert(f.imc.getInstanceManagerWatchMaxBackoff(types.SettingNameInstanceManagerWatchEstablishmentMaxBackoff, instanceManagerWatchEstablishmentBackoffMaxDuration),
+		Equals, 5*time.Minute)
+	c.Assert(f.imc.getInstanceManagerWatchMaxBackoff(types.SettingNameInstanceManagerWatchReceiveMaxBackoff, instanceManagerWatchReceiveBackoffMaxDuration),
+		Equals, 10*time.Second)
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorWatchBackoff(c *C) {
+	monitor := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instance manager", TestInstanceManagerName),
+		Name:         TestInstanceManagerName,
+		lock:         &sync.RWMutex{},
+		clock:        clock.RealClock{},
+		watchBackoff: flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+	}
+
+	var delays []time.Duration
+	monitor.receiveNotifications(func() error {
+		delays = append(delays, monitor.watchBackoff.Get(monitor.Name))
+		return fmt.Errorf("failed to receive")
+	})
+
+	// The monitor stops itself after continuously failing to receive items.
+	c.Assert(monitor.CheckMonitorStoppedWithLock(), Equals, true)
+	c.Assert(delays, HasLen, engineapi.MaxMonitorRetryCount)
+	c.Assert(delays[:6], DeepEquals, []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond, 8 * time.Millisecond})
+
+	// The backoff is reset once an item is received.
+	monitor = &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instance manager", TestInstanceManagerName),
+		Name:         TestInstanceManagerName,
+		lock:         &sync.RWMutex{},
+		clock:        clock.RealClock{},
+		watchBackoff: flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+	}
+	delays = nil
+	count := 0
+	monitor.receiveNotifications(func() error {
+		delays = append(delays, monitor.watchBackoff.Get(monitor.Name))
+		count++
+		switch {
+		case count == 3:
+			return nil
+		case count > 6:
+			monitor.StopMonitorWithLock()
+		}
+		return fmt.Errorf("failed to receive")
+	})
+	c.Assert(delays, DeepEquals, []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 0, time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond})
+	c.Assert(monitor.updateNotification, Equals, true)
+}
+
+func (s *TestSuite) TestInstanceManagerStateMetrics(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	imName := "instance-manager-state-metrics"
+	im := newInstanceManager(imName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	pod := newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodPending}, im)
+	f.addPod(c, pod)
+
+	transitions := func(from, to longhorn.InstanceManagerState) float64 {
+		return testutil.ToFloat64(instanceManagerStateTransitions.WithLabelValues(imName, string(im.Spec.Type), string(from), string(to)))
+	}
+	currentState := func() float64 {
+		return testutil.ToFloat64(instanceManagerCurrentState.WithLabelValues(imName, string(im.Spec.Type)))
+	}
+
+	im = f.syncInstanceManager(c, imName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(transitions(longhorn.InstanceManagerStateStopped, longhorn.InstanceManagerStateStarting), Equals, float64(1))
+	c.Assert(currentState(), Equals, float64(1))
+
+	pod.Status = corev1.PodStatus{Phase: corev1.PodRunning, PodIP: TestIP1}
+	f.updatePod(c, pod)
+	im = f.syncInstanceManager(c, imName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(transitions(longhorn.InstanceManagerStateStarting, longhorn.InstanceManagerStateRunning), Equals, float64(1))
+	c.Assert(currentState(), Equals, float64(2))
+
+	// Syncing again without any state change doesn't increase the counter.
+	im = f.syncInstanceManager(c, imName)
+	c.Assert(transitions(longhorn.InstanceManagerStateStarting, longhorn.InstanceManagerStateRunning), Equals, float64(1))
+
+	pod.Status = corev1.PodStatus{Phase: corev1.PodFailed}
+	f.updatePod(c, pod)
+	im = f.syncInstanceManager(c, imName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(transitions(longhorn.InstanceManagerStateRunning, longhorn.InstanceManagerStateError), Equals, float64(1))
+	c.Assert(currentState(), Equals, float64(3))
+
+	f.imc.stopMonitoring(imName)
+
+	// The previous owner stops exporting the state once the instance manager is owned by another node
+	f.addNode(c, TestNode2)
+	im.Spec.NodeID = TestNode2
+	im.Status.OwnerID = TestNode2
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Update(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+	f.syncInstanceManager(c, imName)
+	c.Assert(instanceManagerCurrentState.DeleteLabelValues(imName, string(im.Spec.Type)), Equals, false)
+	c.Assert(instanceManagerStateTransitions.DeletePartialMatch(prometheus.Labels{metricsLabelInstanceManager: imName}), Equals, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerInstanceMetrics(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	imName := "instance-manager-instance-metrics"
+	im := newInstanceManager(imName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	newProcess := func(name string, instanceType longhorn.InstanceType) longhorn.InstanceProcess {
+		return longhorn.InstanceProcess{
+			Spec:   longhorn.InstanceProcessSpec{Name: name},
+			Status: longhorn.InstanceProcessStatus{Type: instanceType, State: longhorn.InstanceStateRunning},
+		}
+	}
+	instances := map[string]longhorn.InstanceProcess{}
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", imName),
+		Name:         imName,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        f.imc.clock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return instances, nil
+		},
+	}
+	instanceCount := func(instanceType longhorn.InstanceType) float64 {
+		return testutil.ToFloat64(instanceManagerInstances.WithLabelValues(imName, TestNode1, string(instanceType)))
+	}
+
+	instances["engine-1"] = newProcess("engine-1", longhorn.InstanceTypeEngine)
+	instances["replica-1"] = newProcess("replica-1", longhorn.InstanceTypeReplica)
+	instances["replica-2"] = newProcess("replica-2", longhorn.InstanceTypeReplica)
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	c.Assert(instanceCount(longhorn.InstanceTypeEngine), Equals, float64(1))
+	c.Assert(instanceCount(longhorn.InstanceTypeReplica), Equals, float64(2))
+
+	// The removed instances are no longer counted
+	delete(instances, "engine-1")
+	delete(instances, "replica-1")
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	c.Assert(instanceCount(longhorn.InstanceTypeEngine), Equals, float64(0))
+	c.Assert(instanceCount(longhorn.InstanceTypeReplica), Equals, float64(1))
+
+	im = f.getInstanceManager(c, imName)
+	c.Assert(im.Status.InstanceEngines, HasLen, 0)
+	c.Assert(im.Status.InstanceReplicas, HasLen, 1)
+
+	isInstanceCountExported := func() bool {
+		return instanceManagerInstances.DeleteLabelValues(imName, TestNode1, string(longhorn.InstanceTypeReplica))
+	}
+
+	// The previous owner stops exporting the instances once the instance manager is owned by another node
+	f.addNode(c, TestNode2)
+	im.Spec.NodeID = TestNode2
+	im.Status.OwnerID = TestNode2
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Update(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+	f.syncInstanceManager(c, imName)
+	c.Assert(isInstanceCountExported(), Equals, false)
+
+	// Neither does the monitor polling along with the ownership change
+	instanceManagerInstances.WithLabelValues(imName, TestNode1, string(longhorn.InstanceTypeReplica)).Set(1)
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, true)
+	c.Assert(isInstanceCountExported(), Equals, false)
+}
+
+func (s *TestSuite) TestInstanceManagerGracefulCleanup(c *C) {
+	runningEngines := map[string]longhorn.InstanceProcess{
+		TestEngineName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName, DataEngine: longhorn.DataEngineTypeV1},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, Type: longhorn.InstanceTypeEngine},
+		},
+	}
+	timeoutKey := types.GetLonghornLabelKey(types.InstanceManagerGracefulCleanupTimeoutAnnotationKeySuffix)
+
+	// The instances are stopped before the pod is deleted.
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	var stoppedInstances []string
+	f.imc.instancesStopper = func(im *longhorn.InstanceManager, instances map[string]longhorn.InstanceProcess) error {
+		for name := range instances {
+			stoppedInstances = append(stoppedInstances, name)
+		}
+		return nil
+	}
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		runningEngines, nil, longhorn.DataEngineTypeV1, true)
+	im.Annotations = map[string]string{timeoutKey: "1h"}
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodRunning, PodIP: TestIP1}, im))
+
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(stoppedInstances, DeepEquals, []string{TestEngineName})
+	c.Assert(f.listPods(c), HasLen, 1)
+
+	im = f.getInstanceManager(c, im.Name)
+	im.Status.InstanceEngines[TestEngineName] = longhorn.InstanceProcess{
+		Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName, DataEngine: longhorn.DataEngineTypeV1},
+		Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateStopped, Type: longhorn.InstanceTypeEngine},
+	}
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).UpdateStatus(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(stoppedInstances, HasLen, 1)
+	c.Assert(f.listPods(c), HasLen, 0)
+
+	// The pod is forcibly deleted once the graceful cleanup timeout is exceeded.
+	f = newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	stoppedInstances = nil
+	f.imc.instancesStopper = func(im *longhorn.InstanceManager, instances map[string]longhorn.InstanceProcess) error {
+		for name := range instances {
+			stoppedInstances = append(stoppedInstances, name)
+		}
+		return nil
+	}
+
+	im = newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		runningEngines, nil, longhorn.DataEngineTypeV1, true)
+	im.Annotations = map[string]string{timeoutKey: "1m"}
+	deletionTimestamp := metav1.NewTime(time.Now().Add(-time.Hour))
+	im.DeletionTimestamp = &deletionTimestamp
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodRunning, PodIP: TestIP1}, im))
+
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(stoppedInstances, HasLen, 0)
+	c.Assert(f.listPods(c), HasLen, 0)
+}
+
+// delayRecordingQueue records the delays of the keys added with AddAfter
+type delayRecordingQueue struct {
+	workqueue.RateLimitingInterface
+	delays map[interface{}]time.Duration
+}
+
+func (q *delayRecordingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delays[item] = duration
+}
+
+func (s *TestSuite) TestInstanceManagerPodEventRequeueJitter(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	queue := &delayRecordingQueue{RateLimitingInterface: f.imc.queue, delays: map[interface{}]time.Duration{}}
+	f.imc.queue = queue
+
+	var pods []*corev1.Pod
+	for i := 0; i < 10; i++ {
+		im := newInstanceManager(fmt.Sprintf("instance-manager-jitter-%d", i), longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+		pods = append(pods, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+	}
+
+	// The pod events of a node-wide change are spread out within the jitter
+	for _, pod := range pods {
+		f.imc.enqueueInstanceManagerPod(pod)
+	}
+	c.Assert(queue.Len(), Equals, 0)
+	c.Assert(queue.delays, HasLen, len(pods))
+	distinctDelays := map[time.Duration]struct{}{}
+	for _, delay := range queue.delays {
+		c.Assert(delay >= instanceManagerPodEventDebounceInterval && delay < instanceManagerPodEventDebounceInterval+time.Second,
+			Equals, true, Commentf("delay %v", delay))
+		distinctDelays[delay] = struct{}{}
+	}
+	c.Assert(len(distinctDelays) > 1, Equals, true)
+
+	// The jitter can be disabled
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodEventRequeueJitter),
+			Namespace: TestNamespace,
+		},
+		Value: "0",
+	})
+	queue.delays = map[interface{}]time.Duration{}
+	for _, pod := range pods {
+		f.imc.enqueueInstanceManagerPod(pod)
+	}
+	c.Assert(queue.Len(), Equals, 0)
+	c.Assert(queue.delays, HasLen, len(pods))
+	for _, delay := range queue.delays {
+		c.Assert(delay, Equals, instanceManagerPodEventDebounceInterval)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodEventDebounce(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodEventRequeueJitter), "0"))
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.queue = workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(),
+		workqueue.RateLimitingQueueConfig{Clock: fakeClock})
+	defer f.imc.queue.ShutDown()
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	pod := newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im)
+
+	// The rapid updates of a busy pod within the debounce interval
+	for i := 0; i < 5; i++ {
+		f.imc.enqueueInstanceManagerPod(pod)
+		fakeClock.Step(instanceManagerPodEventDebounceInterval / 10)
+	}
+	c.Assert(f.imc.queue.Len(), Equals, 0)
+
+	// The updates collapse into a single enqueue once the interval passes
+	fakeClock.Step(instanceManagerPodEventDebounceInterval)
+	for i := 0; i < 100 && f.imc.queue.Len() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(f.imc.queue.Len(), Equals, 1)
+	key, _ := f.imc.queue.Get()
+	c.Assert(key, Equals, TestNamespace+"/"+im.Name)
+	f.imc.queue.Done(key)
+
+	fakeClock.Step(instanceManagerPodEventDebounceInterval)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(f.imc.queue.Len(), Equals, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerPodAdoption(c *C) {
+	for name, tc := range map[string]struct {
+		currentState  longhorn.InstanceManagerState
+		staleOwner    bool
+		expectedState longhorn.InstanceManagerState
+	}{
+		"adopt the pod of the stopped instance manager": {
+			currentState:  longhorn.InstanceManagerStateStopped,
+			expectedState: longhorn.InstanceManagerStateRunning,
+		},
+		"adopt the pod of the new instance manager": {
+			currentState:  "",
+			expectedState: longhorn.InstanceManagerStateRunning,
+		},
+		"recreate the pod of the previous instance manager": {
+			currentState:  longhorn.InstanceManagerStateStopped,
+			staleOwner:    true,
+			expectedState: longhorn.InstanceManagerStateStopped,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+
+		// The controller crashed after creating the pod, before the starting state is persisted
+		im := newInstanceManager(TestInstanceManagerName, tc.currentState, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+		pod := newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im)
+		pod.OwnerReferences = datastore.GetOwnerReferencesForInstanceManager(im)
+		if tc.staleOwner {
+			pod.OwnerReferences[0].UID = "previous-instance-manager-uid"
+		}
+		f.addPod(c, pod)
+
+		im = f.syncInstanceManager(c, im.Name)
+		c.Assert(im.Status.CurrentState, Equals, tc.expectedState)
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		c.Assert(pods[0].OwnerReferences, HasLen, 1)
+		c.Assert(pods[0].OwnerReferences[0].UID, Equals, im.UID)
+		if tc.expectedState == longhorn.InstanceManagerStateRunning {
+			c.Assert(im.Status.IP, Equals, TestIP1)
+		}
+		f.imc.stopMonitoring(im.Name)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerOrphanedPodCleanup(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	// The instance manager has been removed, only the object is used to build the pods
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.UID = "removed-instance-manager-uid"
+
+	newOrphanedPod := func(name, nodeID string, age time.Duration) *corev1.Pod {
+		pod := newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im)
+		pod.Name = name
+		pod.Spec.NodeName = nodeID
+		pod.OwnerReferences = datastore.GetOwnerReferencesForInstanceManager(im)
+		pod.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+		return pod
+	}
+
+	// The pod just created is not cleaned up before the grace period passes
+	pod := newOrphanedPod("instance-manager-orphaned-new", TestNode1, 0)
+	f.addPod(c, pod)
+	f.imc.enqueueInstanceManagerPod(pod)
+	c.Assert(f.imc.queue.Len(), Equals, 0)
+
+	// The pod on the other node is left for the other controller
+	pod = newOrphanedPod("instance-manager-orphaned-other-node", TestNode2, 2*instanceManagerOrphanedPodCleanupGracePeriod)
+	f.addPod(c, pod)
+	f.imc.enqueueInstanceManagerPod(pod)
+	c.Assert(f.imc.queue.Len(), Equals, 0)
+
+	// The pod beyond the grace period is enqueued then deleted
+	pod = newOrphanedPod("instance-manager-orphaned-old", TestNode1, 2*instanceManagerOrphanedPodCleanupGracePeriod)
+	f.addPod(c, pod)
+	f.imc.enqueueInstanceManagerPod(pod)
+	c.Assert(f.imc.queue.Len(), Equals, 1)
+	key, _ := f.imc.queue.Get()
+	c.Assert(key, Equals, TestNamespace+"/"+pod.Name)
+	err := f.imc.syncInstanceManager(key.(string))
+	c.Assert(err, IsNil)
+	f.imc.queue.Done(key)
+
+	_, err = f.kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+	c.Assert(apierrors.IsNotFound(err), Equals, true)
+	c.Assert(f.listPods(c), HasLen, 2)
+}
+
+func (s *TestSuite) TestInstanceManagerInstanceMapDiff(c *C) {
+	newProcess := func(instanceType longhorn.InstanceType, state longhorn.InstanceState) longhorn.InstanceProcess {
+		return longhorn.InstanceProcess{
+			Status: longhorn.InstanceProcessStatus{
+				Type:  instanceType,
+				State: state,
+			},
+		}
+	}
+
+	existing := map[string]longhorn.InstanceProcess{
+		"engine-1":  newProcess(longhorn.InstanceTypeEngine, longhorn.InstanceStateRunning),
+		"replica-1": newProcess(longhorn.InstanceTypeReplica, longhorn.InstanceStateStarting),
+		"replica-2": newProcess(longhorn.InstanceTypeReplica, longhorn.InstanceStateRunning),
+	}
+	current := map[string]longhorn.InstanceProcess{
+		"engine-1":  newProcess(longhorn.InstanceTypeEngine, longhorn.InstanceStateRunning),
+		"replica-1": newProcess(longhorn.InstanceTypeReplica, longhorn.InstanceStateRunning),
+		"replica-3": newProcess(longhorn.InstanceTypeReplica, longhorn.InstanceStateStarting),
+	}
+
+	diff := getInstanceMapDiff(existing, current)
+	c.Assert(diff.isEmpty(), Equals, false)
+	c.Assert(diff.added, DeepEquals, []string{"replica-3"})
+	c.Assert(diff.updated, DeepEquals, []string{"replica-1(starting->running)"})
+	c.Assert(diff.removed, DeepEquals, []string{"replica-2"})
+
+	c.Assert(getInstanceMapDiff(current, current).isEmpty(), Equals, true)
+
+	// The monitor applies the diff to the instance manager status
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		map[string]longhorn.InstanceProcess{"engine-1": existing["engine-1"]},
+		map[string]longhorn.InstanceProcess{"replica-1": existing["replica-1"], "replica-2": existing["replica-2"]},
+		longhorn.DataEngineTypeV1, false)
+	im.Status.APIVersion = engineapi.CurrentInstanceManagerAPIVersion
+	m := &InstanceManagerMonitor{logger: logrus.StandardLogger().WithField("instanceManager", im.Name)}
+	c.Assert(m.updateInstanceMap(im, current), Equals, true)
+	c.Assert(im.Status.InstanceReplicas, HasLen, 2)
+	c.Assert(im.Status.InstanceReplicas["replica-3"], DeepEquals, current["replica-3"])
+	c.Assert(m.updateInstanceMap(im, current), Equals, false)
+}
+
+func (s *TestSuite) TestInstanceManagerUpdateNilInstanceMap(c *C) {
+	newProcesses := func() map[string]longhorn.InstanceProcess {
+		return map[string]longhorn.InstanceProcess{
+			"engine-1": {
+				Spec:   longhorn.InstanceProcessSpec{Name: "engine-1"},
+				Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeEngine, State: longhorn.InstanceStateRunning},
+			},
+			"replica-1": {
+				Spec:   longhorn.InstanceProcessSpec{Name: "replica-1"},
+				Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeReplica, State: longhorn.InstanceStateStarting},
+			},
+		}
+	}
+	m := &InstanceManagerMonitor{logger: logrus.StandardLogger().WithField("instanceManager", TestInstanceManagerName)}
+
+	for _, apiVersion := range []int{3, engineapi.CurrentInstanceManagerAPIVersion} {
+		fmt.Printf("testing API version %v\n", apiVersion)
+
+		// A freshly created instance manager whose instance maps are not initialized
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		im.Status.APIVersion = apiVersion
+		im.Status.Instances = nil
+		im.Status.InstanceEngines = nil
+		im.Status.InstanceReplicas = nil
+
+		c.Assert(m.updateInstanceMap(im, newProcesses()), Equals, true)
+		instances := im.Status.Instances
+		if apiVersion >= 4 {
+			c.Assert(im.Status.Instances, IsNil)
+			c.Assert(im.Status.InstanceEngines, HasLen, 1)
+			c.Assert(im.Status.InstanceReplicas, HasLen, 1)
+			instances = map[string]longhorn.InstanceProcess{
+				"engine-1":  im.Status.InstanceEngines["engine-1"],
+				"replica-1": im.Status.InstanceReplicas["replica-1"],
+			}
+		}
+		c.Assert(instances, HasLen, 2)
+		c.Assert(instances["engine-1"].Status.StartedAt, Not(Equals), "")
+		c.Assert(instances["replica-1"].Status.State, Equals, longhorn.InstanceStateStarting)
+
+		// The empty maps are omitted from the status and read back as nil, which are not updated again
+		c.Assert(m.updateInstanceMap(im, map[string]longhorn.InstanceProcess{}), Equals, true)
+		im.Status.Instances = nil
+		im.Status.InstanceEngines = nil
+		im.Status.InstanceReplicas = nil
+		c.Assert(m.updateInstanceMap(im, map[string]longhorn.InstanceProcess{}), Equals, false)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorPollInterval(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPollInterval),
+			Namespace: TestNamespace,
+		},
+		Value: "30",
+	})
+
+	m := &InstanceManagerMonitor{
+		logger: logrus.StandardLogger().WithField("instanceManager", TestInstanceManagerName),
+		Name:   TestInstanceManagerName,
+		ds:     f.imc.ds,
+		lock:   &sync.RWMutex{},
+	}
+	pollInterval := m.getPollInterval()
+	c.Assert(pollInterval, Equals, 30*time.Second)
+
+	now := time.Now()
+	c.Assert(m.shouldPoll(now, pollInterval), Equals, true)
+	// The second poll in quick succession is skipped
+	c.Assert(m.shouldPoll(now.Add(time.Second), pollInterval), Equals, false)
+
+	// The poll notified by the instance watch is not throttled
+	m.updateNotification = true
+	c.Assert(m.shouldPoll(now.Add(2*time.Second), pollInterval), Equals, true)
+	c.Assert(m.updateNotification, Equals, false)
+	c.Assert(m.shouldPoll(now.Add(3*time.Second), pollInterval), Equals, false)
+
+	c.Assert(m.shouldPoll(now.Add(2*time.Second+pollInterval), pollInterval), Equals, true)
+}
+
+func (s *TestSuite) TestInstanceManagerAPIReady(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStarting, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	// A stale value left by the previous monitor
+	im.Status.APIReady = true
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.APIReady, Equals, false)
+
+	pollErr := fmt.Errorf("failed to list instances")
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:         im.Name,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        f.imc.clock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return nil, pollErr
+		},
+	}
+
+	// The failed initial poll keeps the API unready
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.APIReady, Equals, false)
+
+	pollErr = nil
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.APIReady, Equals, true)
+}
+
+func (s *TestSuite) TestRefreshInstanceManager(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The instance manager is not monitored, so only the sync is triggered
+	c.Assert(f.imc.RefreshInstanceManager(im.Name), IsNil)
+	c.Assert(f.imc.queue.Len(), Equals, 1)
+	key, _ := f.imc.queue.Get()
+	f.imc.queue.Done(key)
+	f.imc.queue.Forget(key)
+
+	pollCount := 0
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:         im.Name,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        f.imc.clock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			pollCount++
+			return map[string]longhorn.InstanceProcess{
+				"engine-1": {
+					Spec:   longhorn.InstanceProcessSpec{Name: "engine-1"},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeEngine, State: longhorn.InstanceStateRunning},
+				},
+			}, nil
+		},
+	}
+	f.imc.instanceManagerMonitorMap[im.Name] = make(chan struct{}, 1)
+	f.imc.instanceManagerMonitors[im.Name] = m
+
+	// The monitored instance manager is polled immediately
+	c.Assert(f.imc.RefreshInstanceManager(im.Name), IsNil)
+	c.Assert(pollCount, Equals, 1)
+	c.Assert(f.imc.queue.Len(), Equals, 1)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.APIReady, Equals, true)
+	c.Assert(im.Status.InstanceEngines, HasLen, 1)
+	c.Assert(im.Status.InstanceEngines["engine-1"].Status.State, Equals, longhorn.InstanceStateRunning)
+
+	// The instance manager owned by another node cannot be refreshed
+	im.Status.OwnerID = TestNode2
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).UpdateStatus(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	err = f.imIndexer.Update(im)
+	c.Assert(err, IsNil)
+	c.Assert(f.imc.RefreshInstanceManager(im.Name), NotNil)
+	c.Assert(pollCount, Equals, 1)
+
+	c.Assert(f.imc.RefreshInstanceManager("nonexistent"), NotNil)
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorUpdateConflict(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The instance manager is always updated by others first
+	updateCount := 0
+	f.lhClient.PrependReactor("update", "instancemanagers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		updateCount++
+		return true, nil, apierrors.NewConflict(longhorn.Resource("instancemanagers"), im.Name, fmt.Errorf("conflict"))
+	})
+
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:         im.Name,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        f.imc.clock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return map[string]longhorn.InstanceProcess{
+				"engine-1": {
+					Spec:   longhorn.InstanceProcessSpec{Name: "engine-1"},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeEngine, State: longhorn.InstanceStateRunning},
+				},
+			}, nil
+		},
+	}
+
+	// The conflicts are retried on the next ticks until the limit is reached
+	for i := 1; i < instanceManagerMonitorMaxConflictRetryCount; i++ {
+		c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+		c.Assert(m.shouldPoll(m.lastPollTime, time.Hour), Equals, true)
+		c.Assert(m.updateConflictCount, Equals, i)
+	}
+
+	// The update is then dropped rather than retried again
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	c.Assert(m.shouldPoll(m.lastPollTime, time.Hour), Equals, false)
+	c.Assert(m.updateConflictCount, Equals, 0)
+	c.Assert(updateCount, Equals, instanceManagerMonitorMaxConflictRetryCount)
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorConcurrentPolls(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.ResourceVersion = "1"
+	f.addInstanceManager(c, im)
+
+	// Simulate the optimistic concurrency of the API server, with the indexer catching up immediately
+	var apiLock sync.Mutex
+	resourceVersion, conflictCount := 1, 0
+	f.lhClient.PrependReactor("update", "instancemanagers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		apiLock.Lock()
+		defer apiLock.Unlock()
+		obj := action.(k8stesting.UpdateAction).GetObject().(*longhorn.InstanceManager).DeepCopy()
+		if obj.ResourceVersion != strconv.Itoa(resourceVersion) {
+			conflictCount++
+			return true, nil, apierrors.NewConflict(longhorn.Resource("instancemanagers"), obj.Name, fmt.Errorf("conflict"))
+		}
+		resourceVersion++
+		obj.ResourceVersion = strconv.Itoa(resourceVersion)
+		if err := f.imIndexer.Update(obj); err != nil {
+			return true, nil, err
+		}
+		return true, obj, nil
+	})
+
+	// Every poll reports a different instance after a while, so every poll updates the instance map and the polls
+	// overlap if they are not serialized
+	var listCount int64
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:         im.Name,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        f.imc.clock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			time.Sleep(time.Millisecond)
+			name := fmt.Sprintf("engine-%d", atomic.AddInt64(&listCount, 1))
+			return map[string]longhorn.InstanceProcess{
+				name: {
+					Spec:   longhorn.InstanceProcessSpec{Name: name},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeEngine, State: longhorn.InstanceStateRunning},
+				},
+			}, nil
+		},
+	}
+
+	// The polls notified by the instance watch overlap with the on-demand refreshes
+	pollCount := 10
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < pollCount; j++ {
+				m.pollAndUpdateInstanceMap()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The read-modify-write cycles are serialized by the monitor, so no update conflicts
+	c.Assert(conflictCount, Equals, 0)
+	c.Assert(resourceVersion, Equals, 1+2*pollCount)
+}
+
+func (s *TestSuite) TestInstanceManagerHeldStopped(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.Spec.DesiredState = longhorn.InstanceManagerStateStopped
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+	stopCh := make(chan struct{}, 1)
+	f.imc.instanceManagerMonitorMap[im.Name] = stopCh
+
+	// The pod and the monitor are torn down
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStopped)
+	c.Assert(im.Status.APIReady, Equals, false)
+	c.Assert(f.listPods(c), HasLen, 0)
+	select {
+	case <-stopCh:
+	default:
+		c.Fatal("the monitor is not stopped")
+	}
+
+	// The pod is not recreated while the instance manager is held stopped
+	c.Assert(f.pIndexer.Delete(newInstanceManagerTestPod(&corev1.PodStatus{}, im)), IsNil)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStopped)
+	c.Assert(f.listPods(c), HasLen, 0)
+
+	// The pod is recreated once the instance manager is desired to run again
+	im.Spec.DesiredState = longhorn.InstanceManagerStateRunning
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Update(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStopped)
+	pods := f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+
+	pod := &pods[0]
+	pod.Status = corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}
+	f.updatePod(c, pod)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+}
+
+func (s *TestSuite) TestInstanceManagerHeldStoppedWithRunningInstances(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	replicas := map[string]longhorn.InstanceProcess{
+		TestReplicaName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestReplicaName, DataEngine: longhorn.DataEngineTypeV1},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, Type: longhorn.InstanceTypeReplica},
+		},
+	}
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, replicas, longhorn.DataEngineTypeV1, false)
+	im.Spec.DesiredState = longhorn.InstanceManagerStateStopped
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+
+	// The pod is kept while the instances are running
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.InstanceReplicas, HasLen, 1)
+	c.Assert(f.listPods(c), HasLen, 1)
+
+	// The force deletion annotation stops the instance manager regardless of the running instances
+	im.Annotations = map[string]string{
+		types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix): "true",
+	}
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Update(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStopped)
+	c.Assert(f.listPods(c), HasLen, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerEngineImageCompatibility(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	ei := newEngineImage(TestInstanceManagerImage, longhorn.EngineImageStateDeploying)
+	c.Assert(f.eiIndexer.Add(ei), IsNil)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The pod creation waits for the engine image to be deployed
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStopped)
+	c.Assert(f.listPods(c), HasLen, 0)
+
+	ei.Status.State = longhorn.EngineImageStateIncompatible
+	ei.Status.Incompatible = true
+	c.Assert(f.eiIndexer.Update(ei), IsNil)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(im.Status.Message, Equals, fmt.Sprintf("Engine image %v of image %v is incompatible", ei.Name, TestInstanceManagerImage))
+	c.Assert(f.listPods(c), HasLen, 0)
+	events := f.imc.eventRecorder.(*record.FakeRecorder).Events
+	c.Assert(events, HasLen, 1)
+	c.Assert(<-events, Matches, "Warning "+constant.EventReasonFailedStarting+" .*incompatible")
+
+	// The event is not recorded again for the same failure
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(events, HasLen, 0)
+
+	ei.Status.State = longhorn.EngineImageStateDeployed
+	ei.Status.Incompatible = false
+	c.Assert(f.eiIndexer.Update(ei), IsNil)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(f.listPods(c), HasLen, 1)
+}
+
+func (s *TestSuite) TestInstanceManagerAPIReadiness(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	checkCount := 0
+	apiErr := fmt.Errorf("failed to list instances")
+	f.imc.apiReadinessChecker = func(im *longhorn.InstanceManager) error {
+		checkCount++
+		c.Assert(im.Status.IP, Equals, TestIP1)
+		return apiErr
+	}
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStarting, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	// The health probe passed
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{
+		PodIP:             TestIP1,
+		Phase:             corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "instance-manager", Ready: true}},
+	}, im))
+
+	// The instance manager stays starting until the instances can be listed
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(im.Status.IP, Equals, "")
+	c.Assert(checkCount, Equals, 1)
+
+	apiErr = nil
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.IP, Equals, TestIP1)
+	c.Assert(checkCount, Equals, 2)
+
+	// The running instance manager is not checked again
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(checkCount, Equals, 2)
+	f.imc.stopMonitoring(im.Name)
+}
+
+func (s *TestSuite) TestInstanceManagerRunningWithEmptyIP(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	checkCount := 0
+	f.imc.apiReadinessChecker = func(im *longhorn.InstanceManager) error {
+		checkCount++
+		c.Assert(im.Status.IP, Equals, TestIP1)
+		return nil
+	}
+
+	// The running state is persisted without the IP before the controller crashes
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	podStatus := &corev1.PodStatus{
+		Phase:             corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "instance-manager", Ready: true}},
+	}
+	f.addPod(c, newInstanceManagerTestPod(podStatus, im))
+
+	// The instance manager waits for the pod IP instead of monitoring without it
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(im.Status.IP, Equals, "")
+	c.Assert(checkCount, Equals, 0)
+	c.Assert(f.imc.isMonitoring(im.Name), Equals, false)
+
+	// The IP is recovered from the healthy pod
+	im.Status.CurrentState = longhorn.InstanceManagerStateRunning
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).UpdateStatus(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+	podStatus.PodIP = TestIP1
+	f.updatePod(c, newInstanceManagerTestPod(podStatus, im))
+
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.IP, Equals, TestIP1)
+	c.Assert(checkCount, Equals, 1)
+	f.imc.stopMonitoring(im.Name)
+}
+
+func (s *TestSuite) TestInstanceManagerPodRecreationBackoff(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+	events := f.imc.eventRecorder.(*record.FakeRecorder).Events
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateError, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// failPod marks the recreated pod failed, so the next sync moves the instance manager to error again
+	failPod := func() *corev1.Pod {
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		pod := pods[0].DeepCopy()
+		pod.Status.Phase = corev1.PodFailed
+		pod, err := f.kubeClient.CoreV1().Pods(TestNamespace).Update(context.TODO(), pod, metav1.UpdateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(f.pIndexer.Add(pod), IsNil)
+		return pod
+	}
+
+	// The pod is recreated immediately until the consecutive recreations reach the threshold
+	for i := 0; i < instanceManagerPodRecreationBackoffThreshold; i++ {
+		im = f.syncInstanceManager(c, im.Name)
+		c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+		failPod()
+		fakeClock.Step(time.Second)
+	}
+	for len(events) > 0 {
+		<-events
+	}
+
+	// The recreation is then delayed
+	failPod()
+	f.syncInstanceManager(c, im.Name)
+	pods := f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	c.Assert(pods[0].Status.Phase, Equals, corev1.PodFailed)
+	throttlingEventCount := 0
+	for len(events) > 0 {
+		if event := <-events; strings.Contains(event, "Delaying the pod recreation") {
+			throttlingEventCount++
+		}
+	}
+	c.Assert(throttlingEventCount, Equals, 1)
+
+	// The throttling event is not recorded again in the same backoff
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(f.listPods(c)[0].Status.Phase, Equals, corev1.PodFailed)
+	for len(events) > 0 {
+		c.Assert(<-events, Not(Matches), ".*Delaying the pod recreation.*")
+	}
+
+	// The pod is recreated after the backoff
+	fakeClock.Step(instanceManagerPodRecreationBackoffInitialDuration)
+	f.syncInstanceManager(c, im.Name)
+	pods = f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	c.Assert(pods[0].Status.Phase, Not(Equals), corev1.PodFailed)
+
+	// The count is reset after the instance manager keeps running for a while
+	im = f.getInstanceManager(c, im.Name)
+	im.Status.CurrentState = longhorn.InstanceManagerStateRunning
+	im.Status.CurrentStateTransitionTime = fakeClock.Now().UTC().Format(time.RFC3339)
+	c.Assert(f.imc.resetStableInstanceManagerPodRecreation(im), IsNil)
+	c.Assert(f.imc.podRecreations, HasLen, 1)
+	fakeClock.Step(instanceManagerPodRecreationResetPeriod)
+	c.Assert(f.imc.resetStableInstanceManagerPodRecreation(im), IsNil)
+	c.Assert(f.imc.podRecreations, HasLen, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerOwnerChangeEvent(c *C) {
+	for name, tc := range map[string]struct {
+		currentOwnerID string
+		nodeID         string
+		expectedEvent  string
+	}{
+		"take over the unowned instance manager": {
+			currentOwnerID: "",
+			nodeID:         TestNode1,
+			expectedEvent:  `Owner changed from "" to ` + TestNode1 + " since the instance manager was unowned",
+		},
+		"take back the instance manager after the preferred owner node recovered": {
+			currentOwnerID: TestNode2,
+			nodeID:         TestNode1,
+			expectedEvent:  `Owner changed from "` + TestNode2 + `" to ` + TestNode1 + " since the preferred owner node recovered",
+		},
+		"take over the instance manager after the owner node is down": {
+			currentOwnerID: TestNode2,
+			nodeID:         TestNode2,
+			expectedEvent:  `Owner changed from "` + TestNode2 + `" to ` + TestNode1 + " since the owner node " + TestNode2 + " is down",
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		// TestNode2 doesn't exist, so it's considered down
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, tc.currentOwnerID, tc.nodeID, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+
+		im = f.syncInstanceManager(c, im.Name)
+		c.Assert(im.Status.OwnerID, Equals, TestNode1)
+		events := f.imc.eventRecorder.(*record.FakeRecorder).Events
+		c.Assert(len(events) > 0, Equals, true)
+		c.Assert(<-events, Equals, "Normal "+constant.EventReasonOwnerChanged+" "+tc.expectedEvent)
+
+		// The event is not recorded again without the ownership change
+		for len(events) > 0 {
+			c.Assert(<-events, Not(Matches), ".*"+constant.EventReasonOwnerChanged+".*")
+		}
+		f.syncInstanceManager(c, im.Name)
+		for len(events) > 0 {
+			c.Assert(<-events, Not(Matches), ".*"+constant.EventReasonOwnerChanged+".*")
+		}
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodCreationLimit(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameConcurrentInstanceManagerPodCreationPerNodeLimit),
+			Namespace: TestNamespace,
+		},
+		Value: "1",
+	})
+
+	startingIM := newInstanceManager("starting-instance-manager", longhorn.InstanceManagerStateStarting, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV2, false)
+	f.addInstanceManager(c, startingIM)
+	// The instance manager starting on another node doesn't count
+	otherNodeIM := newInstanceManager("other-node-instance-manager", longhorn.InstanceManagerStateStarting, TestNode2, TestNode2, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, otherNodeIM)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The pod creation is deferred since the limit is reached
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStopped)
+	c.Assert(f.listPods(c), HasLen, 0)
+
+	// The pod is created once the other instance manager is running
+	startingIM.Status.CurrentState = longhorn.InstanceManagerStateRunning
+	c.Assert(f.imIndexer.Update(startingIM), IsNil)
+	f.syncInstanceManager(c, im.Name)
+	pods := f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	c.Assert(pods[0].Name, Equals, im.Name)
+}
+
+func (s *TestSuite) TestInstanceManagerPodEngineImageDigest(c *C) {
+	digestAnnotationKey := types.GetLonghornLabelKey(types.EngineImageDigestAnnotationKeySuffix)
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	for name, tc := range map[string]struct {
+		imageDigest        string
+		expectedAnnotation bool
+	}{
+		"annotate the pod with the resolved digest": {
+			imageDigest:        digest,
+			expectedAnnotation: true,
+		},
+		"skip the annotation if the digest is not resolved yet": {
+			imageDigest:        "",
+			expectedAnnotation: false,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+
+		ei := newEngineImage(TestInstanceManagerImage, longhorn.EngineImageStateDeployed)
+		ei.Status.ImageDigest = tc.imageDigest
+		c.Assert(f.eiIndexer.Add(ei), IsNil)
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+
+		f.syncInstanceManager(c, im.Name)
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		annotation, ok := pods[0].Annotations[digestAnnotationKey]
+		c.Assert(ok, Equals, tc.expectedAnnotation)
+		c.Assert(annotation, Equals, tc.imageDigest)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerConditions(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	getCondition := func(im *longhorn.InstanceManager, conditionType string) longhorn.Condition {
+		return types.GetCondition(im.Status.Conditions, conditionType)
+	}
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	im = f.syncInstanceManager(c, im.Name)
+	startTime := fakeClock.Now().UTC().Format(time.RFC3339)
+	condition := getCondition(im, longhorn.InstanceManagerConditionTypePodScheduled)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusFalse)
+	c.Assert(condition.Reason, Equals, longhorn.InstanceManagerConditionReasonPodNotFound)
+	c.Assert(condition.LastTransitionTime, Equals, startTime)
+	condition = getCondition(im, longhorn.InstanceManagerConditionTypeReady)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusFalse)
+	c.Assert(condition.Reason, Equals, longhorn.InstanceManagerConditionReasonNotRunning)
+	c.Assert(getCondition(im, longhorn.InstanceManagerConditionTypeWatchEstablished).Status, Equals, longhorn.ConditionStatusFalse)
+
+	// The pending pod is not scheduled yet
+	pods := f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	pod := &pods[0]
+	pod.Status = corev1.PodStatus{
+		Phase: corev1.PodPending,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable, Message: "node is full"},
+		},
+	}
+	f.updatePod(c, pod)
+	fakeClock.Step(time.Minute)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	condition = getCondition(im, longhorn.InstanceManagerConditionTypePodScheduled)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusFalse)
+	c.Assert(condition.Reason, Equals, longhorn.InstanceManagerConditionReasonPodUnschedulable)
+	c.Assert(condition.Message, Matches, ".*node is full.*")
+	c.Assert(condition.LastTransitionTime, Equals, startTime)
+	condition = getCondition(im, longhorn.InstanceManagerConditionTypeReady)
+	c.Assert(condition.Message, Equals, "Instance manager is in state starting")
+
+	// The running pod makes the instance manager ready before the instance watch is established
+	pod.Status = corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		PodIP: TestIP1,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+		},
+	}
+	f.updatePod(c, pod)
+	fakeClock.Step(time.Minute)
+	runningTime := fakeClock.Now().UTC().Format(time.RFC3339)
+	// Pretend the monitor is running
+	f.imc.instanceManagerMonitorMap[im.Name] = make(chan struct{}, 1)
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	condition = getCondition(im, longhorn.InstanceManagerConditionTypePodScheduled)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusTrue)
+	c.Assert(condition.LastTransitionTime, Equals, runningTime)
+	condition = getCondition(im, longhorn.InstanceManagerConditionTypeReady)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusTrue)
+	c.Assert(condition.Reason, Equals, "")
+	c.Assert(condition.LastTransitionTime, Equals, runningTime)
+	c.Assert(getCondition(im, longhorn.InstanceManagerConditionTypeWatchEstablished).Status, Equals, longhorn.ConditionStatusFalse)
+
+	// The monitor completes the initial poll
+	im.Status.APIReady = true
+	im, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).UpdateStatus(context.TODO(), im, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+	fakeClock.Step(time.Minute)
+	im = f.syncInstanceManager(c, im.Name)
+	condition = getCondition(im, longhorn.InstanceManagerConditionTypeWatchEstablished)
+	c.Assert(condition.Status, Equals, longhorn.ConditionStatusTrue)
+	c.Assert(condition.LastTransitionTime, Equals, fakeClock.Now().UTC().Format(time.RFC3339))
+	c.Assert(getCondition(im, longhorn.InstanceManagerConditionTypeReady).LastTransitionTime, Equals, runningTime)
+	f.imc.stopMonitoring(im.Name)
+}
+
+func (s *TestSuite) TestInstanceManagerNodeBootIDChange(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	updateNodeBootID := func(bootID string) {
+		kubeNode, err := f.kubeClient.CoreV1().Nodes().Get(context.TODO(), TestNode1, metav1.GetOptions{})
+		c.Assert(err, IsNil)
+		kubeNode.Status.NodeInfo.BootID = bootID
+		kubeNode, err = f.kubeClient.CoreV1().Nodes().Update(context.TODO(), kubeNode, metav1.UpdateOptions{})
+		c.Assert(err, IsNil)
+		err = f.kubeNodeIndexer.Update(kubeNode)
+		c.Assert(err, IsNil)
+	}
+
+	instanceEngines := map[string]longhorn.InstanceProcess{
+		TestEngineName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName, DataEngine: longhorn.DataEngineTypeV1},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, Type: longhorn.InstanceTypeEngine},
+		},
+	}
+	instanceReplicas := map[string]longhorn.InstanceProcess{
+		TestReplicaName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestReplicaName, DataEngine: longhorn.DataEngineTypeV1},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, Type: longhorn.InstanceTypeReplica},
+		},
+	}
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		instanceEngines, instanceReplicas, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+
+	// The boot ID is recorded once the instance manager is running
+	updateNodeBootID("boot-id-1")
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.NodeBootID, Equals, "boot-id-1")
+	c.Assert(im.Status.InstanceEngines[TestEngineName].Status.State, Equals, longhorn.InstanceStateRunning)
+
+	// The node rebooted while the instance manager survived
+	updateNodeBootID("boot-id-2")
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.NodeBootID, Equals, "boot-id-2")
+	c.Assert(im.Status.InstanceEngines[TestEngineName].Status.State, Equals, longhorn.InstanceStateError)
+	c.Assert(im.Status.InstanceReplicas[TestReplicaName].Status.State, Equals, longhorn.InstanceStateError)
+
+	recorder := f.imc.eventRecorder.(*record.FakeRecorder)
+	c.Assert(recorder.Events, HasLen, 1)
+	event := <-recorder.Events
+	c.Assert(event, Matches, corev1.EventTypeWarning+" "+constant.EventReasonNodeRebooted+" .*boot-id-1.*boot-id-2.*")
+
+	f.imc.stopMonitoring(TestInstanceManagerName)
+}
+
+func (s *TestSuite) TestInstanceManagerNodeBootIDUnavailable(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	kubeNode, err := f.kubeClient.CoreV1().Nodes().Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	kubeNode.Status.NodeInfo.BootID = "boot-id-1"
+
+	// The Kubernetes node is momentarily unavailable
+	err = f.kubeNodeIndexer.Delete(kubeNode)
+	c.Assert(err, IsNil)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStarting, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+
+	err = f.imc.syncStatusWithPod(im)
+	c.Assert(err, IsNil)
+	err = f.imc.syncStatusWithNode(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.IP, Equals, TestIP1)
+	c.Assert(im.Status.NodeBootID, Equals, "")
+
+	// The boot ID is filled in once the Kubernetes node is available again
+	err = f.kubeNodeIndexer.Add(kubeNode)
+	c.Assert(err, IsNil)
+	err = f.imc.syncStatusWithNode(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.NodeBootID, Equals, "boot-id-1")
+}
+
+func (s *TestSuite) TestInstanceManagerNodeBootIDNotReported(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	updateNodeBootID := func(bootID string) {
+		kubeNode, err := f.kubeClient.CoreV1().Nodes().Get(context.TODO(), TestNode1, metav1.GetOptions{})
+		c.Assert(err, IsNil)
+		kubeNode.Status.NodeInfo.BootID = bootID
+		kubeNode, err = f.kubeClient.CoreV1().Nodes().Update(context.TODO(), kubeNode, metav1.UpdateOptions{})
+		c.Assert(err, IsNil)
+		err = f.kubeNodeIndexer.Update(kubeNode)
+		c.Assert(err, IsNil)
+	}
+
+	instanceEngines := map[string]longhorn.InstanceProcess{
+		TestEngineName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName, DataEngine: longhorn.DataEngineTypeV1},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, Type: longhorn.InstanceTypeEngine},
+		},
+	}
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStarting, TestNode1, TestNode1, "",
+		instanceEngines, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+
+	// The boot ID is not recorded before the instance manager is running
+	updateNodeBootID("")
+	err := f.imc.syncStatusWithNode(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.NodeBootID, Equals, "")
+
+	// The node lacking the boot ID is recorded explicitly once the instance manager is running
+	err = f.imc.syncStatusWithPod(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	err = f.imc.syncStatusWithNode(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.NodeBootID, Equals, longhorn.InstanceManagerNodeBootIDUnknown)
+
+	// The boot ID reported later is recorded without being mistaken for a reboot
+	updateNodeBootID("boot-id-1")
+	err = f.imc.syncStatusWithNode(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.NodeBootID, Equals, "boot-id-1")
+	c.Assert(im.Status.InstanceEngines[TestEngineName].Status.State, Equals, longhorn.InstanceStateRunning)
+
+	// The recorded boot ID is kept if the node stops reporting it
+	updateNodeBootID("")
+	err = f.imc.syncStatusWithNode(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.NodeBootID, Equals, "boot-id-1")
+
+	recorder := f.imc.eventRecorder.(*record.FakeRecorder)
+	c.Assert(recorder.Events, HasLen, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerPodLivenessProbe(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The default values are used without the setting
+	pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	probe := pod.Spec.Containers[0].LivenessProbe
+	c.Assert(probe, NotNil)
+	c.Assert(probe.InitialDelaySeconds, Equals, int32(datastore.PodProbeInitialDelay))
+	c.Assert(probe.PeriodSeconds, Equals, int32(datastore.PodProbePeriodSeconds))
+	c.Assert(probe.TimeoutSeconds, Equals, int32(datastore.PodProbeTimeoutSeconds))
+	c.Assert(probe.FailureThreshold, Equals, int32(datastore.PodLivenessProbeFailureThreshold))
+
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodLivenessProbe),
+			Namespace: TestNamespace,
+		},
+		Value: "initial-delay-seconds:30; period-seconds:10; failure-threshold:60",
+	})
+
+	pod, err = f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	probe = pod.Spec.Containers[0].LivenessProbe
+	c.Assert(probe, NotNil)
+	c.Assert(probe.Exec, NotNil)
+	c.Assert(probe.InitialDelaySeconds, Equals, int32(30))
+	c.Assert(probe.PeriodSeconds, Equals, int32(10))
+	c.Assert(probe.TimeoutSeconds, Equals, int32(datastore.PodProbeTimeoutSeconds))
+	c.Assert(probe.FailureThreshold, Equals, int32(60))
+}
+
+func (s *TestSuite) TestInstanceManagerPodStartupProbe(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The startup probe checks the same services as the liveness probe with a generous window by default
+	pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	probe := pod.Spec.Containers[0].StartupProbe
+	c.Assert(probe, NotNil)
+	c.Assert(probe.Exec, DeepEquals, pod.Spec.Containers[0].LivenessProbe.Exec)
+	c.Assert(probe.InitialDelaySeconds, Equals, int32(datastore.PodProbeInitialDelay))
+	c.Assert(probe.PeriodSeconds, Equals, int32(datastore.PodProbePeriodSeconds))
+	c.Assert(probe.TimeoutSeconds, Equals, int32(datastore.PodProbeTimeoutSeconds))
+	c.Assert(probe.FailureThreshold, Equals, int32(datastore.PodStartupProbeFailureThreshold))
+
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodStartupProbe), "period-seconds:10; failure-threshold:90"))
+
+	pod, err = f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	probe = pod.Spec.Containers[0].StartupProbe
+	c.Assert(probe, NotNil)
+	c.Assert(probe.Exec, NotNil)
+	c.Assert(probe.PeriodSeconds, Equals, int32(10))
+	c.Assert(probe.FailureThreshold, Equals, int32(90))
+	// The liveness probe is not loosened
+	c.Assert(pod.Spec.Containers[0].LivenessProbe.FailureThreshold, Equals, int32(datastore.PodLivenessProbeFailureThreshold))
+}
+
+func (s *TestSuite) TestInstanceManagerPodImagePullPolicy(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The default value is used without the setting
+	pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.Containers[0].ImagePullPolicy, Equals, corev1.PullIfNotPresent)
+
+	setting := &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameSystemManagedPodsImagePullPolicy),
+			Namespace: TestNamespace,
+		},
+		Value: string(types.SystemManagedPodsImagePullPolicyIfNotPresent),
+	}
+	f.addSetting(c, setting)
+
+	for value, expectedPolicy := range map[types.SystemManagedPodsImagePullPolicy]corev1.PullPolicy{
+		types.SystemManagedPodsImagePullPolicyAlways:       corev1.PullAlways,
+		types.SystemManagedPodsImagePullPolicyNever:        corev1.PullNever,
+		types.SystemManagedPodsImagePullPolicyIfNotPresent: corev1.PullIfNotPresent,
+	} {
+		fmt.Printf("testing %v\n", value)
+
+		setting.Value = string(value)
+		f.updateSetting(c, setting)
+
+		pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+		c.Assert(err, IsNil)
+		c.Assert(pod.Spec.Containers[0].ImagePullPolicy, Equals, expectedPolicy)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodCustomMetadata(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodLabels),
+			Namespace: TestNamespace,
+		},
+		Value: "cost-center:storage; example.com/team:infra",
+	})
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodAnnotations),
+			Namespace: TestNamespace,
+		},
+		Value: "example.com/dashboard:https://dashboard.example.com",
+	})
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	err := f.imc.createInstanceManagerPod(im)
+	c.Assert(err, IsNil)
+	pod, err := f.kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+
+	c.Assert(pod.Labels["cost-center"], Equals, "storage")
+	c.Assert(pod.Labels["example.com/team"], Equals, "infra")
+	for key, value := range types.GetInstanceManagerLabels(TestNode1, im.Spec.Image, longhorn.InstanceManagerTypeAllInOne, im.Spec.DataEngine) {
+		c.Assert(pod.Labels[key], Equals, value)
+	}
+	c.Assert(pod.Annotations["example.com/dashboard"], Equals, "https://dashboard.example.com")
+	c.Assert(pod.Annotations[types.GetLonghornLabelKey(types.LastAppliedTolerationAnnotationKeySuffix)], Not(Equals), "")
+
+	// The existing labels and annotations are not overwritten
+	pod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"cost-center": "longhorn"},
+			Annotations: map[string]string{"example.com/dashboard": "longhorn"},
+		},
+	}
+	err = f.imc.addInstanceManagerPodCustomMetadata(pod)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Labels, DeepEquals, map[string]string{"cost-center": "longhorn", "example.com/team": "infra"})
+	c.Assert(pod.Annotations, DeepEquals, map[string]string{"example.com/dashboard": "longhorn"})
+}
+
+func (s *TestSuite) TestInstanceManagerPodCreationDryRun(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodCreationDryRun),
+			Namespace: TestNamespace,
+		},
+		Value: "true",
+	})
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// No pod is created in dry-run mode
+	err := f.imc.createInstanceManagerPod(im)
+	c.Assert(err, IsNil)
+	c.Assert(f.listPods(c), HasLen, 0)
+
+	pod, err := f.imc.BuildInstanceManagerPodSpec(im)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Name, Equals, im.Name)
+	c.Assert(pod.Spec.Containers[0].Image, Equals, TestInstanceManagerImage)
+	podYAML, err := getPodYAML(pod)
+	c.Assert(err, IsNil)
+	c.Assert(podYAML, Matches, "(?s)apiVersion: v1\nkind: Pod\n.*image: "+TestInstanceManagerImage+"\n.*")
+
+	// The pod is logged once per spec change
+	c.Assert(f.imc.dryRunPodSpecs[im.Name], Equals, podYAML)
+	c.Assert(f.imc.observeDryRunPodSpec(im.Name, podYAML), Equals, false)
+
+	// The skipped creation in dry-run mode is not counted as a recreation
+	im.Status.CurrentState = longhorn.InstanceManagerStateError
+	err = f.imc.createInstanceManagerPod(im)
+	c.Assert(err, IsNil)
+	c.Assert(f.listPods(c), HasLen, 0)
+	c.Assert(f.imc.podRecreations[im.Name], IsNil)
+}
+
+func (s *TestSuite) TestInstanceManagerHostNetwork(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStarting, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// The host network cannot be shared by the instance managers of both data engines
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameV2DataEngine),
+			Namespace: TestNamespace,
+		},
+		Value: "true",
+	})
+	c.Assert(f.imc.ds.ValidateSetting(string(types.SettingNameInstanceManagerHostNetwork), "true"), NotNil)
+	c.Assert(f.imc.ds.ValidateSetting(string(types.SettingNameInstanceManagerHostNetwork), "false"), IsNil)
+	v2DataEngine, err := f.lhClient.LonghornV1beta2().Settings(TestNamespace).Get(context.TODO(), string(types.SettingNameV2DataEngine), metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	v2DataEngine.Value = "false"
+	f.updateSetting(c, v2DataEngine)
+	c.Assert(f.imc.ds.ValidateSetting(string(types.SettingNameInstanceManagerHostNetwork), "true"), IsNil)
+
+	// The pod network is used by default
+	pod, err := f.imc.BuildInstanceManagerPodSpec(im)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.HostNetwork, Equals, false)
+	c.Assert(pod.Spec.DNSPolicy, Equals, corev1.DNSPolicy(""))
+
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerHostNetwork),
+			Namespace: TestNamespace,
+		},
+		Value: "true",
+	})
+	pod, err = f.imc.BuildInstanceManagerPodSpec(im)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.HostNetwork, Equals, true)
+	c.Assert(pod.Spec.DNSPolicy, Equals, corev1.DNSClusterFirstWithHostNet)
+	c.Assert(f.imc.ds.ValidateSetting(string(types.SettingNameV2DataEngine), "true"), NotNil)
+
+	kubeNode, err := f.kubeClient.CoreV1().Nodes().Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	kubeNode.Status.Addresses = []corev1.NodeAddress{
+		{Type: corev1.NodeHostName, Address: TestNode1},
+		{Type: corev1.NodeInternalIP, Address: TestIP2},
+	}
+	c.Assert(f.kubeNodeIndexer.Update(kubeNode), IsNil)
+
+	hostIP := "9.10.11.12"
+	testCases := map[string]struct {
+		hostNetwork   bool
+		nodeAddresses bool
+		expectedIP    string
+	}{
+		"pod network": {
+			hostNetwork:   false,
+			nodeAddresses: true,
+			expectedIP:    TestIP1,
+		},
+		"host network": {
+			hostNetwork:   true,
+			nodeAddresses: true,
+			expectedIP:    TestIP2,
+		},
+		"host network falls back to host IP": {
+			hostNetwork:   true,
+			nodeAddresses: false,
+			expectedIP:    hostIP,
+		},
+	}
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		node := kubeNode.DeepCopy()
+		if !tc.nodeAddresses {
+			node.Status.Addresses = nil
+		}
+		c.Assert(f.kubeNodeIndexer.Update(node), IsNil)
+
+		pod := newInstanceManagerTestPod(&corev1.PodStatus{
+			Phase:  corev1.PodRunning,
+			PodIP:  TestIP1,
+			HostIP: hostIP,
+		}, im)
+		pod.Spec.HostNetwork = tc.hostNetwork
+		c.Assert(f.pIndexer.Add(pod), IsNil)
+
+		im := im.DeepCopy()
+		im.Status.CurrentState = longhorn.InstanceManagerStateStarting
+		err := f.imc.syncStatusWithPod(im)
+		c.Assert(err, IsNil)
+		c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+		c.Assert(im.Status.IP, Equals, tc.expectedIP)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodExtraHostPathMounts(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodExtraHostPathMounts),
+			Namespace: TestNamespace,
+		},
+		// The mount path used by the instance manager is skipped
+		Value: "/opt/devices:/host/opt/devices; /var/lib/custom:/host",
+	})
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	pod, err := f.imc.BuildInstanceManagerPodSpec(im)
+	c.Assert(err, IsNil)
+
+	var extraVolumeMounts []corev1.VolumeMount
+	for _, volumeMount := range pod.Spec.Containers[0].VolumeMounts {
+		if strings.HasPrefix(volumeMount.Name, "extra-host-path-") {
+			extraVolumeMounts = append(extraVolumeMounts, volumeMount)
+		}
+	}
+	c.Assert(extraVolumeMounts, HasLen, 1)
+	c.Assert(extraVolumeMounts[0].MountPath, Equals, "/host/opt/devices")
+
+	var extraVolumes []corev1.Volume
+	for _, volume := range pod.Spec.Volumes {
+		if strings.HasPrefix(volume.Name, "extra-host-path-") {
+			extraVolumes = append(extraVolumes, volume)
+		}
+	}
+	c.Assert(extraVolumes, HasLen, 1)
+	c.Assert(extraVolumes[0].Name, Equals, extraVolumeMounts[0].Name)
+	c.Assert(extraVolumes[0].HostPath, NotNil)
+	c.Assert(extraVolumes[0].HostPath.Path, Equals, "/opt/devices")
+}
+
+func (s *TestSuite) TestInstanceManagerPodHostMountPath(c *C) {
+	for name, tc := range map[string]struct {
+		setting           string
+		expectedHostPath  string
+		expectedMountPath string
+	}{
+		"root filesystem by default": {
+			expectedHostPath:  "/",
+			expectedMountPath: "/host",
+		},
+		"data directory": {
+			setting:           "/var/lib/longhorn/",
+			expectedHostPath:  "/var/lib/longhorn",
+			expectedMountPath: "/host/var/lib/longhorn",
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		if tc.setting != "" {
+			f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodHostMountPath), tc.setting))
+		}
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.BuildInstanceManagerPodSpec(im)
+		c.Assert(err, IsNil)
+
+		var hostVolume *corev1.Volume
+		for i := range pod.Spec.Volumes {
+			if pod.Spec.Volumes[i].Name == "host" {
+				hostVolume = &pod.Spec.Volumes[i]
+			}
+		}
+		c.Assert(hostVolume, NotNil)
+		c.Assert(hostVolume.HostPath, NotNil)
+		c.Assert(hostVolume.HostPath.Path, Equals, tc.expectedHostPath)
+
+		var hostVolumeMount *corev1.VolumeMount
+		for i := range pod.Spec.Containers[0].VolumeMounts {
+			if pod.Spec.Containers[0].VolumeMounts[i].Name == "host" {
+				hostVolumeMount = &pod.Spec.Containers[0].VolumeMounts[i]
+			}
+		}
+		c.Assert(hostVolumeMount, NotNil)
+		c.Assert(hostVolumeMount.MountPath, Equals, tc.expectedMountPath)
+
+		// The host /proc and /dev required by the engines are available whatever the host path is
+		hostPaths := map[string]string{}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.HostPath != nil {
+				hostPaths[volume.Name] = volume.HostPath.Path
+			}
+		}
+		for _, dir := range []string{"/proc", "/dev"} {
+			found := tc.expectedHostPath == "/"
+			for _, volumeMount := range pod.Spec.Containers[0].VolumeMounts {
+				if volumeMount.MountPath == filepath.Join("/host", dir) && hostPaths[volumeMount.Name] == dir {
+					found = true
+				}
+			}
+			c.Assert(found, Equals, true, Commentf("host %v is not mounted for %v", dir, name))
+		}
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodTolerations(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	// The storage node is dedicated by taints
+	taints := []corev1.Taint{
+		{Key: "storage", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "storage", Value: "true", Effect: corev1.TaintEffectNoExecute},
+	}
+	kubeNode, err := f.kubeClient.CoreV1().Nodes().Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	kubeNode.Spec.Taints = taints
+	kubeNode, err = f.kubeClient.CoreV1().Nodes().Update(context.TODO(), kubeNode, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.kubeNodeIndexer.Update(kubeNode), IsNil)
+
+	setting := newTolerationSetting()
+	setting.Value = "storage=true:NoSchedule; storage=true:NoExecute"
+	f.updateSetting(c, setting)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	err = f.imc.createInstanceManagerPod(im)
+	c.Assert(err, IsNil)
+	pod, err := f.kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.NodeName, Equals, TestNode1)
+	c.Assert(pod.Spec.Tolerations, HasLen, 2)
+
+	// The pod bypasses the scheduler by the node name, but the kubelet still rejects the pod not tolerating the taints.
+	for _, taint := range taints {
+		tolerated := false
+		for _, toleration := range pod.Spec.Tolerations {
+			if toleration.ToleratesTaint(&taint) {
+				tolerated = true
+				break
+			}
+		}
+		c.Assert(tolerated, Equals, true, Commentf("taint %v is not tolerated", taint))
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodPriorityClass(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	// The built-in priority class for system critical pods
+	priorityClass := &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "system-node-critical",
+		},
+		Value: 2000001000,
+	}
+	priorityClass, err := f.kubeClient.SchedulingV1().PriorityClasses().Create(context.TODO(), priorityClass, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.pcIndexer.Add(priorityClass), IsNil)
+
+	c.Assert(f.imc.ds.ValidateSetting(string(types.SettingNamePriorityClass), priorityClass.Name), IsNil)
+	c.Assert(f.imc.ds.ValidateSetting(string(types.SettingNamePriorityClass), "nonexistent-priority-class"), NotNil)
+
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNamePriorityClass),
+			Namespace: TestNamespace,
+		},
+		Value: priorityClass.Name,
+	})
+
+	for _, imType := range []longhorn.InstanceManagerType{longhorn.InstanceManagerTypeEngine, longhorn.InstanceManagerTypeReplica} {
+		im := newInstanceManager(TestInstanceManagerName+"-"+string(imType), longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		im.Spec.Type = imType
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+		c.Assert(err, IsNil)
+		c.Assert(pod.Spec.PriorityClassName, Equals, priorityClass.Name, Commentf("instance manager type %v", imType))
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodDNSConfig(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerPodDNSConfig),
+			Namespace: TestNamespace,
+		},
+		Value: "policy:None; nameservers:10.0.0.10; searches:corp.example.com; options:ndots=2",
+	})
+
+	for _, imType := range []longhorn.InstanceManagerType{longhorn.InstanceManagerTypeEngine, longhorn.InstanceManagerTypeReplica} {
+		im := newInstanceManager(TestInstanceManagerName+"-"+string(imType), longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		im.Spec.Type = imType
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+		c.Assert(err, IsNil)
+		comment := Commentf("instance manager type %v", imType)
+		c.Assert(pod.Spec.DNSPolicy, Equals, corev1.DNSNone, comment)
+		c.Assert(pod.Spec.DNSConfig, NotNil, comment)
+		c.Assert(pod.Spec.DNSConfig.Nameservers, DeepEquals, []string{"10.0.0.10"}, comment)
+		c.Assert(pod.Spec.DNSConfig.Searches, DeepEquals, []string{"corp.example.com"}, comment)
+		c.Assert(pod.Spec.DNSConfig.Options, HasLen, 1, comment)
+		c.Assert(pod.Spec.DNSConfig.Options[0].Name, Equals, "ndots", comment)
+		c.Assert(*pod.Spec.DNSConfig.Options[0].Value, Equals, "2", comment)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodImageNodeOverride(c *C) {
+	for name, tc := range map[string]struct {
+		arch          string
+		expectedImage string
+	}{
+		"matching override": {
+			arch:          "arm64",
+			expectedImage: "example.com/instance-manager:arm64",
+		},
+		"no matching override": {
+			arch:          "s390x",
+			expectedImage: TestInstanceManagerImage,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		kubeNode, err := f.kubeClient.CoreV1().Nodes().Get(context.TODO(), TestNode1, metav1.GetOptions{})
+		c.Assert(err, IsNil)
+		kubeNode.Labels = map[string]string{corev1.LabelArchStable: tc.arch}
+		c.Assert(f.kubeNodeIndexer.Update(kubeNode), IsNil)
+		f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerImageNodeOverrides),
+			"kubernetes.io/arch=amd64:example.com/instance-manager:amd64; kubernetes.io/arch=arm64:example.com/instance-manager:arm64"))
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+		f.syncInstanceManager(c, im.Name)
+
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		c.Assert(pods[0].Spec.Containers[0].Image, Equals, tc.expectedImage)
+		// The labels keep the instance manager image, and the pod is not considered outdated
+		c.Assert(pods[0].Labels, DeepEquals, types.GetInstanceManagerLabels(TestNode1, TestInstanceManagerImage,
+			longhorn.InstanceManagerTypeAllInOne, longhorn.DataEngineTypeV1))
+		isImageSynced, err := f.imc.isInstanceManagerPodImageSynced(im, &pods[0])
+		c.Assert(err, IsNil)
+		c.Assert(isImageSynced, Equals, true)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodExtraArgs(c *C) {
+	for name, tc := range map[string]struct {
+		extraArgs    string
+		dataEngine   longhorn.DataEngineType
+		expectedArgs []string
+		expectError  bool
+	}{
+		"no extra args": {
+			dataEngine:   longhorn.DataEngineTypeV1,
+			expectedArgs: []string{"instance-manager", "--debug", "daemon", "--listen", "0.0.0.0:8500"},
+		},
+		"v1 data engine extra args": {
+			extraArgs:    "--log-format json --verbose",
+			dataEngine:   longhorn.DataEngineTypeV1,
+			expectedArgs: []string{"instance-manager", "--debug", "daemon", "--listen", "0.0.0.0:8500", "--log-format", "json", "--verbose"},
+		},
+		"v2 data engine extra args": {
+			extraArgs:  "--verbose",
+			dataEngine: longhorn.DataEngineTypeV2,
+			expectedArgs: []string{"instance-manager", "--spdk-log", "all", "--enable-spdk", "--debug",
+				"daemon", "--spdk-enabled", "--listen", "0.0.0.0:8500", "--verbose"},
+		},
+		"conflicting listen override": {
+			extraArgs:   "--listen=0.0.0.0:9500",
+			dataEngine:  longhorn.DataEngineTypeV1,
+			expectError: true,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerExtraArgs), tc.extraArgs))
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, tc.dataEngine, false)
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, tc.dataEngine)
+		if tc.expectError {
+			c.Assert(err, NotNil)
+			continue
+		}
+		c.Assert(err, IsNil)
+		c.Assert(pod.Spec.Containers[0].Args, DeepEquals, tc.expectedArgs)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodGRPCTLSRequired(c *C) {
+	for name, tc := range map[string]struct {
+		tlsRequired            string
+		expectedSecretOptional bool
+	}{
+		"tls optional":  {tlsRequired: "false", expectedSecretOptional: true},
+		"tls required":  {tlsRequired: "true", expectedSecretOptional: false},
+		"default value": {expectedSecretOptional: true},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		if tc.tlsRequired != "" {
+			f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerGRPCTLSRequired), tc.tlsRequired))
+		}
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+		c.Assert(err, IsNil)
+		var secret *corev1.SecretVolumeSource
+		for _, volume := range pod.Spec.Volumes {
+			if volume.Secret != nil && volume.Secret.SecretName == types.TLSSecretName {
+				secret = volume.Secret
+			}
+		}
+		c.Assert(secret, NotNil)
+		c.Assert(*secret.Optional, Equals, tc.expectedSecretOptional)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodCapabilities(c *C) {
+	for name, tc := range map[string]struct {
+		capabilities         string
+		dataEngine           longhorn.DataEngineType
+		expectPrivileged     bool
+		expectedCapabilities []corev1.Capability
+	}{
+		"privileged by default": {
+			dataEngine:       longhorn.DataEngineTypeV1,
+			expectPrivileged: true,
+		},
+		"capabilities instead of privileged": {
+			capabilities:         "SYS_ADMIN,MKNOD",
+			dataEngine:           longhorn.DataEngineTypeV1,
+			expectPrivileged:     false,
+			expectedCapabilities: []corev1.Capability{"SYS_ADMIN", "MKNOD"},
+		},
+		"v2 data engine always privileged": {
+			capabilities:     "SYS_ADMIN,MKNOD",
+			dataEngine:       longhorn.DataEngineTypeV2,
+			expectPrivileged: true,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodCapabilities), tc.capabilities))
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, tc.dataEngine, false)
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.createGenericManagerPodSpec(im, nil, "", nil)
+		c.Assert(err, IsNil)
+		securityContext := pod.Spec.Containers[0].SecurityContext
+		c.Assert(securityContext, NotNil)
+		c.Assert(*securityContext.Privileged, Equals, tc.expectPrivileged)
+		if tc.expectedCapabilities == nil {
+			c.Assert(securityContext.Capabilities, IsNil)
+			continue
+		}
+		c.Assert(securityContext.Capabilities, NotNil)
+		c.Assert(securityContext.Capabilities.Add, DeepEquals, tc.expectedCapabilities)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodTopologySpreadConstraints(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// No constraint by default
+	pod, err := f.imc.createGenericManagerPodSpec(im, nil, "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.TopologySpreadConstraints, IsNil)
+
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodTopologySpreadConstraints),
+		"topology.kubernetes.io/zone:1:ScheduleAnyway; kubernetes.io/hostname:1:DoNotSchedule"))
+	pod, err = f.imc.createGenericManagerPodSpec(im, nil, "", nil)
+	c.Assert(err, IsNil)
+	labelSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			types.GetLonghornLabelComponentKey():                              types.LonghornLabelInstanceManager,
+			types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerType): string(im.Spec.Type),
+		},
+	}
+	c.Assert(pod.Spec.TopologySpreadConstraints, DeepEquals, []corev1.TopologySpreadConstraint{
+		{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: corev1.ScheduleAnyway, LabelSelector: labelSelector},
+		{TopologyKey: "kubernetes.io/hostname", MaxSkew: 1, WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: labelSelector},
+	})
+}
+
+func (s *TestSuite) TestInstanceManagerStartingTimeout(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	f.addSetting(c, &longhorn.Setting{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(types.SettingNameInstanceManagerStartingTimeout),
+			Namespace: TestNamespace,
+		},
+		Value: "600",
+	})
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodPending}, im))
+
+	// The time entering the starting state is recorded
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(im.Status.CurrentStateTransitionTime, Equals, fakeClock.Now().UTC().Format(time.RFC3339))
+	startingTime := im.Status.CurrentStateTransitionTime
+
+	// The instance manager keeps starting before timing out
+	fakeClock.Step(599 * time.Second)
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(im.Status.CurrentStateTransitionTime, Equals, startingTime)
+	c.Assert(im.Status.Message, Equals, "")
+
+	// The instance manager is marked as error once timed out
+	fakeClock.Step(2 * time.Second)
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(im.Status.CurrentStateTransitionTime, Equals, fakeClock.Now().UTC().Format(time.RFC3339))
+	c.Assert(im.Status.Message, Matches, ".*never became ready.*")
+
+	recorder := f.imc.eventRecorder.(*record.FakeRecorder)
+	c.Assert(recorder.Events, HasLen, 1)
+	event := <-recorder.Events
+	c.Assert(event, Matches, corev1.EventTypeWarning+" "+constant.EventReasonFailedStarting+" .*never became ready.*")
+}
+
+func (s *TestSuite) TestInstanceManagerPodIPChange(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	pod := newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im)
+	f.addPod(c, pod)
+	// The monitors keep retrying to establish the instance watch with the incompatible clients
+	f.imc.instanceManagerClientCache.newClient = func(im *longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error) {
+		return &engineapi.InstanceManagerClient{}, nil
+	}
+
+	getMonitor := func() *InstanceManagerMonitor {
+		f.imc.instanceManagerMonitorMutex.Lock()
+		defer f.imc.instanceManagerMonitorMutex.Unlock()
+		return f.imc.instanceManagerMonitors[TestInstanceManagerName]
+	}
+
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.IP, Equals, TestIP1)
+	staleMonitor := getMonitor()
+	c.Assert(staleMonitor != nil, Equals, true)
+	c.Assert(staleMonitor.ip, Equals, TestIP1)
+
+	// The pod is recreated with a new IP behind the back of the controller
+	pod.Status.PodIP = TestIP2
+	f.updatePod(c, pod)
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(im.Status.IP, Equals, TestIP2)
+
+	// The stale monitor is stopped and replaced by the one talking to the new IP
+	monitor := getMonitor()
+	c.Assert(monitor != nil, Equals, true)
+	c.Assert(monitor != staleMonitor, Equals, true)
+	c.Assert(monitor.ip, Equals, TestIP2)
+	select {
+	case <-staleMonitor.stopCh:
+	default:
+		c.Fatal("the stale monitor is not stopped")
+	}
+
+	f.imc.stopMonitoring(TestInstanceManagerName)
+}
+
+func (s *TestSuite) TestInstanceManagerPodImagePullBackOff(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	pod := newInstanceManagerTestPod(&corev1.PodStatus{
+		Phase: corev1.PodPending,
+		ContainerStatuses: []corev1.ContainerStatus{
+			{
+				Name: "instance-manager",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{
+						Reason:  "ImagePullBackOff",
+						Message: "Back-off pulling image \"" + TestInstanceManagerImage + "\"",
+					},
+				},
+			},
+		},
+	}, im)
+	f.addPod(c, pod)
+
+	// The waiting reason is surfaced while the instance manager keeps starting
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(im.Status.Message, Matches, "Instance manager pod .* is pending: container instance-manager is waiting with reason ImagePullBackOff: Back-off pulling image.*")
+
+	recorder := f.imc.eventRecorder.(*record.FakeRecorder)
+	c.Assert(recorder.Events, HasLen, 1)
+	event := <-recorder.Events
+	c.Assert(event, Matches, corev1.EventTypeWarning+" "+constant.EventReasonFailedStarting+" .*ImagePullBackOff.*")
+
+	// The event is not repeated for the same reason
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(recorder.Events, HasLen, 0)
+
+	// The message is cleared once the image is pulled and the container is being created
+	pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}
+	f.updatePod(c, pod)
+	im = f.syncInstanceManager(c, TestInstanceManagerName)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateStarting)
+	c.Assert(im.Status.Message, Equals, "")
+	c.Assert(recorder.Events, HasLen, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerPodFailed(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	pod := newInstanceManagerTestPod(&corev1.PodStatus{
+		Phase: corev1.PodFailed,
+		ContainerStatuses: []corev1.ContainerStatus{
+			{
+				Name: "instance-manager",
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:   "OOMKilled",
+						ExitCode: 137,
+					},
+				},
+			},
+		},
+	}, im)
+	f.addPod(c, pod)
+
+	err := f.imc.syncStatusWithPod(im)
+	c.Assert(err, IsNil)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(im.Status.Message, Matches, ".*instance-manager terminated with reason OOMKilled and exit code 137.*")
+
+	recorder := f.imc.eventRecorder.(*record.FakeRecorder)
+	c.Assert(recorder.Events, HasLen, 1)
+	event := <-recorder.Events
+	c.Assert(event, Matches, corev1.EventTypeWarning+" "+constant.EventReasonFailed+" .*instance-manager terminated with reason OOMKilled and exit code 137.*")
+
+	// The failure is recorded once
+	err = f.imc.syncStatusWithPod(im)
+	c.Assert(err, IsNil)
+	c.Assert(recorder.Events, HasLen, 0)
+}
+
+func (s *TestSuite) TestInstanceManagerPodEvicted(c *C) {
+	pod := newPod(&corev1.PodStatus{
+		Phase:   corev1.PodFailed,
+		Reason:  "Evicted",
+		Message: "The node was low on resource: memory.",
+	}, TestInstanceManagerName, TestNamespace, TestNode1)
+	c.Assert(getInstanceManagerPodFailureMessage(pod), Equals,
+		"Instance manager pod "+TestInstanceManagerName+" failed: pod failed with reason Evicted: The node was low on resource: memory.")
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorStop(c *C) {
+	monitor := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instance manager", TestInstanceManagerName),
+		Name:         TestInstanceManagerName,
+		lock:         &sync.RWMutex{},
+		clock:        clock.RealClock{},
+		watchBackoff: flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+	}
+
+	// The fake stream keeps notifying the monitor until it is stopped.
+	received := make(chan struct{}, 1)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		monitor.receiveNotifications(func() error {
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}()
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the monitor didn't receive any notification")
+	}
+	// The poll loop consumes the notifications concurrently.
+	monitor.shouldPoll(time.Now(), time.Hour)
+
+	monitor.StopMonitorWithLock()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the monitor didn't stop receiving notifications after being stopped")
+	}
+	c.Assert(monitor.CheckMonitorStoppedWithLock(), Equals, true)
+}
+
+func (s *TestSuite) TestInstanceManagerClientCache(c *C) {
+	created := 0
+	cache := newInstanceManagerClientCache(func(im *longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error) {
+		created++
+		return &engineapi.InstanceManagerClient{}, nil
+	})
+	hits := testutil.ToFloat64(instanceManagerClientCacheHits)
+	misses := testutil.ToFloat64(instanceManagerClientCacheMisses)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	client, err := cache.get(im)
+	c.Assert(err, IsNil)
+	c.Assert(created, Equals, 1)
+
+	// The client is reused as long as the instance manager IP doesn't change
+	cachedClient, err := cache.get(im)
+	c.Assert(err, IsNil)
+	c.Assert(cachedClient, Equals, client)
+	c.Assert(created, Equals, 1)
+
+	// The client is evicted once the instance manager IP changes
+	im.Status.IP = TestIP2
+	newClient, err := cache.get(im)
+	c.Assert(err, IsNil)
+	c.Assert(newClient, Not(Equals), client)
+	c.Assert(created, Equals, 2)
+
+	// The client is recreated after being invalidated
+	cache.invalidate(im.Name)
+	_, err = cache.get(im)
+	c.Assert(err, IsNil)
+	c.Assert(created, Equals, 3)
+
+	c.Assert(testutil.ToFloat64(instanceManagerClientCacheHits)-hits, Equals, float64(1))
+	c.Assert(testutil.ToFloat64(instanceManagerClientCacheMisses)-misses, Equals, float64(3))
+}
+
+func (s *TestSuite) TestInstanceManagerInstanceProcessTimestamps(c *C) {
+	newProcesses := func(state longhorn.InstanceState, resourceVersion int64) map[string]longhorn.InstanceProcess {
+		return map[string]longhorn.InstanceProcess{
+			"engine-1": {
+				Status: longhorn.InstanceProcessStatus{
+					Type:            longhorn.InstanceTypeEngine,
+					State:           state,
+					ResourceVersion: resourceVersion,
+				},
+			},
+		}
+	}
+
+	// The start time is set once the instance becomes running
+	processes := newProcesses(longhorn.InstanceStateStarting, 1)
+	setInstanceProcessTimestamps(nil, processes, "t1")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "")
+
+	existing := processes
+	processes = newProcesses(longhorn.InstanceStateRunning, 2)
+	setInstanceProcessTimestamps(existing, processes, "t2")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "t2")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "")
+
+	// The start time is kept while the instance keeps running
+	existing = processes
+	processes = newProcesses(longhorn.InstanceStateRunning, 3)
+	setInstanceProcessTimestamps(existing, processes, "t3")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "t2")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "")
+
+	// The stop time is set once the instance becomes stopped
+	existing = processes
+	processes = newProcesses(longhorn.InstanceStateStopped, 4)
+	setInstanceProcessTimestamps(existing, processes, "t4")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "t2")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "t4")
+
+	// An expired update doesn't clobber the timestamps
+	existing = processes
+	processes = newProcesses(longhorn.InstanceStateRunning, 3)
+	setInstanceProcessTimestamps(existing, processes, "t5")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "t2")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "t4")
+
+	// The restarted process reports the reset resource version, which is not considered expired
+	stopped := processes["engine-1"]
+	stopped.Status.State = longhorn.InstanceStateStopped
+	stopped.Status.ResourceVersion = 10
+	existing = map[string]longhorn.InstanceProcess{"engine-1": stopped}
+	processes = newProcesses(longhorn.InstanceStateRunning, 2)
+	setInstanceProcessTimestamps(existing, processes, "t6")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "t6")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "t4")
+
+	// The following updates of the restarted process are compared with the reset version
+	existing = processes
+	processes = newProcesses(longhorn.InstanceStateStopped, 3)
+	setInstanceProcessTimestamps(existing, processes, "t7")
+	c.Assert(processes["engine-1"].Status.StartedAt, Equals, "t6")
+	c.Assert(processes["engine-1"].Status.StoppedAt, Equals, "t7")
+
+	// The restart count is carried over and increments every time the process is restarted with the same name
+	processes = newProcesses(longhorn.InstanceStateRunning, 2)
+	setInstanceProcessRestartCounts(nil, processes)
+	c.Assert(processes["engine-1"].Status.RestartCount, Equals, int32(0))
+	for i, resourceVersion := range []int64{5, 1, 5, 2, 3} {
+		existing = processes
+		state := longhorn.InstanceStateRunning
+		if i%2 == 0 {
+			state = longhorn.InstanceStateStopped
+		}
+		processes = newProcesses(state, resourceVersion)
+		setInstanceProcessRestartCounts(existing, processes)
+	}
+	c.Assert(processes["engine-1"].Status.RestartCount, Equals, int32(2))
+
+	// The monitor doesn't update the instance manager again if nothing but the poll time changes
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.Status.APIVersion = engineapi.CurrentInstanceManagerAPIVersion
+	m := &InstanceManagerMonitor{logger: logrus.StandardLogger().WithField("instanceManager", im.Name)}
+	c.Assert(m.updateInstanceMap(im, newProcesses(longhorn.InstanceStateRunning, 0)), Equals, true)
+	startedAt := im.Status.InstanceEngines["engine-1"].Status.StartedAt
+	c.Assert(startedAt, Not(Equals), "")
+	c.Assert(m.updateInstanceMap(im, newProcesses(longhorn.InstanceStateRunning, 0)), Equals, false)
+	c.Assert(im.Status.InstanceEngines["engine-1"].Status.StartedAt, Equals, startedAt)
+}
+
+func (s *TestSuite) TestInstanceManagerReconcileMonitors(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+
+	unmonitoredIM := newInstanceManager("instance-manager-unmonitored", longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, unmonitoredIM)
+	monitoredIM := newInstanceManager("instance-manager-monitored", longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, monitoredIM)
+	otherOwnerIM := newInstanceManager("instance-manager-other-owner", longhorn.InstanceManagerStateRunning, TestNode2, TestNode2, TestIP2,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, otherOwnerIM)
+
+	stopChs := map[string]chan struct{}{
+		monitoredIM.Name:         make(chan struct{}, 1),
+		otherOwnerIM.Name:        make(chan struct{}, 1),
+		"instance-manager-stale": make(chan struct{}, 1),
+	}
+	for imName, stopCh := range stopChs {
+		f.imc.instanceManagerMonitorMap[imName] = stopCh
+	}
+
+	f.imc.reconcileMonitors()
+
+	isStopped := func(stopCh chan struct{}) bool {
+		select {
+		case <-stopCh:
+			return true
+		default:
+			return false
+		}
+	}
+	c.Assert(isStopped(stopChs[monitoredIM.Name]), Equals, false)
+	c.Assert(isStopped(stopChs[otherOwnerIM.Name]), Equals, true)
+	c.Assert(isStopped(stopChs["instance-manager-stale"]), Equals, true)
+
+	// Only the running instance manager without a monitor is enqueued to restart monitoring
+	c.Assert(f.imc.queue.Len(), Equals, 1)
+	key, _ := f.imc.queue.Get()
+	c.Assert(key, Equals, TestNamespace+"/"+unmonitoredIM.Name)
+	f.imc.queue.Done(key)
+}
+
+func (s *TestSuite) TestInstanceManagerControllerShutdownStopsMonitors(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	// The informers are not started in the unit test
+	f.imc.cacheSyncs = nil
+
+	stopChs := map[string]chan struct{}{
+		"instance-manager-1": make(chan struct{}, 1),
+		"instance-manager-2": make(chan struct{}, 1),
+	}
+	for imName, stopCh := range stopChs {
+		f.imc.instanceManagerMonitorMap[imName] = stopCh
+	}
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		f.imc.Run(1, stopCh)
+		close(done)
+	}()
+	close(stopCh)
+	<-done
+
+	for imName, monitorStopCh := range stopChs {
+		select {
+		case <-monitorStopCh:
+		default:
+			c.Fatalf("monitor of %v is not stopped after the controller shut down", imName)
+		}
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodImageUpdate(c *C) {
+	outdatedImage := "longhornio/longhorn-instance-manager:outdated"
+
+	for name, tc := range map[string]struct {
+		instanceEngines map[string]longhorn.InstanceProcess
+		expectedImage   string
+	}{
+		"recreate the pod running the outdated image": {
+			expectedImage: TestInstanceManagerImage,
+		},
+		"keep the pod running the outdated image until the instances are stopped": {
+			instanceEngines: map[string]longhorn.InstanceProcess{
+				TestEngineName: {
+					Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName},
+					Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning},
+				},
+			},
+			expectedImage: outdatedImage,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			tc.instanceEngines, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+		// The image is updated in place after the pod creation
+		pod := newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im)
+		pod.OwnerReferences = datastore.GetOwnerReferencesForInstanceManager(im)
+		pod.Spec.Containers[0].Image = outdatedImage
+		f.addPod(c, pod)
+
+		f.syncInstanceManager(c, im.Name)
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		c.Assert(pods[0].Spec.Containers[0].Image, Equals, tc.expectedImage)
+		f.imc.stopMonitoring(im.Name)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerLastPollTimestamp(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	pollErr := fmt.Errorf("failed to list instances")
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:         im.Name,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        fakeClock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return map[string]longhorn.InstanceProcess{}, pollErr
+		},
+	}
+
+	// The failed poll doesn't set the timestamp
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.LastPollTimestamp, Equals, "")
+
+	pollErr = nil
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	im = f.getInstanceManager(c, im.Name)
+	firstPollTimestamp := fakeClock.Now().UTC().Format(time.RFC3339)
+	c.Assert(im.Status.LastPollTimestamp, Equals, firstPollTimestamp)
+	c.Assert(f.imIndexer.Update(im), IsNil)
+
+	// The failed poll keeps the timestamp of the last successful poll
+	fakeClock.Step(time.Minute)
+	pollErr = fmt.Errorf("failed to list instances")
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.LastPollTimestamp, Equals, firstPollTimestamp)
+
+	// The successful poll advances the timestamp even if the instance map is unchanged
+	pollErr = nil
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.LastPollTimestamp, Equals, fakeClock.Now().UTC().Format(time.RFC3339))
+	c.Assert(im.Status.LastPollTimestamp, Not(Equals), firstPollTimestamp)
+}
+
+func (s *TestSuite) TestIsInstanceManagerPod(c *C) {
+	newTestPod := func(labels map[string]string, containerName string) *corev1.Pod {
+		pod := newPod(&corev1.PodStatus{Phase: corev1.PodRunning}, TestInstanceManagerName, TestNamespace, TestNode1)
+		pod.Labels = labels
+		pod.Spec.Containers = []corev1.Container{{Name: containerName}}
+		return pod
+	}
+
+	for name, tc := range map[string]struct {
+		obj      interface{}
+		expected bool
+	}{
+		"pod matched by the component label": {
+			obj:      newTestPod(types.GetInstanceManagerComponentLabel(), "renamed-manager"),
+			expected: true,
+		},
+		"pod matched by the legacy engine manager container": {
+			obj:      newTestPod(nil, "engine-manager"),
+			expected: true,
+		},
+		"pod matched by the legacy replica manager container": {
+			obj:      newTestPod(nil, "replica-manager"),
+			expected: true,
+		},
+		"deleted pod matched by the component label": {
+			obj: cache.DeletedFinalStateUnknown{
+				Key: TestNamespace + "/" + TestInstanceManagerName,
+				Obj: newTestPod(types.GetInstanceManagerComponentLabel(), "renamed-manager"),
+			},
+			expected: true,
+		},
+		"unrelated pod": {
+			obj:      newTestPod(map[string]string{types.GetLonghornLabelComponentKey(): "share-manager"}, "share-manager"),
+			expected: false,
+		},
+		"unrelated object": {
+			obj:      newInstanceManager(TestInstanceManagerName, "", TestNode1, TestNode1, "", nil, nil, longhorn.DataEngineTypeV1, false),
+			expected: false,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+		c.Assert(isInstanceManagerPod(tc.obj), Equals, tc.expected, Commentf("test case: %v", name))
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodEviction(c *C) {
+	for name, tc := range map[string]struct {
+		imType               longhorn.InstanceManagerType
+		instanceReplicas     map[string]longhorn.InstanceProcess
+		otherNode            bool
+		expectedEvictedEvent bool
+	}{
+		"recommend rescheduling the replicas to the other node": {
+			imType: longhorn.InstanceManagerTypeAllInOne,
+			instanceReplicas: map[string]longhorn.InstanceProcess{
+				TestReplicaName: {
+					Spec:   longhorn.InstanceProcessSpec{Name: TestReplicaName},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeReplica, State: longhorn.InstanceStateRunning},
+				},
+			},
+			otherNode:            true,
+			expectedEvictedEvent: true,
+		},
+		"no other node to reschedule the replicas to": {
+			imType: longhorn.InstanceManagerTypeAllInOne,
+			instanceReplicas: map[string]longhorn.InstanceProcess{
+				TestReplicaName: {
+					Spec:   longhorn.InstanceProcessSpec{Name: TestReplicaName},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeReplica, State: longhorn.InstanceStateRunning},
+				},
+			},
+		},
+		"no replica to reschedule": {
+			imType:    longhorn.InstanceManagerTypeAllInOne,
+			otherNode: true,
+		},
+		"engine instance manager": {
+			imType:    longhorn.InstanceManagerTypeEngine,
+			otherNode: true,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		if tc.otherNode {
+			f.addNode(c, TestNode2)
+		}
+		fakeClock := testingclock.NewFakeClock(time.Now())
+		f.imc.clock = fakeClock
+		events := f.imc.eventRecorder.(*record.FakeRecorder).Events
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			nil, tc.instanceReplicas, longhorn.DataEngineTypeV1, false)
+		im.Spec.Type = tc.imType
+		f.addInstanceManager(c, im)
+		pod := newInstanceManagerTestPod(&corev1.PodStatus{
+			Phase:   corev1.PodFailed,
+			Reason:  instanceManagerPodEvictedReason,
+			Message: "The node was low on resource: memory.",
+		}, im)
+		pod.OwnerReferences = datastore.GetOwnerReferencesForInstanceManager(im)
+		f.addPod(c, pod)
+
+		// The evicted pod is not recreated on the pressured node right away
+		im = f.syncInstanceManager(c, im.Name)
+		c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+		c.Assert(im.Status.Message, Matches, ".*Evicted.*")
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		c.Assert(pods[0].Status.Phase, Equals, corev1.PodFailed)
+
+		evictedEvent, throttlingEvent := false, false
+		for len(events) > 0 {
+			event := <-events
+			if strings.HasPrefix(event, corev1.EventTypeWarning+" "+constant.EventReasonEvicted+" ") {
+				evictedEvent = true
+				c.Assert(event, Matches, ".*"+TestReplicaName+".*")
+			}
+			if strings.Contains(event, "Delaying the pod recreation") {
+				throttlingEvent = true
+			}
+		}
+		c.Assert(evictedEvent, Equals, tc.expectedEvictedEvent)
+		c.Assert(throttlingEvent, Equals, true)
+
+		// The pod is recreated after the backoff
+		fakeClock.Step(instanceManagerPodRecreationBackoffInitialDuration)
+		f.syncInstanceManager(c, im.Name)
+		pods = f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		c.Assert(pods[0].Status.Phase, Not(Equals), corev1.PodFailed)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerProcessListHandler(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+
+	instanceEngines := map[string]longhorn.InstanceProcess{
+		TestEngineName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, PortStart: TestPort1},
+		},
+	}
+	instanceReplicas := map[string]longhorn.InstanceProcess{
+		TestReplicaName: {
+			Spec:   longhorn.InstanceProcessSpec{Name: TestReplicaName},
+			Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning},
+		},
+	}
+	f.addInstanceManager(c, newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1,
+		TestNode1, TestIP1, instanceEngines, instanceReplicas, longhorn.DataEngineTypeV1, false))
+	// The instance managers owned by other controllers are excluded
+	f.addInstanceManager(c, newInstanceManager("instance-manager-2", longhorn.InstanceManagerStateRunning, TestNode2,
+		TestNode2, TestIP2, instanceEngines, nil, longhorn.DataEngineTypeV1, false))
+
+	recorder := httptest.NewRecorder()
+	f.imc.InstanceManagerProcessListHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/instancemanagerprocesses", nil))
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), Equals, "application/json")
+
+	processLists := map[string]InstanceManagerProcessList{}
+	c.Assert(json.Unmarshal(recorder.Body.Bytes(), &processLists), IsNil)
+	c.Assert(processLists, HasLen, 1)
+	processList := processLists[TestInstanceManagerName]
+	c.Assert(processList.NodeID, Equals, TestNode1)
+	c.Assert(processList.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+	c.Assert(processList.InstanceEngines, DeepEquals, instanceEngines)
+	c.Assert(processList.InstanceReplicas, DeepEquals, instanceReplicas)
+}
+
+func (s *TestSuite) TestInstanceManagerWatchHealth(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", TestInstanceManagerName),
+		Name:         TestInstanceManagerName,
+		lock:         &sync.RWMutex{},
+		clock:        fakeClock,
+		watchBackoff: flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+	}
+	f.imc.instanceManagerMonitors[m.Name] = m
+
+	getWatchHealth := func() (int, InstanceManagerWatchStatus) {
+		recorder := httptest.NewRecorder()
+		f.imc.InstanceManagerWatchHealthHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/healthz/instancemanagerwatches", nil))
+		statuses := map[string]InstanceManagerWatchStatus{}
+		c.Assert(json.Unmarshal(recorder.Body.Bytes(), &statuses), IsNil)
+		c.Assert(statuses, HasLen, 1)
+		return recorder.Code, statuses[m.Name]
+	}
+
+	// An item is received, then the watch stream is stopped with the monitor
+	received := false
+	m.receiveNotifications(func() error {
+		if received {
+			m.StopMonitorWithLock()
+			return nil
+		}
+		received = true
+		return nil
+	})
+	code, status := getWatchHealth()
+	c.Assert(code, Equals, http.StatusOK)
+	c.Assert(status.Connected, Equals, true)
+	c.Assert(status.Stale, Equals, false)
+	c.Assert(status.LastRecvTime, Equals, fakeClock.Now().UTC().Format(time.RFC3339))
+
+	// The idle watch is healthy as long as it is connected
+	fakeClock.Step(time.Hour)
+	code, status = getWatchHealth()
+	c.Assert(code, Equals, http.StatusOK)
+	c.Assert(status.Connected, Equals, true)
+	c.Assert(status.Stale, Equals, false)
+	c.Assert(status.SinceLastRecv, Equals, time.Hour.String())
+
+	// The disconnected watch is stale regardless of the last received item
+	m.lock.Lock()
+	m.done = false
+	m.lock.Unlock()
+	m.receiveNotifications(func() error {
+		m.StopMonitorWithLock()
+		return fmt.Errorf("failed to receive")
+	})
+	code, status = getWatchHealth()
+	c.Assert(code, Equals, http.StatusServiceUnavailable)
+	c.Assert(status.Connected, Equals, false)
+	c.Assert(status.Stale, Equals, true)
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorWatchEOF(c *C) {
+	for name, tc := range map[string]struct {
+		state          longhorn.InstanceManagerState
+		ownerID        string
+		noIM           bool
+		expectRecvTime int
+	}{
+		"running instance manager": {
+			state:          longhorn.InstanceManagerStateRunning,
+			ownerID:        TestNode1,
+			expectRecvTime: 2,
+		},
+		"stopping instance manager": {
+			state:          longhorn.InstanceManagerStateStopped,
+			ownerID:        TestNode1,
+			expectRecvTime: 1,
+		},
+		"instance manager owned by another node": {
+			state:          longhorn.InstanceManagerStateRunning,
+			ownerID:        TestNode2,
+			expectRecvTime: 1,
+		},
+		"deleted instance manager": {
+			noIM:           true,
+			expectRecvTime: 1,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		if !tc.noIM {
+			im := newInstanceManager(TestInstanceManagerName, tc.state, tc.ownerID, TestNode1, TestIP1,
+				nil, nil, longhorn.DataEngineTypeV1, false)
+			f.addInstanceManager(c, im)
+		}
+
+		m := &InstanceManagerMonitor{
+			logger:       logrus.StandardLogger().WithField("instanceManager", TestInstanceManagerName),
+			Name:         TestInstanceManagerName,
+			controllerID: TestNode1,
+			ds:           f.imc.ds,
+			lock:         &sync.RWMutex{},
+			clock:        clock.RealClock{},
+			watchBackoff: flowcontrol.NewBackOff(time.Millisecond, 8*time.Millisecond),
+		}
+
+		// The stream is gracefully closed by the instance manager. The monitor keeps watching only if the
+		// instance manager is still supposed to be running.
+		recvTime := 0
+		m.receiveNotifications(func() error {
+			recvTime++
+			if recvTime > 1 {
+				m.StopMonitorWithLock()
+			}
+			return io.EOF
+		})
+		c.Assert(recvTime, Equals, tc.expectRecvTime)
+		c.Assert(m.CheckMonitorStoppedWithLock(), Equals, true)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPodTerminationGracePeriod(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	pod, err := f.imc.createGenericManagerPodSpec(im, nil, "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.TerminationGracePeriodSeconds, NotNil)
+	c.Assert(*pod.Spec.TerminationGracePeriodSeconds, Equals, int64(30))
+
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodTerminationGracePeriod), "120"))
+	pod, err = f.imc.createGenericManagerPodSpec(im, nil, "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.TerminationGracePeriodSeconds, NotNil)
+	c.Assert(*pod.Spec.TerminationGracePeriodSeconds, Equals, int64(120))
+}
+
+func (s *TestSuite) TestInstanceManagerPodForceDeletionOnError(c *C) {
+	for name, tc := range map[string]struct {
+		forceDeletion       string
+		expectForceDeletion bool
+	}{
+		"graceful deletion by default": {
+			forceDeletion: "false",
+		},
+		"force deletion on error": {
+			forceDeletion:       "true",
+			expectForceDeletion: true,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+		f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerPodForceDeletionOnError), tc.forceDeletion))
+
+		var deleteOptions []metav1.DeleteOptions
+		f.kubeClient.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			deleteOptions = append(deleteOptions, action.(k8stesting.DeleteActionImpl).DeleteOptions)
+			return false, nil, nil
+		})
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateError, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+		f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodFailed}, im))
+
+		im = f.syncInstanceManager(c, im.Name)
+		c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+		c.Assert(deleteOptions, HasLen, 1)
+		if !tc.expectForceDeletion {
+			c.Assert(deleteOptions[0].GracePeriodSeconds, IsNil)
+			continue
+		}
+		c.Assert(deleteOptions[0].GracePeriodSeconds, NotNil)
+		c.Assert(*deleteOptions[0].GracePeriodSeconds, Equals, int64(0))
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerFailedPodRetention(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addSetting(c, newSetting(string(types.SettingNameInstanceManagerFailedPodRetentionPeriod), "600"))
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateError, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodFailed}, im))
+
+	refreshPod := func() *corev1.Pod {
+		pod, err := f.kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(f.pIndexer.Update(pod), IsNil)
+		return pod
+	}
+
+	// The failed pod is retained and labeled rather than deleted
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	pod := refreshPod()
+	c.Assert(pod.DeletionTimestamp, IsNil)
+	c.Assert(pod.Labels[types.GetLonghornLabelKey(types.LonghornLabelPostMortemRetained)], Equals, "true")
+	c.Assert(pod.Annotations[types.GetLonghornLabelKey(types.InstanceManagerPodRetainedAtAnnotationKeySuffix)], Equals,
+		fakeClock.Now().UTC().Format(time.RFC3339))
+
+	// The pod is kept within the retention period
+	fakeClock.Step(5 * time.Minute)
+	im = f.syncInstanceManager(c, im.Name)
+	refreshPod()
+	c.Assert(f.listPods(c), HasLen, 1)
+
+	// The pod is deleted and recreated once the retention period passes
+	fakeClock.Step(6 * time.Minute)
+	im = f.syncInstanceManager(c, im.Name)
+	pods := f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	c.Assert(pods[0].Status.Phase, Not(Equals), corev1.PodFailed)
+	c.Assert(pods[0].Labels[types.GetLonghornLabelKey(types.LonghornLabelPostMortemRetained)], Equals, "")
+}
+
+func (s *TestSuite) TestInstanceManagerLeftoverPod(c *C) {
+	for name, tc := range map[string]struct {
+		phase         corev1.PodPhase
+		expectAdopted bool
+	}{
+		"healthy leftover pod": {
+			phase:         corev1.PodRunning,
+			expectAdopted: true,
+		},
+		"unhealthy leftover pod": {
+			phase:         corev1.PodFailed,
+			expectAdopted: false,
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addNode(c, TestNode1)
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		f.addInstanceManager(c, im)
+
+		// The pod left over from a prior crash is not in the informer cache yet
+		leftoverPod := newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: tc.phase}, im)
+		leftoverPod.UID = uuid.NewUUID()
+		_, err := f.kubeClient.CoreV1().Pods(TestNamespace).Create(context.TODO(), leftoverPod, metav1.CreateOptions{})
+		c.Assert(err, IsNil)
+
+		f.syncInstanceManager(c, im.Name)
+		pods := f.listPods(c)
+		c.Assert(pods, HasLen, 1)
+		if tc.expectAdopted {
+			c.Assert(pods[0].UID, Equals, leftoverPod.UID)
+			c.Assert(pods[0].DeletionTimestamp, IsNil)
+
+			// The instance manager transitions toward running once the cache catches up with the pod
+			c.Assert(f.pIndexer.Add(&pods[0]), IsNil)
+			im = f.syncInstanceManager(c, im.Name)
+			c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateRunning)
+			continue
+		}
+		c.Assert(pods[0].UID, Not(Equals), leftoverPod.UID)
+		c.Assert(pods[0].Status.Phase, Not(Equals), corev1.PodFailed)
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerControllerWorkers(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	defer f.imc.queue.ShutDown()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setting := newSetting(string(types.SettingNameInstanceManagerControllerWorkers), "3")
+	f.addSetting(c, setting)
+	setWorkers := func(value string) {
+		setting.Value = value
+		c.Assert(f.sIndexer.Update(setting), IsNil)
+	}
+
+	// The configured worker count is honored rather than the default one
+	f.imc.syncWorkerCount(ctx, 2)
+	c.Assert(f.imc.getWorkerCount(), Equals, 3)
+
+	// The workers are scaled live
+	setWorkers("5")
+	f.imc.syncWorkerCount(ctx, 2)
+	c.Assert(f.imc.getWorkerCount(), Equals, 5)
+	setWorkers("1")
+	f.imc.syncWorkerCount(ctx, 2)
+	c.Assert(f.imc.getWorkerCount(), Equals, 1)
+
+	// The default worker count is used if the setting is 0
+	setWorkers("0")
+	f.imc.syncWorkerCount(ctx, 2)
+	c.Assert(f.imc.getWorkerCount(), Equals, 2)
+}
+
+func (s *TestSuite) TestInstanceManagerPaused(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateError, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.Annotations = map[string]string{types.GetLonghornLabelKey(types.InstanceManagerPausedAnnotationKeySuffix): "true"}
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodFailed}, im))
+
+	// The failed pod is neither deleted nor recreated, and the state is untouched
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	pods := f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	c.Assert(pods[0].DeletionTimestamp, IsNil)
+	c.Assert(pods[0].Status.Phase, Equals, corev1.PodFailed)
+
+	recorder := f.imc.eventRecorder.(*record.FakeRecorder)
+	c.Assert(recorder.Events, HasLen, 1)
+	event := <-recorder.Events
+	c.Assert(event, Matches, corev1.EventTypeNormal+" "+constant.EventReasonPaused+" .*")
+
+	// The pause is reported once rather than on every sync
+	f.syncInstanceManager(c, im.Name)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(recorder.Events, HasLen, 0)
+
+	setPaused := func(paused bool) {
+		if paused {
+			if im.Annotations == nil {
+				im.Annotations = map[string]string{}
+			}
+			im.Annotations[types.GetLonghornLabelKey(types.InstanceManagerPausedAnnotationKeySuffix)] = "true"
+		} else {
+			delete(im.Annotations, types.GetLonghornLabelKey(types.InstanceManagerPausedAnnotationKeySuffix))
+		}
+		var err error
+		im, err = f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Update(context.TODO(), im, metav1.UpdateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(f.imIndexer.Update(im), IsNil)
+	}
+
+	// The reconciliation resumes once the annotation is removed
+	setPaused(false)
+	im = f.syncInstanceManager(c, im.Name)
+	pods = f.listPods(c)
+	c.Assert(pods, HasLen, 1)
+	c.Assert(pods[0].Status.Phase, Not(Equals), corev1.PodFailed)
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+
+	// The pause is reported again when the instance manager is paused again
+	setPaused(true)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(recorder.Events, HasLen, 1)
+	event = <-recorder.Events
+	c.Assert(event, Matches, corev1.EventTypeNormal+" "+constant.EventReasonPaused+" .*")
+}
+
+func (s *TestSuite) TestInstanceManagerOwnershipHysteresis(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addNode(c, TestNode2)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	// The controller on the other node races for the instance manager
+	peer := newTestInstanceManagerController(f.lhClient, f.kubeClient, f.extensionsClient, f.informerFactories, TestNode2)
+	peer.clock = fakeClock
+	controllers := map[string]*InstanceManagerController{TestNode1: f.imc, TestNode2: peer}
+
+	setNodeReadyReason := func(reason string) {
+		node, err := f.lhClient.LonghornV1beta2().Nodes(TestNamespace).Get(context.TODO(), TestNode1, metav1.GetOptions{})
+		c.Assert(err, IsNil)
+		node.Status.Conditions = []longhorn.Condition{
+			newNodeCondition(longhorn.NodeConditionTypeReady, longhorn.ConditionStatusFalse, reason),
+		}
+		node, err = f.lhClient.LonghornV1beta2().Nodes(TestNamespace).UpdateStatus(context.TODO(), node, metav1.UpdateOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(f.lhNodeIndexer.Update(node), IsNil)
+	}
+	syncBy := func(controllerID string) string {
+		err := controllers[controllerID].syncInstanceManager(TestNamespace + "/" + TestInstanceManagerName)
+		c.Assert(err, IsNil)
+		im := f.getInstanceManager(c, TestInstanceManagerName)
+		c.Assert(f.imIndexer.Update(im), IsNil)
+		return im.Status.OwnerID
+	}
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+	c.Assert(syncBy(TestNode1), Equals, TestNode1)
+
+	// The manager pod of the preferred node is missing transiently during the rolling update, so both controllers
+	// consider themselves responsible. The ownership stays with the peer rather than flapping.
+	setNodeReadyReason(string(longhorn.NodeConditionReasonManagerPodMissing))
+	c.Assert(syncBy(TestNode2), Equals, TestNode2)
+	for i := 0; i < 5; i++ {
+		c.Assert(syncBy(TestNode1), Equals, TestNode2)
+		c.Assert(syncBy(TestNode2), Equals, TestNode2)
+		fakeClock.Step(instanceManagerOwnershipHysteresis / 10)
+	}
+
+	// The preferred owner takes the ownership back once the hysteresis passes, and keeps it for a while
+	fakeClock.Step(instanceManagerOwnershipHysteresis)
+	c.Assert(syncBy(TestNode1), Equals, TestNode1)
+	c.Assert(syncBy(TestNode2), Equals, TestNode1)
+	c.Assert(syncBy(TestNode1), Equals, TestNode1)
+
+	// The ownership is taken right away once the owner node is definitively down
+	setNodeReadyReason(string(longhorn.NodeConditionReasonKubernetesNodeNotReady))
+	c.Assert(syncBy(TestNode2), Equals, TestNode2)
+
+	f.imc.stopMonitoring(TestInstanceManagerName)
+	peer.stopMonitoring(TestInstanceManagerName)
+}
+
+func (s *TestSuite) TestInstanceManagerOwnerPodName(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addNode(c, TestNode2)
+	peer := newTestInstanceManagerController(f.lhClient, f.kubeClient, f.extensionsClient, f.informerFactories, TestNode2)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, "", TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: TestIP1, Phase: corev1.PodRunning}, im))
+
+	// The pod name is recorded when the ownership is acquired
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.OwnerID, Equals, TestNode1)
+	c.Assert(im.Status.OwnerPodName, Equals, "longhorn-manager-"+TestNode1)
+	f.imc.stopMonitoring(im.Name)
+
+	// The pod name is refreshed after the manager pod on the owner node is replaced
+	f.imc.podName = "longhorn-manager-replaced"
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.OwnerID, Equals, TestNode1)
+	c.Assert(im.Status.OwnerPodName, Equals, "longhorn-manager-replaced")
+	f.imc.stopMonitoring(im.Name)
+
+	// The pod name is updated on the ownership transfer once the owner node is down
+	node, err := f.lhClient.LonghornV1beta2().Nodes(TestNamespace).Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	node.Status.Conditions = []longhorn.Condition{
+		newNodeCondition(longhorn.NodeConditionTypeReady, longhorn.ConditionStatusFalse, string(longhorn.NodeConditionReasonKubernetesNodeGone)),
+	}
+	node, err = f.lhClient.LonghornV1beta2().Nodes(TestNamespace).UpdateStatus(context.TODO(), node, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.lhNodeIndexer.Update(node), IsNil)
+
+	c.Assert(peer.syncInstanceManager(TestNamespace+"/"+im.Name), IsNil)
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.OwnerID, Equals, TestNode2)
+	c.Assert(im.Status.OwnerPodName, Equals, "longhorn-manager-"+TestNode2)
+	peer.stopMonitoring(im.Name)
+}
+
+func (s *TestSuite) TestInstanceManagerEmptyNodeID(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateStopped, "", "", "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.OwnerID, Equals, TestNode1)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(im.Status.Message, Equals, "Instance manager spec.nodeID is empty, cannot create the instance manager pod")
+	ready := types.GetCondition(im.Status.Conditions, longhorn.InstanceManagerConditionTypeReady)
+	c.Assert(ready.Status, Equals, longhorn.ConditionStatusFalse)
+	c.Assert(strings.Contains(ready.Message, im.Status.Message), Equals, true)
+	c.Assert(f.listPods(c), HasLen, 0)
+
+	// The instance manager stays in error state without creating the pod
+	im = f.syncInstanceManager(c, im.Name)
+	c.Assert(im.Status.CurrentState, Equals, longhorn.InstanceManagerStateError)
+	c.Assert(f.listPods(c), HasLen, 0)
+}
+
+func (s *TestSuite) TestCleanupInstanceManagersForNode(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	f.addNode(c, TestNode2)
+
+	ims := []*longhorn.InstanceManager{
+		newInstanceManager("instance-manager-node1-v1", longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV1, false),
+		newInstanceManager("instance-manager-node1-v2", longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV2, false),
+		newInstanceManager("instance-manager-node1-deleting", longhorn.InstanceManagerStateError, TestNode1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV1, true),
+		newInstanceManager("instance-manager-node2-v1", longhorn.InstanceManagerStateRunning, TestNode2, TestNode2, TestIP2,
+			nil, nil, longhorn.DataEngineTypeV1, false),
+	}
+	for _, im := range ims {
+		im.Finalizers = []string{metav1.FinalizerDeleteDependents}
+		im.Status.InstanceEngines = map[string]longhorn.InstanceProcess{
+			TestEngineName: {
+				Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName, DataEngine: im.Spec.DataEngine},
+				Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateRunning, Type: longhorn.InstanceTypeEngine},
+			},
+		}
+		f.addInstanceManager(c, im)
+		f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{PodIP: im.Status.IP, Phase: corev1.PodRunning}, im))
+	}
+
+	// The deletions go through the webhook, which rejects the instance managers with the running instances unless
+	// they are annotated with the force deletion
+	validator := instancemanager.NewValidator(f.imc.ds)
+	f.lhClient.PrependReactor("delete", "instancemanagers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj, err := f.lhClient.Tracker().Get(longhorn.SchemeGroupVersion.WithResource("instancemanagers"), TestNamespace, action.(k8stesting.DeleteAction).GetName())
+		if err != nil {
+			return false, nil, nil
+		}
+		if err := validator.Delete(nil, obj); err != nil {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+
+	err := f.imc.CleanupInstanceManagersForNode(TestNode1)
+	c.Assert(err, IsNil)
+
+	for _, im := range ims {
+		_, imErr := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
+		_, podErr := f.kubeClient.CoreV1().Pods(TestNamespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
+		if im.Spec.NodeID == TestNode1 {
+			if im.DeletionTimestamp == nil {
+				c.Assert(apierrors.IsNotFound(imErr), Equals, true)
+			} else {
+				// The deleting instance manager is gone once the finalizer is removed
+				updatedIM, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).Get(context.TODO(), im.Name, metav1.GetOptions{})
+				c.Assert(err, IsNil)
+				c.Assert(updatedIM.Finalizers, HasLen, 0)
+			}
+			c.Assert(apierrors.IsNotFound(podErr), Equals, true)
+		} else {
+			c.Assert(imErr, IsNil)
+			c.Assert(podErr, IsNil)
+		}
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerDebugLogs(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	output := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = output
+	logger.SetLevel(logrus.InfoLevel)
+	f.imc.logger = logger
+
+	debugIM := newInstanceManager("instance-manager-debug", longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	debugIM.Annotations = map[string]string{types.GetLonghornLabelKey(types.InstanceManagerDebugAnnotationKeySuffix): "true"}
+	f.addInstanceManager(c, debugIM)
+	im := newInstanceManager("instance-manager-regular", longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// Only the sync of the annotated instance manager logs at the trace level
+	f.syncInstanceManager(c, debugIM.Name)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(strings.Contains(output.String(), "instanceManager=instance-manager-debug"), Equals, true)
+	for _, line := range strings.Split(output.String(), "\n") {
+		if strings.Contains(line, "level=trace") {
+			c.Assert(strings.Contains(line, "instanceManager=instance-manager-debug"), Equals, true, Commentf("line %v", line))
+		}
+	}
+	c.Assert(strings.Contains(output.String(), "Syncing instance manager"), Equals, true)
+	c.Assert(logger.IsLevelEnabled(logrus.DebugLevel), Equals, false)
+
+	// The monitor follows the annotation on the polls
+	output.Reset()
+	levelLogger := newDerivedLogger(logger, getInstanceManagerLogLevel(logger, im))
+	monitor := &InstanceManagerMonitor{
+		logger:      levelLogger.WithField("instance manager", im.Name),
+		levelLogger: levelLogger,
+		baseLogger:  logger,
+	}
+	monitor.logger.Debug("Hidden debug log")
+	c.Assert(output.String(), Equals, "")
+
+	monitor.updateLogLevel(debugIM)
+	monitor.logger.Debug("Shown debug log")
+	c.Assert(strings.Contains(output.String(), "Shown debug log"), Equals, true)
+
+	output.Reset()
+	monitor.updateLogLevel(im)
+	monitor.logger.Debug("Hidden debug log")
+	c.Assert(output.String(), Equals, "")
+}
+
+func (s *TestSuite) TestInstanceManagerForceCleanup(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	// The pod deletion keeps failing, e.g., the node of the pod is gone
+	f.kubeClient.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("failed to delete pod")
+	})
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.Finalizers = []string{metav1.FinalizerDeleteDependents}
+	deletionTimestamp := metav1.NewTime(fakeClock.Now())
+	im.DeletionTimestamp = &deletionTimestamp
+	f.addInstanceManager(c, im)
+	f.addPod(c, newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodRunning, PodIP: TestIP1}, im))
+
+	// The cleanup failure is returned for retrying before the timeout
+	timeout, err := f.imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerForceCleanupTimeout)
+	c.Assert(err, IsNil)
+	fakeClock.Step(time.Duration(timeout) * time.Second)
+	err = f.imc.syncInstanceManager(TestNamespace + "/" + im.Name)
+	c.Assert(err, ErrorMatches, ".*failed to delete pod.*")
+	c.Assert(f.getInstanceManager(c, im.Name).Finalizers, DeepEquals, []string{metav1.FinalizerDeleteDependents})
+
+	// The finalizer is removed regardless of the pod once the timeout is exceeded
+	fakeClock.Step(time.Second)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(f.getInstanceManager(c, im.Name).Finalizers, HasLen, 0)
+	c.Assert(f.listPods(c), HasLen, 1)
+}
+
+func (s *TestSuite) TestInstanceManagerForceCleanupTerminatingPod(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im.Finalizers = []string{metav1.FinalizerDeleteDependents}
+	deletionTimestamp := metav1.NewTime(fakeClock.Now())
+	im.DeletionTimestamp = &deletionTimestamp
+	f.addInstanceManager(c, im)
+
+	// The pod is stuck terminating, e.g., the kubelet of the node is gone, so the cleanup has nothing to fail on
+	pod := newInstanceManagerTestPod(&corev1.PodStatus{Phase: corev1.PodRunning, PodIP: TestIP1}, im)
+	pod.DeletionTimestamp = &deletionTimestamp
+	f.addPod(c, pod)
+
+	// The finalizer is kept for the pod to be gone before the timeout
+	timeout, err := f.imc.ds.GetSettingAsInt(types.SettingNameInstanceManagerForceCleanupTimeout)
+	c.Assert(err, IsNil)
+	fakeClock.Step(time.Duration(timeout) * time.Second)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(f.getInstanceManager(c, im.Name).Finalizers, DeepEquals, []string{metav1.FinalizerDeleteDependents})
+
+	// The finalizer is removed once the pod stays terminating past the timeout
+	fakeClock.Step(time.Second)
+	f.syncInstanceManager(c, im.Name)
+	c.Assert(f.getInstanceManager(c, im.Name).Finalizers, HasLen, 0)
+	c.Assert(f.listPods(c), HasLen, 1)
+}
+
+func (s *TestSuite) TestInstanceManagerAllInOne(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	c.Assert(im.Spec.Type, Equals, longhorn.InstanceManagerTypeAllInOne)
+	f.addInstanceManager(c, im)
+
+	// A single pod with a single container hosts both the engines and the replicas
+	pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+	c.Assert(pod.Spec.Containers, HasLen, 1)
+	c.Assert(pod.Spec.Containers[0].Name, Equals, "instance-manager")
+	c.Assert(pod.Labels[types.GetLonghornLabelKey(types.LonghornLabelInstanceManagerType)], Equals, string(longhorn.InstanceManagerTypeAllInOne))
+
+	// A single instance watch of the pod keeps both the engine and the replica instance maps updated
+	m := &InstanceManagerMonitor{
+		logger:       logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:         im.Name,
+		controllerID: TestNode1,
+		ds:           f.imc.ds,
+		lock:         &sync.RWMutex{},
+		clock:        f.imc.clock,
+		nodeCallback: func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return map[string]longhorn.InstanceProcess{
+				TestEngineName: {
+					Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeEngine, State: longhorn.InstanceStateRunning},
+				},
+				TestReplicaName: {
+					Spec:   longhorn.InstanceProcessSpec{Name: TestReplicaName},
+					Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeReplica, State: longhorn.InstanceStateRunning},
+				},
+			}, nil
+		},
+	}
+	c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+
+	im = f.getInstanceManager(c, im.Name)
+	c.Assert(im.Status.InstanceEngines, HasLen, 1)
+	c.Assert(im.Status.InstanceEngines[TestEngineName].Status.Type, Equals, longhorn.InstanceTypeEngine)
+	c.Assert(im.Status.InstanceReplicas, HasLen, 1)
+	c.Assert(im.Status.InstanceReplicas[TestReplicaName].Status.Type, Equals, longhorn.InstanceTypeReplica)
+}
+
+func (s *TestSuite) TestInstanceManagerCPURequestDiskScale(c *C) {
+	for name, tc := range map[string]struct {
+		imType        longhorn.InstanceManagerType
+		guaranteedCPU string
+		maxDiskScale  string
+		diskCount     int
+		expectCPU     string
+	}{
+		"not scaled by default": {
+			imType:        longhorn.InstanceManagerTypeAllInOne,
+			guaranteedCPU: "12",
+			maxDiskScale:  "1",
+			diskCount:     5,
+			expectCPU:     "480m",
+		},
+		"scaled by the disk count": {
+			imType:        longhorn.InstanceManagerTypeAllInOne,
+			guaranteedCPU: "12",
+			maxDiskScale:  "3",
+			diskCount:     2,
+			expectCPU:     "960m",
+		},
+		"scaled up to the max disk scale": {
+			imType:        longhorn.InstanceManagerTypeAllInOne,
+			guaranteedCPU: "12",
+			maxDiskScale:  "3",
+			diskCount:     5,
+			expectCPU:     "1440m",
+		},
+		"capped at the allocatable CPU": {
+			imType:        longhorn.InstanceManagerTypeAllInOne,
+			guaranteedCPU: "40",
+			maxDiskScale:  "3",
+			diskCount:     5,
+			expectCPU:     "4",
+		},
+		"engine instance manager not scaled": {
+			imType:        longhorn.InstanceManagerTypeEngine,
+			guaranteedCPU: "12",
+			maxDiskScale:  "3",
+			diskCount:     5,
+			expectCPU:     "480m",
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		f := newInstanceManagerTestFixture(c, TestNode1)
+		f.addSetting(c, newSetting(string(types.SettingNameGuaranteedInstanceManagerCPU), tc.guaranteedCPU))
+		f.addSetting(c, newSetting(string(types.SettingNameGuaranteedInstanceManagerCPUMaxDiskScale), tc.maxDiskScale))
+
+		kubeNode := newKubernetesNode(TestNode1, corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionFalse, corev1.ConditionFalse, corev1.ConditionFalse, corev1.ConditionTrue)
+		kubeNode.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+		c.Assert(f.kubeNodeIndexer.Add(kubeNode), IsNil)
+
+		// The node carries several filesystem disks and a block disk not counted for the v1 data engine
+		lhNode := newNode(TestNode1, TestNamespace, true, longhorn.ConditionStatusTrue, "")
+		for i := 1; i < tc.diskCount; i++ {
+			lhNode.Spec.Disks[fmt.Sprintf("disk-%d", i)] = longhorn.DiskSpec{
+				Type: longhorn.DiskTypeFilesystem,
+				Path: fmt.Sprintf("/mnt/disk-%d", i),
+			}
+		}
+		lhNode.Spec.Disks["block-disk"] = longhorn.DiskSpec{
+			Type: longhorn.DiskTypeBlock,
+			Path: "/dev/nvme0n1",
+		}
+		c.Assert(f.lhNodeIndexer.Add(lhNode), IsNil)
+
+		im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		im.Spec.Type = tc.imType
+		f.addInstanceManager(c, im)
+
+		pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+		c.Assert(err, IsNil)
+		cpuRequest := pod.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]
+		c.Assert(cpuRequest.String(), Equals, tc.expectCPU)
+	}
+}
+
+func (s *TestSuite) TestListAllInstanceProcesses(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+
+	newProcess := func(name string, instanceType longhorn.InstanceType) longhorn.InstanceProcess {
+		return longhorn.InstanceProcess{
+			Spec:   longhorn.InstanceProcessSpec{Name: name},
+			Status: longhorn.InstanceProcessStatus{Type: instanceType, State: longhorn.InstanceStateRunning},
+		}
+	}
+
+	im1 := newInstanceManager("instance-manager-1", longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		map[string]longhorn.InstanceProcess{TestEngineName: newProcess(TestEngineName, longhorn.InstanceTypeEngine)},
+		map[string]longhorn.InstanceProcess{TestReplicaName: newProcess(TestReplicaName, longhorn.InstanceTypeReplica)},
+		longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im1)
+
+	// The instance manager of the legacy API version keeps all instances in one map
+	im2 := newInstanceManager("instance-manager-2", longhorn.InstanceManagerStateRunning, TestNode2, TestNode2, TestIP2,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	im2.Spec.Type = longhorn.InstanceManagerTypeReplica
+	im2.Status.APIVersion = 3
+	// nolint:all
+	im2.Status.Instances = map[string]longhorn.InstanceProcess{"legacy-replica": newProcess("legacy-replica", longhorn.InstanceTypeReplica)}
+	f.addInstanceManager(c, im2)
+
+	// The instance manager with nil instance maps contributes nothing
+	im3 := newInstanceManager("instance-manager-3", longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+		nil, nil, longhorn.DataEngineTypeV2, false)
+	f.addInstanceManager(c, im3)
+
+	processes, err := f.imc.ds.ListAllInstanceProcesses()
+	c.Assert(err, IsNil)
+	c.Assert(processes, HasLen, 3)
+
+	c.Assert(processes[0].InstanceManagerName, Equals, im1.Name)
+	c.Assert(processes[0].NodeID, Equals, TestNode1)
+	c.Assert(processes[0].InstanceManagerType, Equals, longhorn.InstanceManagerTypeAllInOne)
+	c.Assert(processes[0].Process.Spec.Name, Equals, TestEngineName)
+	c.Assert(processes[0].Process.Status.Type, Equals, longhorn.InstanceTypeEngine)
+	c.Assert(processes[1].InstanceManagerName, Equals, im1.Name)
+	c.Assert(processes[1].Process.Spec.Name, Equals, TestReplicaName)
+	c.Assert(processes[1].Process.Status.Type, Equals, longhorn.InstanceTypeReplica)
+	c.Assert(processes[2].InstanceManagerName, Equals, im2.Name)
+	c.Assert(processes[2].NodeID, Equals, TestNode2)
+	c.Assert(processes[2].InstanceManagerType, Equals, longhorn.InstanceManagerTypeReplica)
+	c.Assert(processes[2].Process.Spec.Name, Equals, "legacy-replica")
+}
+
+func (s *TestSuite) TestInstanceManagerDuplicateInstanceEvent(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	newEngineProcess := func(state longhorn.InstanceState) map[string]longhorn.InstanceProcess {
+		return map[string]longhorn.InstanceProcess{
+			TestEngineName: {
+				Spec:   longhorn.InstanceProcessSpec{Name: TestEngineName},
+				Status: longhorn.InstanceProcessStatus{Type: longhorn.InstanceTypeEngine, State: state},
+			},
+		}
+	}
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+	otherIM := newInstanceManager("instance-manager-2", longhorn.InstanceManagerStateRunning, TestNode2, TestNode2, TestIP2,
+		newEngineProcess(longhorn.InstanceStateStopped), nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, otherIM)
+
+	m := &InstanceManagerMonitor{
+		logger:        logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:          im.Name,
+		controllerID:  TestNode1,
+		ds:            f.imc.ds,
+		lock:          &sync.RWMutex{},
+		clock:         fakeClock,
+		eventRecorder: f.imc.eventRecorder,
+		nodeCallback:  func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return newEngineProcess(longhorn.InstanceStateRunning), nil
+		},
+	}
+	poll := func() {
+		c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+		updatedIM := f.getInstanceManager(c, im.Name)
+		c.Assert(f.imIndexer.Update(updatedIM), IsNil)
+	}
+	events := f.imc.eventRecorder.(*record.FakeRecorder).Events
+	countDuplicateInstanceEvents := func() (count int, lastEvent string) {
+		for {
+			select {
+			case event := <-events:
+				if strings.HasPrefix(event, "Warning "+constant.EventReasonDuplicateInstance+" ") {
+					count++
+					lastEvent = event
+				}
+			default:
+				return count, lastEvent
+			}
+		}
+	}
+
+	// The stopped instance left in the other instance manager is not a conflict
+	poll()
+	count, _ := countDuplicateInstanceEvents()
+	c.Assert(count, Equals, 0)
+
+	// Both instance managers report the same running instance
+	otherIM = f.getInstanceManager(c, otherIM.Name)
+	otherIM.Status.InstanceEngines = newEngineProcess(longhorn.InstanceStateRunning)
+	otherIM, err := f.lhClient.LonghornV1beta2().InstanceManagers(TestNamespace).UpdateStatus(context.TODO(), otherIM, metav1.UpdateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(f.imIndexer.Update(otherIM), IsNil)
+	poll()
+	count, event := countDuplicateInstanceEvents()
+	c.Assert(count, Equals, 1)
+	c.Assert(event, Matches, ".*"+TestEngineName+" \\(also in instance-manager-2\\).*")
+
+	// The warning events are rate limited
+	poll()
+	count, _ = countDuplicateInstanceEvents()
+	c.Assert(count, Equals, 0)
+	fakeClock.Step(instanceManagerDuplicateInstanceEventInterval)
+	poll()
+	count, _ = countDuplicateInstanceEvents()
+	c.Assert(count, Equals, 1)
+}
+
+func (s *TestSuite) TestInstanceManagerExpiredInstanceUpdateEvent(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	f.imc.clock = fakeClock
+
+	newEngineProcess := func(resourceVersion int64) map[string]longhorn.InstanceProcess {
+		return map[string]longhorn.InstanceProcess{
+			TestEngineName: {
+				Spec: longhorn.InstanceProcessSpec{Name: TestEngineName},
+				Status: longhorn.InstanceProcessStatus{
+					Type:            longhorn.InstanceTypeEngine,
+					State:           longhorn.InstanceStateRunning,
+					ResourceVersion: resourceVersion,
+				},
+			},
+		}
+	}
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		newEngineProcess(10), nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	resourceVersion := int64(10)
+	m := &InstanceManagerMonitor{
+		logger:        logrus.StandardLogger().WithField("instanceManager", im.Name),
+		Name:          im.Name,
+		controllerID:  TestNode1,
+		ds:            f.imc.ds,
+		lock:          &sync.RWMutex{},
+		clock:         fakeClock,
+		eventRecorder: f.imc.eventRecorder,
+		nodeCallback:  func(nodeName string) {},
+		instanceLister: func() (map[string]longhorn.InstanceProcess, error) {
+			return newEngineProcess(resourceVersion), nil
+		},
+	}
+	poll := func() {
+		c.Assert(m.pollAndUpdateInstanceMap(), Equals, false)
+		updatedIM := f.getInstanceManager(c, im.Name)
+		c.Assert(f.imIndexer.Update(updatedIM), IsNil)
+	}
+	events := f.imc.eventRecorder.(*record.FakeRecorder).Events
+	countExpiredInstanceUpdateEvents := func() (count int, lastEvent string) {
+		for {
+			select {
+			case event := <-events:
+				if strings.HasPrefix(event, "Warning "+constant.EventReasonExpiredInstanceUpdate+" ") {
+					count++
+					lastEvent = event
+				}
+			default:
+				return count, lastEvent
+			}
+		}
+	}
+
+	// The up-to-date update is accepted silently
+	resourceVersion = 11
+	poll()
+	count, _ := countExpiredInstanceUpdateEvents()
+	c.Assert(count, Equals, 0)
+
+	// The expired update is still recorded in the instance map, and both versions are reported
+	resourceVersion = 9
+	poll()
+	count, event := countExpiredInstanceUpdateEvents()
+	c.Assert(count, Equals, 1)
+	c.Assert(event, Matches, ".*"+TestEngineName+" \\(resource version 9, existing 11\\).*")
+	c.Assert(f.getInstanceManager(c, im.Name).Status.InstanceEngines[TestEngineName].Status.ResourceVersion, Equals, int64(9))
+
+	// The events are rate limited
+	resourceVersion = 8
+	poll()
+	count, _ = countExpiredInstanceUpdateEvents()
+	c.Assert(count, Equals, 0)
+
+	fakeClock.Step(instanceManagerExpiredInstanceUpdateEventInterval)
+	resourceVersion = 7
+	poll()
+	count, _ = countExpiredInstanceUpdateEvents()
+	c.Assert(count, Equals, 1)
+}
+
+func (s *TestSuite) TestInstanceManagerMonitorWatchCancellation(c *C) {
+	for name, stop := range map[string]func(m *InstanceManagerMonitor, stopCh chan struct{}){
+		"stopped by controller": func(m *InstanceManagerMonitor, stopCh chan struct{}) {
+			close(stopCh)
+		},
+		"stopped by monitor itself": func(m *InstanceManagerMonitor, stopCh chan struct{}) {
+			m.StopMonitorWithLock()
+		},
+	} {
+		fmt.Printf("testing %v\n", name)
+
+		stopCh := make(chan struct{})
+		m := &InstanceManagerMonitor{
+			logger: logrus.StandardLogger().WithField("instanceManager", TestInstanceManagerName),
+			Name:   TestInstanceManagerName,
+			lock:   &sync.RWMutex{},
+			clock:  clock.RealClock{},
+			stopCh: stopCh,
+			// A failed receive would be retried after a long delay
+			watchBackoff: flowcontrol.NewBackOff(time.Hour, time.Hour),
+		}
+
+		// The receive blocks until the watch context is canceled, without any item or error from the stream
+		ctx, cancel := m.newWatchContext()
+		received := make(chan struct{})
+		exited := make(chan struct{})
+		go func() {
+			defer close(exited)
+			m.receiveNotifications(func() error {
+				close(received)
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		}()
+		<-received
+
+		stop(m, stopCh)
+		select {
+		case <-exited:
+		case <-time.After(5 * time.Second):
+			c.Fatal("the instance watch receive is not interrupted by the stop")
+		}
+		c.Assert(m.CheckMonitorStoppedWithLock(), Equals, true)
+		c.Assert(m.watchBackoff.Get(m.Name), Equals, time.Duration(0))
+		cancel()
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerEngineBinaryDirectoryWithCustomDataPath(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addSetting(c, newSetting(string(types.SettingNameDefaultDataPath), "/mnt/longhorn"))
+	f.addNode(c, TestNode1)
+
+	lhNode, err := f.lhClient.LonghornV1beta2().Nodes(TestNamespace).Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	lhNode.Spec.Disks[TestDiskID1] = longhorn.DiskSpec{Type: longhorn.DiskTypeFilesystem, Path: "/mnt/longhorn"}
+	c.Assert(f.lhNodeIndexer.Update(lhNode), IsNil)
+
+	im := newInstanceManager(TestInstanceManagerName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	pod, err := f.imc.createInstanceManagerPodSpec(im, nil, "", nil, im.Spec.DataEngine)
+	c.Assert(err, IsNil)
+
+	// The engine image daemon set deploys the binaries into the same directory on every node, which does not follow
+	// the data path. The instance manager pod has to mount that directory to find the binaries.
+	var engineBinaryDirectory string
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == "engine-binaries" {
+			engineBinaryDirectory = volume.HostPath.Path
+		}
+	}
+	c.Assert(engineBinaryDirectory, Equals, types.EngineBinaryDirectoryOnHost)
+	c.Assert(filepath.Dir(types.GetEngineBinaryDirectoryOnHostForImage(TestEngineImage))+"/", Equals, engineBinaryDirectory)
+}
+
+func (s *TestSuite) TestInstanceManagerIndex(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	// Two instance managers of the same type, image and data engine coexist on the node
+	ims := []*longhorn.InstanceManager{}
+	for index := 0; index < 2; index++ {
+		imName, err := types.GetInstanceManagerName(longhorn.InstanceManagerTypeAllInOne, TestNode1, TestInstanceManagerImage,
+			string(longhorn.DataEngineTypeV1), index)
+		c.Assert(err, IsNil)
+		im := newInstanceManager(imName, longhorn.InstanceManagerStateStopped, TestNode1, TestNode1, "",
+			nil, nil, longhorn.DataEngineTypeV1, false)
+		im.Spec.Index = index
+		f.addInstanceManager(c, im)
+		ims = append(ims, im)
+	}
+	c.Assert(ims[0].Name, Not(Equals), ims[1].Name)
+	c.Assert(engineapi.GetInstanceManagerProcessManagerServicePort(ims[0]), Equals, engineapi.InstanceManagerProcessManagerServiceDefaultPort)
+	c.Assert(engineapi.GetInstanceManagerProcessManagerServicePort(ims[1]), Equals,
+		engineapi.InstanceManagerProcessManagerServiceDefaultPort+engineapi.InstanceManagerIndexPortStride)
+	c.Assert(engineapi.GetInstanceManagerSpdkServicePort(ims[0]) < engineapi.GetInstanceManagerProcessManagerServicePort(ims[1]), Equals, true)
+
+	for _, im := range ims {
+		f.syncInstanceManager(c, im.Name)
+	}
+	pods := map[string]corev1.Pod{}
+	for _, pod := range f.listPods(c) {
+		pods[pod.Name] = pod
+	}
+	c.Assert(pods, HasLen, 2)
+
+	for i, im := range ims {
+		pod, exists := pods[im.Name]
+		c.Assert(exists, Equals, true)
+		listen := fmt.Sprintf("0.0.0.0:%d", engineapi.GetInstanceManagerProcessManagerServicePort(im))
+		c.Assert(strings.Join(pod.Spec.Containers[0].Args, " "), Matches, ".*--listen "+listen+".*")
+
+		// The PDB of each instance manager covers its own pod only
+		selector, err := metav1.LabelSelectorAsSelector(f.imc.generateInstanceManagerPDBManifest(im).Spec.Selector)
+		c.Assert(err, IsNil)
+		for j, other := range ims {
+			otherPod := pods[other.Name]
+			c.Assert(selector.Matches(k8slabels.Set(otherPod.Labels)), Equals, i == j)
+		}
+	}
+}
+
+func (s *TestSuite) TestInstanceManagerPDBSelectorUpdate(c *C) {
+	f := newInstanceManagerTestFixture(c, TestNode1)
+	f.addNode(c, TestNode1)
+
+	imName, err := types.GetInstanceManagerName(longhorn.InstanceManagerTypeAllInOne, TestNode1, TestInstanceManagerImage,
+		string(longhorn.DataEngineTypeV1), 0)
+	c.Assert(err, IsNil)
+	im := newInstanceManager(imName, longhorn.InstanceManagerStateRunning, TestNode1, TestNode1, TestIP1,
+		nil, nil, longhorn.DataEngineTypeV1, false)
+	f.addInstanceManager(c, im)
+
+	// A PDB created by an older version selects the pods by the instance manager labels only
+	pdb := f.imc.generateInstanceManagerPDBManifest(im)
+	pdb.Spec.Selector.MatchExpressions = nil
+	pdb, err = f.kubeClient.PolicyV1().PodDisruptionBudgets(TestNamespace).Create(context.TODO(), pdb, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	pdbIndexer := f.informerFactories.KubeNamespaceFilteredInformerFactory.Policy().V1().PodDisruptionBudgets().Informer().GetIndexer()
+	err = pdbIndexer.Add(pdb)
+	c.Assert(err, IsNil)
+
+	err = f.imc.syncInstanceManagerPDB(im)
+	c.Assert(err, IsNil)
+
+	pdb, err = f.kubeClient.PolicyV1().PodDisruptionBudgets(TestNamespace).Get(context.TODO(), pdb.Name, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(pdb.Spec.Selector, DeepEquals, getInstanceManagerPodSelector(im))
+}
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/utils/clock"
+
+	"github.com/longhorn/longhorn-manager/metrics_collector/registry"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+)
+
+// This file contains the metrics which are updated by the controllers directly when the events happen,
+// rather than being collected from the datastore when scraped.
+const (
+	metricsLonghornName             = "longhorn"
+	metricsSubsystemInstanceManager = "instance_manager"
+	metricsLabelInstanceManager     = "instance_manager"
+	metricsLabelInstanceManagerType = "instance_manager_type"
+	metricsLabelNode                = "node"
+	metricsLabelInstanceType        = "instance_type"
+	metricsLabelFromState           = "from_state"
+	metricsLabelToState             = "to_state"
+
+	metricsSubsystemBackingImageDataSource = "backing_image_data_source"
+	metricsLabelSourceType                 = "source_type"
+)
+
+var (
+	instanceManagerStateTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemInstanceManager,
+		Name:      "state_transitions_total",
+		Help:      "Total number of state transitions of this Longhorn instance manager",
+	}, []string{metricsLabelInstanceManager, metricsLabelInstanceManagerType, metricsLabelFromState, metricsLabelToState})
+
+	instanceManagerCurrentState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemInstanceManager,
+		Name:      "state",
+		Help:      "The current state of this Longhorn instance manager. 0=stopped, 1=starting, 2=running, 3=error, 4=unknown",
+	}, []string{metricsLabelInstanceManager, metricsLabelInstanceManagerType})
+
+	instanceManagerInstances = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemInstanceManager,
+		Name:      "instances",
+		Help:      "The number of engine or replica instances hosted by this Longhorn instance manager",
+	}, []string{metricsLabelInstanceManager, metricsLabelNode, metricsLabelInstanceType})
+
+	instanceManagerClientCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemInstanceManager,
+		Name:      "client_cache_hits_total",
+		Help:      "Total number of times a cached Longhorn instance manager client is reused",
+	})
+
+	instanceManagerClientCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemInstanceManager,
+		Name:      "client_cache_misses_total",
+		Help:      "Total number of times a new Longhorn instance manager client is created",
+	})
+
+	backingImageDataSourcePreparationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemBackingImageDataSource,
+		Name:      "preparation_duration_seconds",
+		Help:      "The duration of preparing the file of Longhorn backing image data sources from in progress to ready",
+		Buckets:   []float64{30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400},
+	}, []string{metricsLabelSourceType})
+
+	backingImageDataSourcePreparationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsLonghornName,
+		Subsystem: metricsSubsystemBackingImageDataSource,
+		Name:      "preparation_failures_total",
+		Help:      "Total number of failures of preparing the file of Longhorn backing image data sources",
+	}, []string{metricsLabelSourceType})
+
+	controllerMetrics = []prometheus.Collector{
+		instanceManagerStateTransitions,
+		instanceManagerCurrentState,
+		instanceManagerInstances,
+		instanceManagerClientCacheHits,
+		instanceManagerClientCacheMisses,
+		backingImageDataSourcePreparationDuration,
+		backingImageDataSourcePreparationFailures,
+	}
+)
+
+func init() {
+	for _, m := range controllerMetrics {
+		registry.Register(m)
+	}
+}
+
+func getInstanceManagerStateMetricValue(state longhorn.InstanceManagerState) float64 {
+	switch state {
+	case longhorn.InstanceManagerStateStopped:
+		return 0
+	case longhorn.InstanceManagerStateStarting:
+		return 1
+	case longhorn.InstanceManagerStateRunning:
+		return 2
+	case longhorn.InstanceManagerStateError:
+		return 3
+	}
+	return 4
+}
+
+func recordInstanceManagerStateMetrics(im *longhorn.InstanceManager, previousState longhorn.InstanceManagerState) {
+	if previousState != im.Status.CurrentState {
+		instanceManagerStateTransitions.WithLabelValues(im.Name, string(im.Spec.Type), string(previousState), string(im.Status.CurrentState)).Inc()
+	}
+	instanceManagerCurrentState.WithLabelValues(im.Name, string(im.Spec.Type)).Set(getInstanceManagerStateMetricValue(im.Status.CurrentState))
+}
+
+func deleteInstanceManagerStateMetrics(imName string) {
+	instanceManagerStateTransitions.DeletePartialMatch(prometheus.Labels{metricsLabelInstanceManager: imName})
+	instanceManagerCurrentState.DeletePartialMatch(prometheus.Labels{metricsLabelInstanceManager: imName})
+}
+
+func deleteInstanceManagerInstanceMetrics(imName string) {
+	instanceManagerInstances.DeletePartialMatch(prometheus.Labels{metricsLabelInstanceManager: imName})
+}
+
+// recordInstanceManagerInstanceMetrics sets the number of the engine and replica instances. The instances removed
+// from the map are no longer counted, and the instance type without any instance is set to 0 rather than left stale.
+func recordInstanceManagerInstanceMetrics(im *longhorn.InstanceManager, instances map[string]longhorn.InstanceProcess) {
+	counts := map[longhorn.InstanceType]int{
+		longhorn.InstanceTypeEngine:  0,
+		longhorn.InstanceTypeReplica: 0,
+	}
+	for _, instance := range instances {
+		if _, ok := counts[instance.Status.Type]; ok {
+			counts[instance.Status.Type]++
+		}
+	}
+	for instanceType, count := range counts {
+		instanceManagerInstances.WithLabelValues(im.Name, im.Spec.NodeID, string(instanceType)).Set(float64(count))
+	}
+}
+
+// backingImageDataSourcePreparationTracker remembers when the backing image data sources enter the in-progress state,
+// so the preparation duration can be observed once they become ready. The data sources starting the preparation before
+// the controller restarts or takes over the ownership are not observed.
+type backingImageDataSourcePreparationTracker struct {
+	lock       sync.Mutex
+	clock      clock.PassiveClock
+	startTimes map[string]time.Time
+}
+
+func newBackingImageDataSourcePreparationTracker(clock clock.PassiveClock) *backingImageDataSourcePreparationTracker {
+	return &backingImageDataSourcePreparationTracker{
+		clock:      clock,
+		startTimes: map[string]time.Time{},
+	}
+}
+
+// record observes the preparation duration on the successful completion, or counts the failure, based on the state
+// change between the existing and the updated backing image data source status.
+func (t *backingImageDataSourcePreparationTracker) record(existingBIDS, bids *longhorn.BackingImageDataSource) {
+	if existingBIDS.Status.CurrentState == bids.Status.CurrentState {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	sourceType := string(bids.Spec.SourceType)
+	switch bids.Status.CurrentState {
+	case longhorn.BackingImageStateInProgress:
+		t.startTimes[bids.Name] = t.clock.Now()
+	case longhorn.BackingImageStateReadyForTransfer, longhorn.BackingImageStateReady:
+		startTime, ok := t.startTimes[bids.Name]
+		if !ok {
+			return
+		}
+		delete(t.startTimes, bids.Name)
+		backingImageDataSourcePreparationDuration.WithLabelValues(sourceType).Observe(t.clock.Since(startTime).Seconds())
+	case longhorn.BackingImageStateFailed:
+		delete(t.startTimes, bids.Name)
+		backingImageDataSourcePreparationFailures.WithLabelValues(sourceType).Inc()
+	}
+}
+
+func (t *backingImageDataSourcePreparationTracker) forget(name string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.startTimes, name)
+}
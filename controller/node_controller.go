@@ -69,6 +69,10 @@ type NodeController struct {
 	topologyLabelsChecker TopologyLabelsChecker
 
 	scheduler *scheduler.ReplicaScheduler
+
+	// instanceManagersCleaner tears down the instance managers of the node being deleted, which may be stuck since
+	// the node is gone
+	instanceManagersCleaner func(nodeID string) error
 }
 
 type TopologyLabelsChecker func(kubeClient clientset.Interface, vers string) (bool, error)
@@ -363,6 +367,11 @@ func (nc *NodeController) syncNode(key string) (err error) {
 
 	if node.DeletionTimestamp != nil {
 		nc.eventRecorder.Eventf(node, corev1.EventTypeWarning, constant.EventReasonDelete, "Deleting node %v", node.Name)
+		if nc.instanceManagersCleaner != nil {
+			if err := nc.instanceManagersCleaner(node.Name); err != nil {
+				return err
+			}
+		}
 		return nc.ds.RemoveFinalizerForNode(node)
 	}
 
@@ -1079,8 +1088,11 @@ func (nc *NodeController) syncInstanceManagers(node *longhorn.Node) error {
 				cleanupRequired := true
 
 				if im.Spec.Image == defaultInstanceManagerImage && im.Spec.DataEngine == dataEngine {
-					// Create default instance manager if needed.
-					defaultInstanceManagerCreated = true
+					// Create default instance manager if needed. Additional instance managers
+					// with a non-zero index don't replace the default one.
+					if im.Spec.Index == 0 {
+						defaultInstanceManagerCreated = true
+					}
 					cleanupRequired = false
 
 					if types.IsDataEngineV2(dataEngine) {
@@ -1112,7 +1124,7 @@ func (nc *NodeController) syncInstanceManagers(node *longhorn.Node) error {
 				}
 			}
 			if !defaultInstanceManagerCreated && imType == longhorn.InstanceManagerTypeAllInOne {
-				imName, err := types.GetInstanceManagerName(imType, node.Name, defaultInstanceManagerImage, string(dataEngine))
+				imName, err := types.GetInstanceManagerName(imType, node.Name, defaultInstanceManagerImage, string(dataEngine), 0)
 				if err != nil {
 					return err
 				}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	monitor "github.com/longhorn/longhorn-manager/controller/monitor"
@@ -1818,6 +1819,37 @@ func (s *NodeControllerSuite) checkNodeConditions(c *C, expectation *NodeControl
 	c.Assert(node.Status.Conditions, DeepEquals, expectation.nodeStatus[node.Name].Conditions)
 }
 
+func (s *NodeControllerSuite) TestDeleteNodeCleansUpInstanceManagers(c *C) {
+	node := newNode(TestNode1, TestNamespace, false, longhorn.ConditionStatusFalse, string(longhorn.NodeConditionReasonManagerPodMissing))
+	node.Finalizers = []string{longhornFinalizerKey}
+	deletionTimestamp := metav1.Now()
+	node.DeletionTimestamp = &deletionTimestamp
+	node, err := s.lhClient.LonghornV1beta2().Nodes(TestNamespace).Create(context.TODO(), node, metav1.CreateOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(s.lhNodeIndexer.Add(node), IsNil)
+
+	// The node is kept until the instance managers are cleaned up
+	cleanedNodes := []string{}
+	cleanupErr := fmt.Errorf("failed to clean up instance managers")
+	s.controller.instanceManagersCleaner = func(nodeID string) error {
+		cleanedNodes = append(cleanedNodes, nodeID)
+		return cleanupErr
+	}
+	err = s.controller.syncNode(getKey(node, c))
+	c.Assert(err, ErrorMatches, ".*failed to clean up instance managers.*")
+	node, err = s.lhClient.LonghornV1beta2().Nodes(TestNamespace).Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(node.Finalizers, DeepEquals, []string{longhornFinalizerKey})
+
+	cleanupErr = nil
+	err = s.controller.syncNode(getKey(node, c))
+	c.Assert(err, IsNil)
+	c.Assert(cleanedNodes, DeepEquals, []string{TestNode1, TestNode1})
+	node, err = s.lhClient.LonghornV1beta2().Nodes(TestNamespace).Get(context.TODO(), TestNode1, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(node.Finalizers, HasLen, 0)
+}
+
 func (s *NodeControllerSuite) checkDiskConditions(c *C, expectation *NodeControllerExpectation, node *longhorn.Node) {
 	// Check that all disk status conditions match the expected disk status
 	// conditions - save for the last transition timestamp and the actual message
@@ -344,7 +344,7 @@ func (rc *ReplicaController) CreateInstance(obj interface{}) (*longhorn.Instance
 		return nil, err
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(rc.ds, im)
 	if err != nil {
 		return nil, err
 	}
@@ -540,7 +540,7 @@ func (rc *ReplicaController) DeleteInstance(obj interface{}) error {
 		return nil
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(rc.ds, im)
 	if err != nil {
 		return err
 	}
@@ -663,7 +663,7 @@ func (rc *ReplicaController) GetInstance(obj interface{}) (*longhorn.InstancePro
 		}
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(rc.ds, im)
 	if err != nil {
 		return nil, err
 	}
@@ -694,7 +694,7 @@ func (rc *ReplicaController) LogInstance(ctx context.Context, obj interface{}) (
 		return nil, nil, err
 	}
 
-	c, err := engineapi.NewInstanceManagerClient(im)
+	c, err := newInstanceManagerClient(rc.ds, im)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -306,6 +306,7 @@ func (sc *SettingController) syncDangerZoneSettingsForManagedComponents(settingN
 		types.SettingNameV2DataEngine,
 		types.SettingNameGuaranteedInstanceManagerCPU,
 		types.SettingNameV2DataEngineGuaranteedInstanceManagerCPU,
+		types.SettingNameGuaranteedInstanceManagerCPUMaxDiskScale,
 	}
 
 	if slices.Contains(dangerSettingsRequiringSpecificDataEngineVolumesDetached, settingName) {
@@ -315,7 +316,8 @@ func (sc *SettingController) syncDangerZoneSettingsForManagedComponents(settingN
 				return errors.Wrapf(err, "failed to apply %v setting to Longhorn instance managers when there are attached volumes. "+
 					"It will be eventually applied", settingName)
 			}
-		case types.SettingNameGuaranteedInstanceManagerCPU, types.SettingNameV2DataEngineGuaranteedInstanceManagerCPU:
+		case types.SettingNameGuaranteedInstanceManagerCPU, types.SettingNameV2DataEngineGuaranteedInstanceManagerCPU,
+			types.SettingNameGuaranteedInstanceManagerCPUMaxDiskScale:
 			dataEngine := longhorn.DataEngineTypeV1
 			if settingName == types.SettingNameV2DataEngineGuaranteedInstanceManagerCPU {
 				dataEngine = longhorn.DataEngineTypeV2
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/engineapi"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -76,3 +78,13 @@ func setReplicaFailedAt(r *longhorn.Replica, timestamp string) {
 		r.Spec.LastFailedAt = timestamp
 	}
 }
+
+// newInstanceManagerClient creates the instance manager client, which doesn't fall back to the plaintext connection
+// if the TLS connection is required by the setting.
+func newInstanceManagerClient(ds *datastore.DataStore, im *longhorn.InstanceManager) (*engineapi.InstanceManagerClient, error) {
+	tlsRequired, err := ds.GetSettingAsBool(types.SettingNameInstanceManagerGRPCTLSRequired)
+	if err != nil {
+		return nil, err
+	}
+	return engineapi.NewInstanceManagerClientWithOptions(im, engineapi.InstanceManagerClientOptions{TLSRequired: tlsRequired})
+}
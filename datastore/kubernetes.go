@@ -42,6 +42,7 @@ const (
 	PodProbeTimeoutSeconds           = PodProbePeriodSeconds - 1
 	PodProbePeriodSeconds            = 5
 	PodLivenessProbeFailureThreshold = 3
+	PodStartupProbeFailureThreshold  = 60
 )
 
 func labelMapToLabelSelector(labels map[string]string) (labels.Selector, error) {
@@ -206,6 +207,11 @@ func (s *DataStore) DeletePDB(name string) error {
 	return s.kubeClient.PolicyV1().PodDisruptionBudgets(s.namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 }
 
+// UpdatePDB updates the PodDisruptionBudget resource with the given object and namespace
+func (s *DataStore) UpdatePDB(pdb *policyv1.PodDisruptionBudget) (*policyv1.PodDisruptionBudget, error) {
+	return s.kubeClient.PolicyV1().PodDisruptionBudgets(s.namespace).Update(context.TODO(), pdb, metav1.UpdateOptions{})
+}
+
 // GetPDBRO gets PDB for the given name and namespace.
 // This function returns direct reference to the internal cache object and should not be mutated.
 // Consider using this function when you can guarantee read only access and don't want the overhead of deep copies
@@ -239,6 +245,14 @@ func (s *DataStore) DeletePod(name string) error {
 	return s.kubeClient.CoreV1().Pods(s.namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
 }
 
+// DeletePodWithGracePeriod deletes Pod for the given name and namespace with the given grace period, which overrides
+// the termination grace period in the pod spec
+func (s *DataStore) DeletePodWithGracePeriod(name string, gracePeriodSeconds int64) error {
+	return s.kubeClient.CoreV1().Pods(s.namespace).Delete(context.TODO(), name, metav1.DeleteOptions{
+		GracePeriodSeconds: &gracePeriodSeconds,
+	})
+}
+
 // UpdatePod updates Pod for the given Pod object and namespace
 func (s *DataStore) UpdatePod(obj *corev1.Pod) (*corev1.Pod, error) {
 	return s.kubeClient.CoreV1().Pods(s.namespace).Update(context.TODO(), obj, metav1.UpdateOptions{})
@@ -329,6 +343,12 @@ func (s *DataStore) GetPodRO(namespace, name string) (*corev1.Pod, error) {
 	return pod, err
 }
 
+// GetPodFromAPIServer gets the Pod for the given name in the Longhorn namespace, bypassing the informer cache that may
+// not have caught up yet. Be careful that this function will directly talk with the API server.
+func (s *DataStore) GetPodFromAPIServer(name string) (*corev1.Pod, error) {
+	return s.kubeClient.CoreV1().Pods(s.namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
 // GetPodContainerLog dumps the log of a container in a Pod object for the given name and namespace.
 // Be careful that this function will directly talk with the API server.
 func (s *DataStore) GetPodContainerLog(podName, containerName string) ([]byte, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -362,12 +363,29 @@ func (s *DataStore) ValidateSetting(name, value string) (err error) {
 				return err
 			}
 
+			hostNetwork, err := s.GetSettingAsBool(types.SettingNameInstanceManagerHostNetwork)
+			if err != nil {
+				return err
+			}
+			if dataEngineEnabled && hostNetwork {
+				return errors.Errorf("cannot set %v setting to true when %v setting is true", name, types.SettingNameInstanceManagerHostNetwork)
+			}
+
 			_, err = s.ValidateV2DataEngineEnabled(dataEngineEnabled)
 			if err != nil {
 				return err
 			}
 		}
 
+	case types.SettingNameInstanceManagerHostNetwork:
+		// The instance managers of both data engines on a node would bind the same host ports
+		v2DataEngineEnabled, err := s.GetSettingAsBool(types.SettingNameV2DataEngine)
+		if err != nil {
+			return err
+		}
+		if value == "true" && v2DataEngineEnabled {
+			return errors.Errorf("cannot set %v setting to true when %v setting is true", name, types.SettingNameV2DataEngine)
+		}
 	case types.SettingNameAutoCleanupSystemGeneratedSnapshot:
 		disablePurgeValue, err := s.GetSettingAsBool(types.SettingNameDisableSnapshotPurge)
 		if err != nil {
@@ -3071,6 +3089,133 @@ func (s *DataStore) GetSettingSystemManagedComponentsNodeSelector() (map[string]
 	return nodeSelector, nil
 }
 
+// GetSettingInstanceManagerPodLabels returns the extra labels of instance manager pods
+func (s *DataStore) GetSettingInstanceManagerPodLabels() (map[string]string, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodLabels)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalPodLabels(setting.Value)
+}
+
+// GetSettingInstanceManagerPodAnnotations returns the extra annotations of instance manager pods
+func (s *DataStore) GetSettingInstanceManagerPodAnnotations() (map[string]string, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodAnnotations)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalPodAnnotations(setting.Value)
+}
+
+// GetSettingInstanceManagerPodExtraHostPathMounts returns the extra host path mounts of instance manager pods
+func (s *DataStore) GetSettingInstanceManagerPodExtraHostPathMounts() ([]types.HostPathMount, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodExtraHostPathMounts)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalHostPathMounts(setting.Value)
+}
+
+// GetSettingInstanceManagerPodDNSConfig returns the DNS policy and config of instance manager pods.
+func (s *DataStore) GetSettingInstanceManagerPodDNSConfig() (corev1.DNSPolicy, *corev1.PodDNSConfig, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodDNSConfig)
+	if err != nil {
+		return "", nil, err
+	}
+	return types.UnmarshalPodDNSConfig(setting.Value)
+}
+
+// GetSettingInstanceManagerPodTopologySpreadConstraints returns the topology spread constraints of instance manager
+// pods without the label selectors.
+func (s *DataStore) GetSettingInstanceManagerPodTopologySpreadConstraints() ([]corev1.TopologySpreadConstraint, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodTopologySpreadConstraints)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalPodTopologySpreadConstraints(setting.Value)
+}
+
+// GetSettingInstanceManagerExtraArgs returns the extra arguments appended to the instance manager daemon command
+func (s *DataStore) GetSettingInstanceManagerExtraArgs() ([]string, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerExtraArgs)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalInstanceManagerExtraArgs(setting.Value)
+}
+
+// GetSettingInstanceManagerPodHostMountPath returns the host path mounted into the instance manager containers
+func (s *DataStore) GetSettingInstanceManagerPodHostMountPath() (string, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodHostMountPath)
+	if err != nil {
+		return "", err
+	}
+	return types.UnmarshalInstanceManagerPodHostMountPath(setting.Value)
+}
+
+// GetSettingInstanceManagerImageNodeOverrides returns the instance manager images overridden for the nodes with the
+// matching labels, in the order of precedence
+func (s *DataStore) GetSettingInstanceManagerImageNodeOverrides() ([]types.InstanceManagerImageNodeOverride, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerImageNodeOverrides)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalInstanceManagerImageNodeOverrides(setting.Value)
+}
+
+// GetSettingInstanceManagerPodCapabilities returns the capabilities granted to the instance manager pods instead of
+// the privileged mode. Empty means the pods run in the privileged mode.
+func (s *DataStore) GetSettingInstanceManagerPodCapabilities() ([]corev1.Capability, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(types.SettingNameInstanceManagerPodCapabilities)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalPodCapabilities(setting.Value)
+}
+
+// GetSettingInstanceManagerPodLivenessProbe returns the liveness probe of instance manager pods
+// without the handler. The fields not specified by the setting use the default values.
+func (s *DataStore) GetSettingInstanceManagerPodLivenessProbe() (*corev1.Probe, error) {
+	return s.getSettingProbe(types.SettingNameInstanceManagerPodLivenessProbe, PodLivenessProbeFailureThreshold)
+}
+
+// GetSettingInstanceManagerPodStartupProbe returns the startup probe of instance manager pods, which suspends the
+// liveness probe until the instance manager starts.
+func (s *DataStore) GetSettingInstanceManagerPodStartupProbe() (*corev1.Probe, error) {
+	return s.getSettingProbe(types.SettingNameInstanceManagerPodStartupProbe, PodStartupProbeFailureThreshold)
+}
+
+func (s *DataStore) getSettingProbe(settingName types.SettingName, defaultFailureThreshold int32) (*corev1.Probe, error) {
+	setting, err := s.GetSettingWithAutoFillingRO(settingName)
+	if err != nil {
+		return nil, err
+	}
+	probeSetting, err := types.UnmarshalProbeSetting(setting.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &corev1.Probe{
+		InitialDelaySeconds: PodProbeInitialDelay,
+		TimeoutSeconds:      PodProbeTimeoutSeconds,
+		PeriodSeconds:       PodProbePeriodSeconds,
+		FailureThreshold:    defaultFailureThreshold,
+	}
+	if value, ok := probeSetting[types.ProbeSettingKeyInitialDelaySeconds]; ok {
+		probe.InitialDelaySeconds = value
+	}
+	if value, ok := probeSetting[types.ProbeSettingKeyTimeoutSeconds]; ok {
+		probe.TimeoutSeconds = value
+	}
+	if value, ok := probeSetting[types.ProbeSettingKeyPeriodSeconds]; ok {
+		probe.PeriodSeconds = value
+	}
+	if value, ok := probeSetting[types.ProbeSettingKeyFailureThreshold]; ok {
+		probe.FailureThreshold = value
+	}
+	return probe, nil
+}
+
 // ResetMonitoringEngineStatus clean and update Engine status
 func (s *DataStore) ResetMonitoringEngineStatus(e *longhorn.Engine) (*longhorn.Engine, error) {
 	e.Status.Endpoint = ""
@@ -3193,13 +3338,19 @@ func (s *DataStore) GetDefaultInstanceManagerByNodeRO(name string, dataEngine lo
 	}
 
 	instanceManager := &longhorn.InstanceManager{}
+	defaultInstanceManagerCount := 0
 	for _, im := range instanceManagers {
-		instanceManager = im
-
-		if len(instanceManagers) != 1 {
-			logrus.Debugf("Found more than 1 %v instance manager with %v on %v, use %v", longhorn.InstanceManagerTypeEngine, defaultInstanceManagerImage, name, instanceManager.Name)
-			break
+		if im.Spec.Index > 0 {
+			// Only the instance manager with index 0 is the default one
+			continue
 		}
+		defaultInstanceManagerCount++
+		if defaultInstanceManagerCount == 1 {
+			instanceManager = im
+		}
+	}
+	if defaultInstanceManagerCount > 1 {
+		logrus.Debugf("Found more than 1 default %v instance manager with %v on %v, use %v", longhorn.InstanceManagerTypeAllInOne, defaultInstanceManagerImage, name, instanceManager.Name)
 	}
 
 	return instanceManager, nil
@@ -3293,6 +3444,12 @@ func (s *DataStore) GetInstanceManagerByInstanceRO(obj interface{}) (*longhorn.I
 	if err != nil {
 		return nil, err
 	}
+	for name, im := range imMap {
+		// Instances are not scheduled to the instance managers with a non-zero index yet
+		if im.Spec.Index > 0 {
+			delete(imMap, name)
+		}
+	}
 	if len(imMap) == 1 {
 		for _, im := range imMap {
 			return im, nil
@@ -3306,6 +3463,50 @@ func (s *DataStore) ListInstanceManagersByNodeRO(node string, imType longhorn.In
 	return s.ListInstanceManagersBySelectorRO(node, "", imType, dataEngine)
 }
 
+// InstanceProcessInfo is an instance process tagged with the instance manager hosting it
+type InstanceProcessInfo struct {
+	InstanceManagerName string
+	InstanceManagerType longhorn.InstanceManagerType
+	NodeID              string
+	DataEngine          longhorn.DataEngineType
+	Process             longhorn.InstanceProcess
+}
+
+// ListAllInstanceProcesses returns the instance processes of all instance managers, sorted by the instance manager
+// name and then the instance name. The instance maps of all API versions are aggregated.
+func (s *DataStore) ListAllInstanceProcesses() ([]InstanceProcessInfo, error) {
+	imList, err := s.instanceManagerLister.InstanceManagers(s.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(imList, func(i, j int) bool {
+		return imList[i].Name < imList[j].Name
+	})
+
+	processes := []InstanceProcessInfo{}
+	for _, im := range imList {
+		// nolint:all
+		instances := types.ConsolidateInstances(im.Status.InstanceEngines, im.Status.InstanceReplicas, im.Status.Instances)
+		instanceNames := make([]string, 0, len(instances))
+		for name := range instances {
+			instanceNames = append(instanceNames, name)
+		}
+		sort.Strings(instanceNames)
+
+		for _, name := range instanceNames {
+			process := instances[name]
+			processes = append(processes, InstanceProcessInfo{
+				InstanceManagerName: im.Name,
+				InstanceManagerType: im.Spec.Type,
+				NodeID:              im.Spec.NodeID,
+				DataEngine:          im.Spec.DataEngine,
+				Process:             *process.DeepCopy(),
+			})
+		}
+	}
+	return processes, nil
+}
+
 // ListInstanceManagers gets a list of InstanceManagers for the given namespace.
 // Returns a new InstanceManager object
 func (s *DataStore) ListInstanceManagers() (map[string]*longhorn.InstanceManager, error) {
@@ -3348,6 +3549,27 @@ func (s *DataStore) UpdateInstanceManager(im *longhorn.InstanceManager) (*longho
 	return obj, nil
 }
 
+// RemoveFinalizerForInstanceManager removes the foreground deletion finalizer of the instance manager, so the deletion
+// no longer waits for the instance manager pod to be deleted
+func (s *DataStore) RemoveFinalizerForInstanceManager(obj *longhorn.InstanceManager) error {
+	if !util.FinalizerExists(metav1.FinalizerDeleteDependents, obj) {
+		// finalizer already removed
+		return nil
+	}
+	if err := util.RemoveFinalizer(metav1.FinalizerDeleteDependents, obj); err != nil {
+		return err
+	}
+	_, err := s.lhClient.LonghornV1beta2().InstanceManagers(s.namespace).Update(context.TODO(), obj, metav1.UpdateOptions{})
+	if err != nil {
+		// workaround `StorageError: invalid object, Code: 4` due to empty object
+		if obj.DeletionTimestamp != nil {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to remove finalizer for instance manager %v", obj.Name)
+	}
+	return nil
+}
+
 // UpdateInstanceManagerStatus updates Longhorn InstanceManager resource status
 // and verifies update
 func (s *DataStore) UpdateInstanceManagerStatus(im *longhorn.InstanceManager) (*longhorn.InstanceManager, error) {
@@ -31,7 +31,7 @@ func NewDiskServiceClient(im *longhorn.InstanceManager, logger logrus.FieldLogge
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	endpoint := "tcp://" + imutil.GetURL(im.Status.IP, InstanceManagerDiskServiceDefaultPort)
+	endpoint := "tcp://" + imutil.GetURL(im.Status.IP, GetInstanceManagerDiskServicePort(im))
 	client, err := imclient.NewDiskServiceClient(ctx, cancel, endpoint, nil)
 	if err != nil {
 		return nil, err
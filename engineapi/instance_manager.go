@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"runtime"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -41,6 +44,14 @@ const (
 	// IncompatibleInstanceManagerAPIVersion means the instance manager version in v0.7.0
 	IncompatibleInstanceManagerAPIVersion = -1
 	DeprecatedInstanceManagerBinaryName   = "longhorn-instance-manager"
+
+	// The listed instances are parsed in parallel only if there are enough instances to amortize the goroutines
+	instanceParseParallelThreshold = 64
+	instanceParseConcurrentLimit   = 8
+
+	// The gRPC clients wait for the connection until the gRPC service timeout, so the connection check of an
+	// unreachable instance manager is cancelled earlier. The gRPC clients already set the TCP keepalive.
+	instanceManagerConnectionCheckTimeout = 10 * time.Second
 )
 
 type InstanceManagerClient struct {
@@ -92,8 +103,21 @@ func CheckInstanceManagerProxySupport(im *longhorn.InstanceManager) error {
 	return nil
 }
 
+// InstanceManagerClientOptions customizes the connection of the instance manager client
+type InstanceManagerClientOptions struct {
+	// TLSRequired disables the fallback to the plaintext connection if the TLS connection cannot be established
+	TLSRequired bool
+}
+
 // NewInstanceManagerClient creates a new instance manager client
 func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerClient, error) {
+	return NewInstanceManagerClientWithOptions(im, InstanceManagerClientOptions{})
+}
+
+// NewInstanceManagerClientWithOptions creates the instance manager client, which connects with TLS using the
+// certificates in the TLS directory if possible. Otherwise, it falls back to the plaintext connection unless TLS is
+// required.
+func NewInstanceManagerClientWithOptions(im *longhorn.InstanceManager, opts InstanceManagerClientOptions) (*InstanceManagerClient, error) {
 	// Do not check the major version here. Since IM cannot get the major version without using this client to call VersionGet().
 	if im.Status.CurrentState != longhorn.InstanceManagerStateRunning || im.Status.IP == "" {
 		return nil, fmt.Errorf("invalid Instance Manager %v, state: %v, IP: %v", im.Name, im.Status.CurrentState, im.Status.IP)
@@ -122,7 +146,7 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 			return nil, errors.Wrap(err, "failed to load Instance Manager Process Manager Service Client TLS files")
 		}
 
-		if err = processManagerClient.CheckConnection(); err != nil {
+		if err = checkInstanceManagerConnection(processManagerClient.CheckConnection, cancel, instanceManagerConnectionCheckTimeout); err != nil {
 			return processManagerClient, errors.Wrapf(err, "failed to check Instance Manager Process Manager Service Client TLS connection for %v IP %v",
 				im.Name, im.Status.IP)
 		}
@@ -155,7 +179,7 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 			return nil, errors.Wrap(err, "failed to load Instance Manager Instance Service Client TLS files")
 		}
 
-		if err = instanceServiceClient.CheckConnection(); err != nil {
+		if err = checkInstanceManagerConnection(instanceServiceClient.CheckConnection, cancel, instanceManagerConnectionCheckTimeout); err != nil {
 			return instanceServiceClient, errors.Wrapf(err, "failed to check Instance Manager Instance Service Client TLS connection for %v IP %v",
 				im.Name, im.Status.IP)
 		}
@@ -171,7 +195,7 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 	// HACK: TODO: fix me
 	var err error
 	var processManagerClient *imclient.ProcessManagerClient
-	endpoint := "tcp://" + imutil.GetURL(im.Status.IP, InstanceManagerProcessManagerServiceDefaultPort)
+	endpoint := GetInstanceManagerProcessManagerServiceEndpoint(im)
 	if im.Status.APIVersion < 4 {
 		processManagerClient, err = initProcessManagerTLSClient(endpoint)
 		defer func() {
@@ -180,6 +204,10 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 				processManagerClient = nil
 			}
 		}()
+		if err != nil && opts.TLSRequired {
+			return nil, errors.Wrapf(err, "failed to establish the required TLS connection of Instance Manager Process Manager Service Client for %v IP %v",
+				im.Name, im.Status.IP)
+		}
 		if err != nil {
 			logrus.WithError(err).Tracef("Falling back to non-tls client for Instance Manager Process Manager Service Client for %v IP %v",
 				im.Name, im.Status.IP)
@@ -191,7 +219,7 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 				return nil, errors.Wrapf(err, "failed to initialize Instance Manager Process Manager Service Client for %v IP %v",
 					im.Name, im.Status.IP)
 			}
-			if err = processManagerClient.CheckConnection(); err != nil {
+			if err = checkInstanceManagerConnection(processManagerClient.CheckConnection, cancel, instanceManagerConnectionCheckTimeout); err != nil {
 				return nil, errors.Wrapf(err, "failed to check Instance Manager Process Manager Service Client connection for %v IP %v",
 					im.Name, im.Status.IP)
 			}
@@ -213,7 +241,7 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 	}
 
 	// Create a new instance service  client
-	endpoint = "tcp://" + imutil.GetURL(im.Status.IP, InstanceManagerInstanceServiceDefaultPort)
+	endpoint = GetInstanceManagerInstanceServiceEndpoint(im)
 	instanceServiceClient, err := initInstanceServiceTLSClient(endpoint)
 	defer func() {
 		if err != nil && instanceServiceClient != nil {
@@ -221,6 +249,10 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 			instanceServiceClient = nil
 		}
 	}()
+	if err != nil && opts.TLSRequired {
+		return nil, errors.Wrapf(err, "failed to establish the required TLS connection of Instance Manager Instance Service Client for %v IP %v",
+			im.Name, im.Status.IP)
+	}
 	if err != nil {
 		logrus.WithError(err).Tracef("Falling back to non-tls client for Instance Manager Instance Service Client for %v, IP %v",
 			im.Name, im.Status.IP)
@@ -232,7 +264,7 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 			return nil, errors.Wrapf(err, "failed to initialize Instance Manager Instance Service Client for %v IP %v",
 				im.Name, im.Status.IP)
 		}
-		if err = instanceServiceClient.CheckConnection(); err != nil {
+		if err = checkInstanceManagerConnection(instanceServiceClient.CheckConnection, cancel, instanceManagerConnectionCheckTimeout); err != nil {
 			return nil, errors.Wrapf(err, "failed to check Instance Manager Instance Service Client connection for %v IP %v",
 				im.Name, im.Status.IP)
 		}
@@ -257,6 +289,31 @@ func NewInstanceManagerClient(im *longhorn.InstanceManager) (*InstanceManagerCli
 	}, nil
 }
 
+// checkInstanceManagerConnection runs the connection check of a gRPC client and cancels the client context if the
+// check does not finish within the timeout, so that an unreachable instance manager fails fast rather than stalling
+// the caller.
+func checkInstanceManagerConnection(check func() error, cancel context.CancelFunc, timeout time.Duration) error {
+	timer := time.AfterFunc(timeout, cancel)
+	if err := check(); err != nil {
+		timer.Stop()
+		return err
+	}
+	if !timer.Stop() {
+		return fmt.Errorf("connection check did not finish within %v", timeout)
+	}
+	return nil
+}
+
+// GetInstanceManagerProcessManagerServiceEndpoint returns the gRPC endpoint of the process manager service of the instance manager
+func GetInstanceManagerProcessManagerServiceEndpoint(im *longhorn.InstanceManager) string {
+	return "tcp://" + imutil.GetURL(im.Status.IP, GetInstanceManagerProcessManagerServicePort(im))
+}
+
+// GetInstanceManagerInstanceServiceEndpoint returns the gRPC endpoint of the instance service of the instance manager
+func GetInstanceManagerInstanceServiceEndpoint(im *longhorn.InstanceManager) string {
+	return "tcp://" + imutil.GetURL(im.Status.IP, GetInstanceManagerInstanceServicePort(im))
+}
+
 func parseInstance(p *imapi.Instance) *longhorn.InstanceProcess {
 	if p == nil {
 		return nil
@@ -659,8 +716,6 @@ func (c *InstanceManagerClient) InstanceList() (map[string]longhorn.InstanceProc
 		return nil, err
 	}
 
-	result := map[string]longhorn.InstanceProcess{}
-
 	if c.GetAPIVersion() < 4 {
 		/* Fall back to the old way of listing processes */
 		processes, err := c.processManagerGrpcClient.ProcessList()
@@ -678,11 +733,51 @@ func (c *InstanceManagerClient) InstanceList() (map[string]longhorn.InstanceProc
 	if err != nil {
 		return nil, err
 	}
-	for name, instance := range instances {
-		result[name] = *parseInstance(instance)
+
+	// There is no gain to parse the instances with more workers than the available CPUs
+	return parseInstances(instances, min(instanceParseConcurrentLimit, runtime.GOMAXPROCS(0))), nil
+}
+
+// parseInstances parses the listed instances with bounded parallelism, since an instance manager may host
+// hundreds of instances. Each worker parses a chunk of the instances then merges the results.
+func parseInstances(instances map[string]*imapi.Instance, concurrentLimit int) map[string]longhorn.InstanceProcess {
+	result := make(map[string]longhorn.InstanceProcess, len(instances))
+	if len(instances) < instanceParseParallelThreshold || concurrentLimit <= 1 {
+		for name, instance := range instances {
+			result[name] = *parseInstance(instance)
+		}
+		return result
+	}
+
+	names := make([]string, 0, len(instances))
+	for name := range instances {
+		names = append(names, name)
+	}
+	chunkSize := (len(names) + concurrentLimit - 1) / concurrentLimit
+
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for start := 0; start < len(names); start += chunkSize {
+		chunk := names[start:min(start+chunkSize, len(names))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			processes := make(map[string]longhorn.InstanceProcess, len(chunk))
+			for _, name := range chunk {
+				processes[name] = *parseInstance(instances[name])
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			for name, process := range processes {
+				result[name] = process
+			}
+		}()
 	}
+	wg.Wait()
 
-	return result, nil
+	return result
 }
 
 type EngineInstanceUpgradeRequest struct {
@@ -0,0 +1,190 @@
+package engineapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	imapi "github.com/longhorn/longhorn-instance-manager/pkg/api"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+)
+
+func TestGetInstanceManagerServiceEndpoint(t *testing.T) {
+	assert := require.New(t)
+
+	tests := map[string]struct {
+		ip                             string
+		port                           int
+		expectedProcessManagerEndpoint string
+		expectedInstanceEndpoint       string
+	}{
+		"ipv4": {
+			ip:                             "10.42.0.1",
+			expectedProcessManagerEndpoint: "tcp://10.42.0.1:8500",
+			expectedInstanceEndpoint:       "tcp://10.42.0.1:8503",
+		},
+		"ipv6": {
+			ip:                             "fd00::1",
+			expectedProcessManagerEndpoint: "tcp://[fd00::1]:8500",
+			expectedInstanceEndpoint:       "tcp://[fd00::1]:8503",
+		},
+		"ipv6 with custom port": {
+			ip:                             "fd00::1",
+			port:                           9500,
+			expectedProcessManagerEndpoint: "tcp://[fd00::1]:9500",
+			expectedInstanceEndpoint:       "tcp://[fd00::1]:9503",
+		},
+	}
+
+	for name, test := range tests {
+		im := &longhorn.InstanceManager{
+			Spec: longhorn.InstanceManagerSpec{
+				Port: test.port,
+			},
+			Status: longhorn.InstanceManagerStatus{
+				IP: test.ip,
+			},
+		}
+		assert.Equal(test.expectedProcessManagerEndpoint, GetInstanceManagerProcessManagerServiceEndpoint(im), name)
+		assert.Equal(test.expectedInstanceEndpoint, GetInstanceManagerInstanceServiceEndpoint(im), name)
+	}
+}
+
+func newTestInstances(count int) map[string]*imapi.Instance {
+	instances := map[string]*imapi.Instance{}
+	for i := 0; i < count; i++ {
+		instanceType := string(longhorn.InstanceTypeEngine)
+		if i%2 == 1 {
+			instanceType = string(longhorn.InstanceTypeReplica)
+		}
+		name := fmt.Sprintf("%v-%v", instanceType, i)
+		instances[name] = &imapi.Instance{
+			Name:       name,
+			Type:       instanceType,
+			DataEngine: string(longhorn.DataEngineTypeV1),
+			InstanceStatus: imapi.InstanceStatus{
+				State:      string(longhorn.InstanceStateRunning),
+				Conditions: map[string]bool{"FilesystemReadOnly": false},
+				PortStart:  int32(10000 + i),
+				PortEnd:    int32(10000 + i),
+			},
+		}
+	}
+	return instances
+}
+
+func TestParseInstances(t *testing.T) {
+	assert := require.New(t)
+
+	for _, count := range []int{0, 1, instanceParseParallelThreshold - 1, instanceParseParallelThreshold, 1000} {
+		instances := newTestInstances(count)
+		serial := parseInstances(instances, 1)
+		assert.Len(serial, count)
+		assert.Equal(serial, parseInstances(instances, instanceParseConcurrentLimit), "instance count %v", count)
+	}
+}
+
+func TestParseInstance(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Nil(parseInstance(nil))
+
+	// The instance status carries the state, the error, the conditions and the ports only. The resource usage of
+	// the instance is not reported by the instance manager API.
+	instance := parseInstance(&imapi.Instance{
+		Name:       "test-volume-r-12345678",
+		DataEngine: string(longhorn.DataEngineTypeV2),
+		InstanceStatus: imapi.InstanceStatus{
+			State:      string(longhorn.InstanceStateError),
+			ErrorMsg:   "failed to start",
+			Conditions: map[string]bool{"FilesystemReadOnly": true},
+			PortStart:  10000,
+			PortEnd:    10010,
+		},
+	})
+	assert.Equal(&longhorn.InstanceProcess{
+		Spec: longhorn.InstanceProcessSpec{
+			Name:       "test-volume-r-12345678",
+			DataEngine: longhorn.DataEngineTypeV2,
+		},
+		Status: longhorn.InstanceProcessStatus{
+			Type:       longhorn.InstanceTypeReplica,
+			State:      longhorn.InstanceStateError,
+			ErrorMsg:   "failed to start",
+			Conditions: map[string]bool{"FilesystemReadOnly": true},
+			PortStart:  10000,
+			PortEnd:    10010,
+		},
+	}, instance)
+}
+
+func BenchmarkParseInstances(b *testing.B) {
+	instances := newTestInstances(1000)
+	for _, concurrentLimit := range []int{1, instanceParseConcurrentLimit} {
+		b.Run(fmt.Sprintf("concurrent-limit-%v", concurrentLimit), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				parseInstances(instances, concurrentLimit)
+			}
+		})
+	}
+}
+
+func TestCheckInstanceManagerConnection(t *testing.T) {
+	timeout := 500 * time.Millisecond
+
+	// The check of an unreachable instance manager blocks until the client context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	err := checkInstanceManagerConnection(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, cancel, timeout)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), timeout+time.Second)
+
+	// The client context stays usable after a successful check.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	err = checkInstanceManagerConnection(func() error { return nil }, cancel, timeout)
+	require.NoError(t, err)
+	time.Sleep(2 * timeout)
+	require.NoError(t, ctx.Err())
+}
+
+func TestNewInstanceManagerClientWithTLSRequired(t *testing.T) {
+	assert := require.New(t)
+
+	// The listener accepts the connections but never speaks gRPC, and the TLS files are not available in the test.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	im := &longhorn.InstanceManager{}
+	im.Name = "instance-manager"
+	im.Spec.Port = listener.Addr().(*net.TCPAddr).Port - 3
+	im.Status.CurrentState = longhorn.InstanceManagerStateRunning
+	im.Status.IP = "127.0.0.1"
+	im.Status.APIVersion = CurrentInstanceManagerAPIVersion
+
+	_, err = NewInstanceManagerClientWithOptions(im, InstanceManagerClientOptions{TLSRequired: true})
+	assert.ErrorContains(err, "failed to establish the required TLS connection")
+
+	// The client falls back to the plaintext connection unless TLS is required
+	_, err = NewInstanceManagerClientWithOptions(im, InstanceManagerClientOptions{})
+	assert.Error(err)
+	assert.NotContains(err.Error(), "required TLS connection")
+}
@@ -96,7 +96,7 @@ func NewEngineClientProxy(im *longhorn.InstanceManager, logger logrus.FieldLogge
 		proxyClient, err = imclient.NewProxyClientWithTLS(ctx,
 			cancel,
 			ip,
-			InstanceManagerProxyServiceDefaultPort,
+			GetInstanceManagerProxyServicePort(im),
 			filepath.Join(types.TLSDirectoryInContainer, types.TLSCAFile),
 			filepath.Join(types.TLSDirectoryInContainer, types.TLSCertFile),
 			filepath.Join(types.TLSDirectoryInContainer, types.TLSKeyFile),
@@ -125,7 +125,7 @@ func NewEngineClientProxy(im *longhorn.InstanceManager, logger logrus.FieldLogge
 		// fallback to non tls client, there is no way to differentiate between im versions unless we get the version via the im client
 		// TODO: remove this im client fallback mechanism in a future version maybe 2.4 / 2.5 or the next time we update the api version
 		ctx, cancel := context.WithCancel(context.Background())
-		proxyClient, err = imclient.NewProxyClient(ctx, cancel, im.Status.IP, InstanceManagerProxyServiceDefaultPort, nil)
+		proxyClient, err = imclient.NewProxyClient(ctx, cancel, im.Status.IP, GetInstanceManagerProxyServicePort(im), nil)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to initialize Proxy Service Client for %v IP %v",
 				im.Name, im.Status.IP)
@@ -30,6 +30,9 @@ const (
 	InstanceManagerInstanceServiceDefaultPort       = InstanceManagerProcessManagerServiceDefaultPort + 3 // 8503
 	InstanceManagerSpdkServiceDefaultPort           = InstanceManagerProcessManagerServiceDefaultPort + 4 // 8504
 
+	// InstanceManagerIndexPortStride is the distance between the default base ports of instance managers with adjacent indexes
+	InstanceManagerIndexPortStride = 10
+
 	BackingImageManagerDefaultPort    = 8000
 	BackingImageDataSourceDefaultPort = 8000
 	BackingImageSyncServerDefaultPort = 8001
@@ -310,3 +313,28 @@ func IsEndpointTGTBlockDev(endpoint string) bool {
 
 	return true
 }
+
+// GetInstanceManagerProcessManagerServicePort returns the base port of the instance manager, which is the port the
+// process manager service listens on. The other services listen on the subsequent ports.
+func GetInstanceManagerProcessManagerServicePort(im *longhorn.InstanceManager) int {
+	if im.Spec.Port == 0 {
+		return InstanceManagerProcessManagerServiceDefaultPort + im.Spec.Index*InstanceManagerIndexPortStride
+	}
+	return im.Spec.Port
+}
+
+func GetInstanceManagerProxyServicePort(im *longhorn.InstanceManager) int {
+	return GetInstanceManagerProcessManagerServicePort(im) + 1
+}
+
+func GetInstanceManagerDiskServicePort(im *longhorn.InstanceManager) int {
+	return GetInstanceManagerProcessManagerServicePort(im) + 2
+}
+
+func GetInstanceManagerInstanceServicePort(im *longhorn.InstanceManager) int {
+	return GetInstanceManagerProcessManagerServicePort(im) + 3
+}
+
+func GetInstanceManagerSpdkServicePort(im *longhorn.InstanceManager) int {
+	return GetInstanceManagerProcessManagerServicePort(im) + 4
+}
@@ -44,6 +44,9 @@ type BackingImageDataSourceSpec struct {
 	Parameters map[string]string `json:"parameters"`
 	// +optional
 	FileTransferred bool `json:"fileTransferred"`
+	// Cancel stops the file preparation, e.g., an in-progress download, without retrying. The partial file is cleaned up.
+	// +optional
+	Cancel bool `json:"cancel"`
 }
 
 // BackingImageDataSourceStatus defines the observed state of the Longhorn backing image data source
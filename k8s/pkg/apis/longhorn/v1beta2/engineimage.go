@@ -58,6 +58,9 @@ type EngineImageStatus struct {
 	NoRefSince string `json:"noRefSince"`
 	// +optional
 	Incompatible bool `json:"incompatible"`
+	// ImageDigest is the digest of the image resolved by the container runtime, e.g. "sha256:<hex>"
+	// +optional
+	ImageDigest string `json:"imageDigest"`
 	// +optional
 	// +nullable
 	Conditions []Condition `json:"conditions"`
@@ -34,8 +34,26 @@ const (
 	InstanceConditionTypeInstanceCreation = "InstanceCreation"
 )
 
+const (
+	InstanceManagerConditionTypePodScheduled     = "PodScheduled"
+	InstanceManagerConditionTypeReady            = "Ready"
+	InstanceManagerConditionTypeWatchEstablished = "WatchEstablished"
+)
+
+const (
+	InstanceManagerConditionReasonPodNotFound         = "PodNotFound"
+	InstanceManagerConditionReasonPodUnschedulable    = "PodUnschedulable"
+	InstanceManagerConditionReasonNotRunning          = "NotRunning"
+	InstanceManagerConditionReasonWatchNotEstablished = "WatchNotEstablished"
+)
+
+// InstanceManagerNodeBootIDUnknown is recorded as the node boot ID if the node doesn't report the boot ID, which
+// distinguishes it from the boot ID not recorded yet.
+const InstanceManagerNodeBootIDUnknown = "unknown"
+
 const (
 	InstanceConditionReasonInstanceCreationFailure = "InstanceCreationFailure"
+	InstanceConditionReasonInstanceManagerError    = "InstanceManagerError"
 )
 
 type InstanceProcess struct {
@@ -139,6 +157,15 @@ type InstanceProcessStatus struct {
 	Type InstanceType `json:"type"`
 	// +optional
 	ResourceVersion int64 `json:"resourceVersion"`
+	// The number of times the instance process was observed restarting with the same name.
+	// +optional
+	RestartCount int32 `json:"restartCount"`
+	// The time when the instance was observed transitioning into running.
+	// +optional
+	StartedAt string `json:"startedAt"`
+	// The time when the instance was observed transitioning into stopped.
+	// +optional
+	StoppedAt string `json:"stoppedAt"`
 }
 
 // InstanceManagerSpec defines the desired state of the Longhorn instance manager
@@ -151,12 +178,29 @@ type InstanceManagerSpec struct {
 	Type InstanceManagerType `json:"type"`
 	// +optional
 	DataEngine DataEngineType `json:"dataEngine"`
+	// The base port of the instance manager gRPC services. The proxy, disk, instance and SPDK services listen on the subsequent ports.
+	// Defaults to 8500 when empty.
+	// +optional
+	Port int `json:"port"`
+	// The ordinal of the instance manager among the instance managers of the same type, image and data engine on the node.
+	// Non-zero indexes get distinct names, pods and default ports so that they can coexist with the default one (index 0).
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Index int `json:"index"`
+	// The desired state of the instance manager. Set to stopped to hold the instance manager stopped for maintenance,
+	// in which case the pod is deleted and not recreated. The pod is kept until the running instances are gone, unless
+	// the instance manager is annotated with the force deletion. Empty means running.
+	// +optional
+	DesiredState InstanceManagerState `json:"desiredState"`
 }
 
 // InstanceManagerStatus defines the observed state of the Longhorn instance manager
 type InstanceManagerStatus struct {
 	// +optional
 	OwnerID string `json:"ownerID"`
+	// OwnerPodName is the name of the longhorn-manager pod reconciling the instance manager on the owner node.
+	// +optional
+	OwnerPodName string `json:"ownerPodName"`
 	// +optional
 	CurrentState InstanceManagerState `json:"currentState"`
 	// +optional
@@ -175,6 +219,26 @@ type InstanceManagerStatus struct {
 	ProxyAPIMinVersion int `json:"proxyApiMinVersion"`
 	// +optional
 	ProxyAPIVersion int `json:"proxyApiVersion"`
+	// APIReady indicates the instance map is trustworthy. It is set only after the instance manager is running,
+	// the instance watch is established, and the initial poll of the instances succeeds.
+	// +optional
+	APIReady bool `json:"apiReady"`
+	// NodeBootID is the boot ID of the node when the instance manager becomes running. It is "unknown" if the node
+	// doesn't report the boot ID.
+	// +optional
+	NodeBootID string `json:"nodeBootID"`
+	// CurrentStateTransitionTime is the time when the instance manager entered the current state.
+	// +optional
+	CurrentStateTransitionTime string `json:"currentStateTransitionTime"`
+	// LastPollTimestamp is the time when the instance monitor last polled the instances successfully.
+	// +optional
+	LastPollTimestamp string `json:"lastPollTimestamp"`
+	// +optional
+	Message string `json:"message"`
+	// Conditions of the instance manager, including PodScheduled, Ready and WatchEstablished.
+	// +optional
+	// +nullable
+	Conditions []Condition `json:"conditions"`
 
 	// Deprecated: Replaced by InstanceEngines and InstanceReplicas
 	// +optional
@@ -191,6 +255,7 @@ type InstanceManagerStatus struct {
 // +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.currentState`,description="The state of the instance manager"
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`,description="The type of the instance manager (engine or replica)"
 // +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeID`,description="The node that the instance manager is running on"
+// +kubebuilder:printcolumn:name="Last Poll",type=date,JSONPath=`.status.lastPollTimestamp`,description="The time when the instances of the instance manager were last polled successfully"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // InstanceManager is where Longhorn stores instance manager object.
@@ -1420,6 +1420,11 @@ func (in *InstanceManagerStatus) DeepCopyInto(out *InstanceManagerStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		copy(*out, *in)
+	}
 	if in.Instances != nil {
 		in, out := &in.Instances, &out.Instances
 		*out = make(map[string]InstanceProcess, len(*in))
@@ -6,9 +6,11 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
-	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	"github.com/longhorn/longhorn-manager/datastore"
 	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/util"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
 )
 
 func (m *VolumeManager) GetInstanceManager(name string) (*longhorn.InstanceManager, error) {
@@ -19,6 +21,10 @@ func (m *VolumeManager) ListInstanceManagers() (map[string]*longhorn.InstanceMan
 	return m.ds.ListInstanceManagers()
 }
 
+func (m *VolumeManager) ListAllInstanceProcesses() ([]datastore.InstanceProcessInfo, error) {
+	return m.ds.ListAllInstanceProcesses()
+}
+
 func (m *VolumeManager) GetNode(name string) (*longhorn.Node, error) {
 	return m.ds.GetNode(name)
 }
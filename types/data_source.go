@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -134,3 +135,20 @@ func decodeDataSourceTypeSnapshot(vds longhorn.VolumeDataSource) (volumeName, sn
 	}
 	return split[0], split[1]
 }
+
+// ValidateBackingImageDownloadParameters validates the parameters to download a backing image file. The URL is required
+// and should be an HTTP(S) URL, so that an invalid URL fails fast rather than as an opaque download failure.
+func ValidateBackingImageDownloadParameters(parameters map[string]string) error {
+	downloadURL := parameters[longhorn.DataSourceTypeDownloadParameterURL]
+	if downloadURL == "" {
+		return fmt.Errorf("missing parameter %v for source type %v", longhorn.DataSourceTypeDownloadParameterURL, longhorn.BackingImageDataSourceTypeDownload)
+	}
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid parameter %v %v", longhorn.DataSourceTypeDownloadParameterURL, downloadURL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid parameter %v %v, it should be an HTTP or HTTPS URL", longhorn.DataSourceTypeDownloadParameterURL, downloadURL)
+	}
+	return nil
+}
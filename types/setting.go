@@ -2,7 +2,9 @@ package types
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -17,6 +19,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/longhorn/longhorn-manager/meta"
 
@@ -122,6 +125,32 @@ const (
 	SettingNameAllowEmptyNodeSelectorVolume                             = SettingName("allow-empty-node-selector-volume")
 	SettingNameAllowEmptyDiskSelectorVolume                             = SettingName("allow-empty-disk-selector-volume")
 	SettingNameDisableSnapshotPurge                                     = SettingName("disable-snapshot-purge")
+	SettingNameInstanceManagerPollInterval                              = SettingName("instance-manager-poll-interval")
+	SettingNameInstanceManagerPodLivenessProbe                          = SettingName("instance-manager-pod-liveness-probe")
+	SettingNameInstanceManagerStartingTimeout                           = SettingName("instance-manager-starting-timeout")
+	SettingNameInstanceManagerPodLabels                                 = SettingName("instance-manager-pod-labels")
+	SettingNameInstanceManagerPodAnnotations                            = SettingName("instance-manager-pod-annotations")
+	SettingNameInstanceManagerPodCreationDryRun                         = SettingName("instance-manager-pod-creation-dry-run")
+	SettingNameInstanceManagerHostNetwork                               = SettingName("instance-manager-host-network")
+	SettingNameInstanceManagerPodExtraHostPathMounts                    = SettingName("instance-manager-pod-extra-host-path-mounts")
+	SettingNameInstanceManagerPodEventRequeueJitter                     = SettingName("instance-manager-pod-event-requeue-jitter")
+	SettingNameConcurrentInstanceManagerPodCreationPerNodeLimit         = SettingName("concurrent-instance-manager-pod-creation-per-node-limit")
+	SettingNameInstanceManagerPodDNSConfig                              = SettingName("instance-manager-pod-dns-config")
+	SettingNameInstanceManagerForceCleanupTimeout                       = SettingName("instance-manager-force-cleanup-timeout")
+	SettingNameGuaranteedInstanceManagerCPUMaxDiskScale                 = SettingName("guaranteed-instance-manager-cpu-max-disk-scale")
+	SettingNameInstanceManagerPodCapabilities                           = SettingName("instance-manager-pod-capabilities")
+	SettingNameInstanceManagerPodTerminationGracePeriod                 = SettingName("instance-manager-pod-termination-grace-period")
+	SettingNameInstanceManagerPodForceDeletionOnError                   = SettingName("instance-manager-pod-force-deletion-on-error")
+	SettingNameInstanceManagerPodTopologySpreadConstraints              = SettingName("instance-manager-pod-topology-spread-constraints")
+	SettingNameInstanceManagerPodStartupProbe                           = SettingName("instance-manager-pod-startup-probe")
+	SettingNameInstanceManagerWatchEstablishmentMaxBackoff              = SettingName("instance-manager-watch-establishment-max-backoff")
+	SettingNameInstanceManagerWatchReceiveMaxBackoff                    = SettingName("instance-manager-watch-receive-max-backoff")
+	SettingNameInstanceManagerExtraArgs                                 = SettingName("instance-manager-extra-args")
+	SettingNameInstanceManagerGRPCTLSRequired                           = SettingName("instance-manager-grpc-tls-required")
+	SettingNameInstanceManagerFailedPodRetentionPeriod                  = SettingName("instance-manager-failed-pod-retention-period")
+	SettingNameInstanceManagerControllerWorkers                         = SettingName("instance-manager-controller-workers")
+	SettingNameInstanceManagerImageNodeOverrides                        = SettingName("instance-manager-image-node-overrides")
+	SettingNameInstanceManagerPodHostMountPath                          = SettingName("instance-manager-pod-host-mount-path")
 	SettingNameV1DataEngine                                             = SettingName("v1-data-engine")
 	SettingNameV2DataEngine                                             = SettingName("v2-data-engine")
 	SettingNameV2DataEngineHugepageLimit                                = SettingName("v2-data-engine-hugepage-limit")
@@ -212,9 +241,55 @@ var (
 		SettingNameAllowEmptyNodeSelectorVolume,
 		SettingNameAllowEmptyDiskSelectorVolume,
 		SettingNameDisableSnapshotPurge,
+		SettingNameInstanceManagerPollInterval,
+		SettingNameInstanceManagerPodLivenessProbe,
+		SettingNameInstanceManagerStartingTimeout,
+		SettingNameInstanceManagerPodLabels,
+		SettingNameInstanceManagerPodAnnotations,
+		SettingNameInstanceManagerPodCreationDryRun,
+		SettingNameInstanceManagerHostNetwork,
+		SettingNameInstanceManagerPodExtraHostPathMounts,
+		SettingNameInstanceManagerPodEventRequeueJitter,
+		SettingNameConcurrentInstanceManagerPodCreationPerNodeLimit,
+		SettingNameInstanceManagerPodDNSConfig,
+		SettingNameInstanceManagerForceCleanupTimeout,
+		SettingNameGuaranteedInstanceManagerCPUMaxDiskScale,
+		SettingNameInstanceManagerPodCapabilities,
+		SettingNameInstanceManagerPodTerminationGracePeriod,
+		SettingNameInstanceManagerPodForceDeletionOnError,
+		SettingNameInstanceManagerPodTopologySpreadConstraints,
+		SettingNameInstanceManagerPodStartupProbe,
+		SettingNameInstanceManagerWatchEstablishmentMaxBackoff,
+		SettingNameInstanceManagerWatchReceiveMaxBackoff,
+		SettingNameInstanceManagerExtraArgs,
+		SettingNameInstanceManagerGRPCTLSRequired,
+		SettingNameInstanceManagerFailedPodRetentionPeriod,
+		SettingNameInstanceManagerControllerWorkers,
+		SettingNameInstanceManagerImageNodeOverrides,
+		SettingNameInstanceManagerPodHostMountPath,
 	}
 )
 
+const (
+	ProbeSettingKeyInitialDelaySeconds = "initial-delay-seconds"
+	ProbeSettingKeyPeriodSeconds       = "period-seconds"
+	ProbeSettingKeyTimeoutSeconds      = "timeout-seconds"
+	ProbeSettingKeyFailureThreshold    = "failure-threshold"
+)
+
+const (
+	DNSConfigSettingKeyPolicy      = "policy"
+	DNSConfigSettingKeyNameservers = "nameservers"
+	DNSConfigSettingKeySearches    = "searches"
+	DNSConfigSettingKeyOptions     = "options"
+
+	// The limits are enforced by the Kubernetes pod validation
+	maxDNSConfigNameservers = 3
+	maxDNSConfigSearches    = 32
+)
+
+var podCapabilityRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
 type SettingCategory string
 
 const (
@@ -324,6 +399,32 @@ var (
 		SettingNameAllowEmptyNodeSelectorVolume:                             SettingDefinitionAllowEmptyNodeSelectorVolume,
 		SettingNameAllowEmptyDiskSelectorVolume:                             SettingDefinitionAllowEmptyDiskSelectorVolume,
 		SettingNameDisableSnapshotPurge:                                     SettingDefinitionDisableSnapshotPurge,
+		SettingNameInstanceManagerPollInterval:                              SettingDefinitionInstanceManagerPollInterval,
+		SettingNameInstanceManagerPodLivenessProbe:                          SettingDefinitionInstanceManagerPodLivenessProbe,
+		SettingNameInstanceManagerStartingTimeout:                           SettingDefinitionInstanceManagerStartingTimeout,
+		SettingNameInstanceManagerPodLabels:                                 SettingDefinitionInstanceManagerPodLabels,
+		SettingNameInstanceManagerPodAnnotations:                            SettingDefinitionInstanceManagerPodAnnotations,
+		SettingNameInstanceManagerPodCreationDryRun:                         SettingDefinitionInstanceManagerPodCreationDryRun,
+		SettingNameInstanceManagerHostNetwork:                               SettingDefinitionInstanceManagerHostNetwork,
+		SettingNameInstanceManagerPodExtraHostPathMounts:                    SettingDefinitionInstanceManagerPodExtraHostPathMounts,
+		SettingNameInstanceManagerPodEventRequeueJitter:                     SettingDefinitionInstanceManagerPodEventRequeueJitter,
+		SettingNameConcurrentInstanceManagerPodCreationPerNodeLimit:         SettingDefinitionConcurrentInstanceManagerPodCreationPerNodeLimit,
+		SettingNameInstanceManagerPodDNSConfig:                              SettingDefinitionInstanceManagerPodDNSConfig,
+		SettingNameInstanceManagerForceCleanupTimeout:                       SettingDefinitionInstanceManagerForceCleanupTimeout,
+		SettingNameGuaranteedInstanceManagerCPUMaxDiskScale:                 SettingDefinitionGuaranteedInstanceManagerCPUMaxDiskScale,
+		SettingNameInstanceManagerPodCapabilities:                           SettingDefinitionInstanceManagerPodCapabilities,
+		SettingNameInstanceManagerPodTerminationGracePeriod:                 SettingDefinitionInstanceManagerPodTerminationGracePeriod,
+		SettingNameInstanceManagerPodForceDeletionOnError:                   SettingDefinitionInstanceManagerPodForceDeletionOnError,
+		SettingNameInstanceManagerPodTopologySpreadConstraints:              SettingDefinitionInstanceManagerPodTopologySpreadConstraints,
+		SettingNameInstanceManagerPodStartupProbe:                           SettingDefinitionInstanceManagerPodStartupProbe,
+		SettingNameInstanceManagerWatchEstablishmentMaxBackoff:              SettingDefinitionInstanceManagerWatchEstablishmentMaxBackoff,
+		SettingNameInstanceManagerWatchReceiveMaxBackoff:                    SettingDefinitionInstanceManagerWatchReceiveMaxBackoff,
+		SettingNameInstanceManagerExtraArgs:                                 SettingDefinitionInstanceManagerExtraArgs,
+		SettingNameInstanceManagerGRPCTLSRequired:                           SettingDefinitionInstanceManagerGRPCTLSRequired,
+		SettingNameInstanceManagerFailedPodRetentionPeriod:                  SettingDefinitionInstanceManagerFailedPodRetentionPeriod,
+		SettingNameInstanceManagerControllerWorkers:                         SettingDefinitionInstanceManagerControllerWorkers,
+		SettingNameInstanceManagerImageNodeOverrides:                        SettingDefinitionInstanceManagerImageNodeOverrides,
+		SettingNameInstanceManagerPodHostMountPath:                          SettingDefinitionInstanceManagerPodHostMountPath,
 	}
 
 	SettingDefinitionBackupTarget = SettingDefinition{
@@ -783,7 +884,8 @@ var (
 		Description: "The name of the Priority Class to set on the Longhorn components. This can help prevent Longhorn components from being evicted under Node Pressure. \n" +
 			"Longhorn system contains user deployed components (e.g, Longhorn manager, Longhorn driver, Longhorn UI) and system managed components (e.g, instance manager, engine image, CSI driver, etc.) " +
 			"Note that this setting only sets Priority Class for system managed components. " +
-			"Depending on how you deployed Longhorn, you need to set Priority Class for user deployed components in Helm chart or deployment YAML file. \n",
+			"Depending on how you deployed Longhorn, you need to set Priority Class for user deployed components in Helm chart or deployment YAML file. \n" +
+			"Since instance manager pods run the volume engines and replicas, the built-in `system-node-critical` Priority Class can be used to keep them from being evicted before less critical workloads. \n",
 		Category: SettingCategoryDangerZone,
 		Required: false,
 		ReadOnly: false,
@@ -1333,6 +1435,372 @@ var (
 		Default:     "false",
 	}
 
+	SettingDefinitionInstanceManagerPollInterval = SettingDefinition{
+		DisplayName: "Instance Manager Poll Interval",
+		Description: "In seconds. The minimum interval between two successive polls of the instances in an instance manager when there is no instance change notification. " +
+			"The instance changes notified by the instance manager are still polled immediately. Increasing this value reduces the load on the instance managers in a large cluster.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "60",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 1,
+		},
+	}
+
+	SettingDefinitionInstanceManagerPodLivenessProbe = SettingDefinition{
+		DisplayName: "Instance Manager Pod Liveness Probe",
+		Description: "Customize the liveness probe of instance manager pods, which is helpful when instance managers on slow nodes need a longer time to become healthy. " +
+			"Multiple key-value pairs are separated by semicolon. The supported keys are `initial-delay-seconds`, `period-seconds`, `timeout-seconds` and `failure-threshold`. " +
+			"The keys not specified use the default values. For example: \n\n" +
+			"* `initial-delay-seconds:10; failure-threshold:60` \n\n" +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerStartingTimeout = SettingDefinition{
+		DisplayName: "Instance Manager Starting Timeout",
+		Description: "In seconds. The maximum time an instance manager can stay in the starting state, e.g., when the instance manager pod is pending since the resource requests can never be satisfied. " +
+			"Once timed out, the instance manager is marked as error and its pod is recreated. 0 means no timeout.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+		},
+	}
+
+	SettingDefinitionInstanceManagerPodLabels = SettingDefinition{
+		DisplayName: "Instance Manager Pod Labels",
+		Description: "The extra labels added to instance manager pods, e.g., for the monitoring and network policy tools. " +
+			"Multiple labels are separated by semicolon. For example: \n\n" +
+			"* `cost-center:storage; team:infra` \n\n" +
+			"The keys with the longhorn.io prefix are reserved, and the labels set by Longhorn are never overwritten. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerPodAnnotations = SettingDefinition{
+		DisplayName: "Instance Manager Pod Annotations",
+		Description: "The extra annotations added to instance manager pods. " +
+			"Multiple annotations are separated by semicolon, and each annotation is split into the key and the value by the first colon. For example: \n\n" +
+			"* `example.com/owner:storage-team; example.com/dashboard:https://dashboard.example.com` \n\n" +
+			"The keys with the longhorn.io prefix are reserved, and the annotations set by Longhorn are never overwritten. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerPodCreationDryRun = SettingDefinition{
+		DisplayName: "Instance Manager Pod Creation Dry Run",
+		Description: "For debugging the scheduling of instance manager pods. " +
+			"If enabled, Longhorn logs the pod YAML of the instance manager instead of creating the pod, so the tolerations, node selector, resources, and other settings applied to the pod can be validated. " +
+			"WARNING: The instance managers cannot start in dry-run mode. Do not enable it unless debugging.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionInstanceManagerHostNetwork = SettingDefinition{
+		DisplayName: "Instance Manager Host Network",
+		Description: "If enabled, the instance manager pods use the host network of the nodes instead of the pod network, which reduces the CNI overhead of the replica traffic. " +
+			"The instance managers are then reached through the node IPs, and the ports used by the instance managers must be available on the nodes. " +
+			"The setting cannot be enabled together with the V2 Data Engine setting, since the instance managers of both data engines would bind the same host ports. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionInstanceManagerPodExtraHostPathMounts = SettingDefinition{
+		DisplayName: "Instance Manager Pod Extra Host Path Mounts",
+		Description: "The extra host paths mounted into the instance manager containers, for example the devices not under /dev on some distros. " +
+			"Multiple mounts are separated by semicolon, and each mount is split into the host path and the mount path in the container by colon. For example: \n\n" +
+			"* `/opt/devices:/host/opt/devices; /var/run/custom:/var/run/custom` \n\n" +
+			"Both paths must be absolute, and the mount paths cannot be duplicated. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerPodEventRequeueJitter = SettingDefinition{
+		DisplayName: "Instance Manager Pod Event Requeue Jitter",
+		Description: "In milliseconds. The maximum random delay of syncing an instance manager after its pod changes. " +
+			"After a node-wide event, e.g., a node becomes ready again, the jitter spreads out the syncs of the instance managers on the node rather than reaching all of them at once. " +
+			"Set to 0 to sync the instance managers without the jitter.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "1000",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+			ValueIntRangeMaximum: 60000,
+		},
+	}
+
+	SettingDefinitionConcurrentInstanceManagerPodCreationPerNodeLimit = SettingDefinition{
+		DisplayName: "Concurrent Instance Manager Pod Creation Per Node Limit",
+		Description: "This setting controls how many instance manager pods on a node can be starting simultaneously. " +
+			"Once the limit is reached, the creation of the other instance manager pods on the node is deferred until some of the starting pods are running, so a mass recovery doesn't overload the kubelet. " +
+			"Set to 0 to disable the limit.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+		},
+	}
+
+	SettingDefinitionInstanceManagerPodDNSConfig = SettingDefinition{
+		DisplayName: "Instance Manager Pod DNS Config",
+		Description: "The DNS policy and config of instance manager pods, e.g., for resolving the backup target hostname with the split-horizon DNS. " +
+			"Multiple key-value pairs are separated by semicolon, and multiple values of a key are separated by comma. The supported keys are `policy`, `nameservers`, `searches` and `options`. For example: \n\n" +
+			"* `policy:None; nameservers:10.0.0.10,10.0.0.11; searches:corp.example.com; options:ndots=2,edns0` \n\n" +
+			"At most 3 nameservers are allowed, and at least one is required by the policy `None`. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerForceCleanupTimeout = SettingDefinition{
+		DisplayName: "Instance Manager Force Cleanup Timeout",
+		Description: "In seconds. The timeout for cleaning up a deleting instance manager. " +
+			"Once the timeout is exceeded and the instance manager pod still cannot be deleted or is stuck terminating, e.g., the node is gone, Longhorn removes the finalizer of the instance manager so that it no longer blocks the deletion of the namespace. " +
+			"The pod left behind is cleaned up by the Kubernetes garbage collector. 0 means the instance manager is never cleaned up forcibly.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "600",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+		},
+	}
+
+	SettingDefinitionGuaranteedInstanceManagerCPUMaxDiskScale = SettingDefinition{
+		DisplayName: "Guaranteed Instance Manager CPU Max Disk Scale for V1 Data Engine",
+		Description: "The maximum factor of scaling the guaranteed CPU of each v1 instance manager pod hosting replicas by the number of filesystem disks on the node, since the nodes with many disks host more replicas. " +
+			"For example, if the setting Guaranteed Instance Manager CPU is 12 and this setting is 3, the instance manager pod on a node with 2 disks requests 24% of the allocatable CPU, and the one on a node with 5 disks requests 36%. \n\n" +
+			"WARNING: \n\n" +
+			"  - Value 1 means the guaranteed CPU is not scaled. \n\n" +
+			"  - The scaled CPU request never exceeds the allocatable CPU of the node. \n\n" +
+			"  - This global setting will be ignored for a node if the field \"InstanceManagerCPURequest\" on the node is set. \n\n" +
+			"  - After this setting is changed, the v1 instance manager pod using this global setting will be automatically restarted without instances running on the v1 instance manager. \n\n",
+		Category: SettingCategoryDangerZone,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "1",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 1,
+			ValueIntRangeMaximum: 10,
+		},
+	}
+
+	SettingDefinitionInstanceManagerPodCapabilities = SettingDefinition{
+		DisplayName: "Instance Manager Pod Capabilities",
+		Description: "The Linux capabilities granted to the v1 data engine instance manager pods instead of running them in the privileged mode, separated by comma. For example: \n\n" +
+			"* `SYS_ADMIN,MKNOD` \n\n" +
+			"Empty means the instance manager pods run in the privileged mode, which is the default. " +
+			"The v2 data engine instance manager pods always run in the privileged mode. " +
+			"The setting is applied to the newly created instance manager pods only. \n\n" +
+			"WARNING: The instance managers fail to operate the volumes if any of the required capabilities is missing.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerPodTerminationGracePeriod = SettingDefinition{
+		DisplayName: "Instance Manager Pod Termination Grace Period",
+		Description: "In seconds. The termination grace period of the instance manager pods, which gives the instance managers time to flush data and stop the instances before they are killed. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "30",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+		},
+	}
+
+	SettingDefinitionInstanceManagerPodForceDeletionOnError = SettingDefinition{
+		DisplayName: "Instance Manager Pod Force Deletion on Error",
+		Description: "If enabled, Longhorn deletes the pod of the instance manager in error state without the termination grace period, so that the instance manager recovers faster. " +
+			"WARNING: The instances in the pod are killed without stopping gracefully.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionInstanceManagerPodTopologySpreadConstraints = SettingDefinition{
+		DisplayName: "Instance Manager Pod Topology Spread Constraints",
+		Description: "The topology spread constraints of instance manager pods, which spread the pods of the same instance manager type across the failure domains. " +
+			"Multiple constraints are separated by semicolon, and each constraint is in the format `topologyKey:maxSkew:whenUnsatisfiable`. For example: \n\n" +
+			"* `topology.kubernetes.io/zone:1:ScheduleAnyway; kubernetes.io/hostname:1:DoNotSchedule` \n\n" +
+			"The supported values of whenUnsatisfiable are `DoNotSchedule` and `ScheduleAnyway`. " +
+			"Since the instance manager pods are bound to their nodes, the constraints take effect only when multiple instance managers of the same type can run on a node. " +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerPodStartupProbe = SettingDefinition{
+		DisplayName: "Instance Manager Pod Startup Probe",
+		Description: "Customize the startup probe of instance manager pods. The liveness probe is suspended until the startup probe succeeds, " +
+			"so the instance managers on slow nodes have a long time to start without loosening the liveness probe. " +
+			"Multiple key-value pairs are separated by semicolon. The supported keys are `initial-delay-seconds`, `period-seconds`, `timeout-seconds` and `failure-threshold`. " +
+			"The keys not specified use the default values, which allow the instance manager 5 minutes to start. For example: \n\n" +
+			"* `period-seconds:10; failure-threshold:90` \n\n" +
+			"The setting is applied to the newly created instance manager pods only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerWatchEstablishmentMaxBackoff = SettingDefinition{
+		DisplayName: "Instance Manager Watch Establishment Max Backoff",
+		Description: "In seconds. The maximum delay between two successive retries of establishing the instance watch to an instance manager. " +
+			"The delay doubles after each failure, so an instance manager that is down is not connected to at a high rate. " +
+			"The setting is applied to the newly started instance manager monitors only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "120",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 1,
+		},
+	}
+
+	SettingDefinitionInstanceManagerWatchReceiveMaxBackoff = SettingDefinition{
+		DisplayName: "Instance Manager Watch Receive Max Backoff",
+		Description: "In seconds. The maximum delay between two successive retries of receiving items from an established instance watch after the failures in the middle of the stream. " +
+			"The delay doubles after each failure and is reset once an item is received. " +
+			"The setting is applied to the newly started instance manager monitors only.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "30",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 1,
+		},
+	}
+
+	SettingDefinitionInstanceManagerExtraArgs = SettingDefinition{
+		DisplayName: "Instance Manager Extra Arguments",
+		Description: "The extra arguments appended to the daemon command of instance managers for debugging, separated by whitespace. For example: \n\n" +
+			"* `--log-format json` \n\n" +
+			"The `--listen` argument is managed by Longhorn and cannot be overridden. " +
+			"The setting is applied to the newly created instance manager pods only. \n\n" +
+			"WARNING: The instance managers fail to start if any of the arguments is not supported.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerGRPCTLSRequired = SettingDefinition{
+		DisplayName: "Instance Manager gRPC TLS Required",
+		Description: "Require the TLS connection between Longhorn managers and instance managers. " +
+			"The instance managers serve TLS, and Longhorn managers connect with the mutual TLS, once the `longhorn-grpc-tls` secret with `ca.crt`, `tls.crt` and `tls.key` is provided. " +
+			"By default, the connection falls back to plaintext if the TLS connection cannot be established. " +
+			"If this setting is enabled, the connection fails instead of falling back to plaintext.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeBool,
+		Required: true,
+		ReadOnly: false,
+		Default:  "false",
+	}
+
+	SettingDefinitionInstanceManagerFailedPodRetentionPeriod = SettingDefinition{
+		DisplayName: "Instance Manager Failed Pod Retention Period",
+		Description: "In seconds. The period to retain the pod of an instance manager in error state for the post-mortem, e.g., `kubectl logs`, before it is deleted and recreated. " +
+			"The retained pods are labeled with `longhorn.io/post-mortem-retained: true`. " +
+			"The instance manager stays in error state until the period passes, so the instances on the node are unavailable meanwhile. " +
+			"0 means the pod is deleted immediately, which is the default.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+		},
+	}
+
+	SettingDefinitionInstanceManagerControllerWorkers = SettingDefinition{
+		DisplayName: "Instance Manager Controller Workers",
+		Description: "The number of the workers syncing the instance managers in each Longhorn manager. " +
+			"The setting is re-read periodically, so the workers are scaled up or down without restarting Longhorn manager. " +
+			"0 means the default number of the controller workers.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeInt,
+		Required: true,
+		ReadOnly: false,
+		Default:  "0",
+		ValueIntRange: map[string]int{
+			ValueIntRangeMinimum: 0,
+			ValueIntRangeMaximum: 100,
+		},
+	}
+
+	SettingDefinitionInstanceManagerImageNodeOverrides = SettingDefinition{
+		DisplayName: "Instance Manager Image Node Overrides",
+		Description: "The instance manager images overridden for the nodes with the matching Kubernetes node labels, e.g., in the clusters with mixed architectures. " +
+			"The overrides are in the format `<label key>=<label value>:<image>` separated by semicolons, and the first matching override is used. For example: \n\n" +
+			"* `kubernetes.io/arch=arm64:example.com/longhornio/longhorn-instance-manager:v1.6.0-arm64` \n\n" +
+			"The instance manager image is used for the nodes without any matching override. " +
+			"The instance manager pods are recreated with the overridden images once no instance is running in them.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: false,
+		ReadOnly: false,
+	}
+
+	SettingDefinitionInstanceManagerPodHostMountPath = SettingDefinition{
+		DisplayName: "Instance Manager Pod Host Mount Path",
+		Description: "The host path mounted into the instance manager containers under `/host`, which keeps the same path in the containers, e.g., `/var/lib/longhorn` is mounted at `/host/var/lib/longhorn`. " +
+			"The default `/` mounts the entire root filesystem of the host at `/host`. " +
+			"The host `/proc` and `/dev`, which the engines require, are always mounted at `/host/proc` and `/host/dev`. " +
+			"The path must be absolute and cannot be within `/proc` or `/dev`. The setting is applied to the newly created instance manager pods only. \n\n" +
+			"WARNING: The replicas on the disks outside of the path cannot be started.",
+		Category: SettingCategoryGeneral,
+		Type:     SettingTypeString,
+		Required: true,
+		ReadOnly: false,
+		Default:  "/",
+	}
+
 	SettingDefinitionV2DataEngineLogLevel = SettingDefinition{
 		DisplayName: "V2 Data Engine Log Level",
 		Description: "The log level used in SPDK target daemon (spdk_tgt) of V2 Data Engine. Supported values are: Disabled, Error, Warn, Notice, Info and Debug. By default Notice.",
@@ -1583,6 +2051,369 @@ func UnmarshalNodeSelector(nodeSelectorSetting string) (map[string]string, error
 	return nodeSelector, nil
 }
 
+// UnmarshalProbeSetting parses the probe setting in the format `key1:value1; key2:value2`.
+// The values should be positive integers.
+func UnmarshalProbeSetting(probeSetting string) (map[string]int32, error) {
+	probe := map[string]int32{}
+
+	probeSetting = strings.Trim(probeSetting, " ")
+	if probeSetting == "" {
+		return probe, nil
+	}
+
+	for _, pair := range strings.Split(probeSetting, ";") {
+		key, value, err := validateAndUnmarshalLabel(pair)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while unmarshal probe setting")
+		}
+		switch key {
+		case ProbeSettingKeyInitialDelaySeconds, ProbeSettingKeyPeriodSeconds, ProbeSettingKeyTimeoutSeconds, ProbeSettingKeyFailureThreshold:
+		default:
+			return nil, fmt.Errorf("unsupported probe setting key %v", key)
+		}
+		intValue, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "value %v of probe setting key %v is not a number", value, key)
+		}
+		if intValue <= 0 {
+			return nil, fmt.Errorf("value %v of probe setting key %v should be larger than 0", value, key)
+		}
+		probe[key] = int32(intValue)
+	}
+	return probe, nil
+}
+
+// UnmarshalPodLabels parses the pod labels in the format `key1:value1; key2:value2`.
+func UnmarshalPodLabels(labelSetting string) (map[string]string, error) {
+	labels, err := unmarshalPodMetadata(labelSetting)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while unmarshal pod labels")
+	}
+	for key, value := range labels {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value %v of label %v: %v", value, key, strings.Join(errs, ", "))
+		}
+	}
+	return labels, nil
+}
+
+// UnmarshalPodAnnotations parses the pod annotations in the format `key1:value1; key2:value2`.
+// The values can contain colons.
+func UnmarshalPodAnnotations(annotationSetting string) (map[string]string, error) {
+	annotations, err := unmarshalPodMetadata(annotationSetting)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while unmarshal pod annotations")
+	}
+	return annotations, nil
+}
+
+func unmarshalPodMetadata(metadataSetting string) (map[string]string, error) {
+	metadata := map[string]string{}
+
+	metadataSetting = strings.Trim(metadataSetting, " ")
+	if metadataSetting == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(metadataSetting, ";") {
+		// The qualified names cannot contain colons, hence the first colon is the separator.
+		parts := strings.SplitN(strings.Trim(pair, " "), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid pair %v: should contain the separator ':'", pair)
+		}
+		key, value := strings.Trim(parts[0], " "), strings.Trim(parts[1], " ")
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid key %v: %v", key, strings.Join(errs, ", "))
+		}
+		if prefix, _, found := strings.Cut(key, "/"); found && (prefix == LonghornLabelKeyPrefix || strings.HasSuffix(prefix, "."+LonghornLabelKeyPrefix)) {
+			return nil, fmt.Errorf("key %v is reserved by Longhorn", key)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// HostPathMount is a host path mounted into a container
+type HostPathMount struct {
+	HostPath  string
+	MountPath string
+}
+
+// UnmarshalHostPathMounts parses the host path mounts in the format `hostPath1:mountPath1; hostPath2:mountPath2`.
+func UnmarshalHostPathMounts(mountSetting string) ([]HostPathMount, error) {
+	mounts := []HostPathMount{}
+
+	mountSetting = strings.Trim(mountSetting, " ")
+	if mountSetting == "" {
+		return mounts, nil
+	}
+
+	mountPaths := map[string]struct{}{}
+	for _, pair := range strings.Split(mountSetting, ";") {
+		parts := strings.Split(strings.Trim(pair, " "), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid mount %v: should be in the format hostPath:mountPath", pair)
+		}
+		hostPath, mountPath := strings.Trim(parts[0], " "), strings.Trim(parts[1], " ")
+		for _, path := range []string{hostPath, mountPath} {
+			if !filepath.IsAbs(path) {
+				return nil, fmt.Errorf("invalid mount %v: path %v is not absolute", pair, path)
+			}
+		}
+		mountPath = filepath.Clean(mountPath)
+		if _, exists := mountPaths[mountPath]; exists {
+			return nil, fmt.Errorf("invalid mount %v: mount path %v is duplicated", pair, mountPath)
+		}
+		mountPaths[mountPath] = struct{}{}
+		mounts = append(mounts, HostPathMount{
+			HostPath:  filepath.Clean(hostPath),
+			MountPath: mountPath,
+		})
+	}
+	return mounts, nil
+}
+
+// UnmarshalPodDNSConfig parses the pod DNS policy and config in the format
+// `policy:None; nameservers:10.0.0.10,10.0.0.11; searches:corp.example.com; options:ndots=2,edns0`.
+// The policy is empty if not specified, and the config is nil if none of the other keys are specified.
+func UnmarshalPodDNSConfig(dnsSetting string) (corev1.DNSPolicy, *corev1.PodDNSConfig, error) {
+	dnsSetting = strings.Trim(dnsSetting, " ")
+	if dnsSetting == "" {
+		return "", nil, nil
+	}
+
+	var policy corev1.DNSPolicy
+	var config *corev1.PodDNSConfig
+	for _, pair := range strings.Split(dnsSetting, ";") {
+		// The IPv6 nameservers contain colons, so the key is split by the first colon only
+		parts := strings.SplitN(strings.Trim(pair, " "), ":", 2)
+		if len(parts) != 2 {
+			return "", nil, fmt.Errorf("invalid DNS config %v: should be in the format key:value", pair)
+		}
+		key, value := strings.Trim(parts[0], " "), strings.Trim(parts[1], " ")
+		if value == "" {
+			return "", nil, fmt.Errorf("invalid DNS config %v: the value is empty", pair)
+		}
+
+		if key == DNSConfigSettingKeyPolicy {
+			switch corev1.DNSPolicy(value) {
+			case corev1.DNSClusterFirstWithHostNet, corev1.DNSClusterFirst, corev1.DNSDefault, corev1.DNSNone:
+				policy = corev1.DNSPolicy(value)
+			default:
+				return "", nil, fmt.Errorf("unsupported DNS policy %v", value)
+			}
+			continue
+		}
+
+		if config == nil {
+			config = &corev1.PodDNSConfig{}
+		}
+		values := []string{}
+		for _, v := range strings.Split(value, ",") {
+			values = append(values, strings.Trim(v, " "))
+		}
+		switch key {
+		case DNSConfigSettingKeyNameservers:
+			for _, nameserver := range values {
+				if net.ParseIP(nameserver) == nil {
+					return "", nil, fmt.Errorf("invalid nameserver %v: not an IP address", nameserver)
+				}
+			}
+			config.Nameservers = append(config.Nameservers, values...)
+		case DNSConfigSettingKeySearches:
+			for _, search := range values {
+				if search == "" {
+					return "", nil, fmt.Errorf("invalid DNS config %v: the search domain is empty", pair)
+				}
+			}
+			config.Searches = append(config.Searches, values...)
+		case DNSConfigSettingKeyOptions:
+			for _, option := range values {
+				name, optionValue, hasValue := strings.Cut(option, "=")
+				if name == "" {
+					return "", nil, fmt.Errorf("invalid DNS option %v: the name is empty", option)
+				}
+				dnsOption := corev1.PodDNSConfigOption{Name: name}
+				if hasValue {
+					dnsOption.Value = &optionValue
+				}
+				config.Options = append(config.Options, dnsOption)
+			}
+		default:
+			return "", nil, fmt.Errorf("unsupported DNS config key %v", key)
+		}
+	}
+
+	if config != nil {
+		if len(config.Nameservers) > maxDNSConfigNameservers {
+			return "", nil, fmt.Errorf("at most %v nameservers are allowed", maxDNSConfigNameservers)
+		}
+		if len(config.Searches) > maxDNSConfigSearches {
+			return "", nil, fmt.Errorf("at most %v search domains are allowed", maxDNSConfigSearches)
+		}
+	}
+	if policy == corev1.DNSNone && (config == nil || len(config.Nameservers) == 0) {
+		return "", nil, fmt.Errorf("at least one nameserver is required by DNS policy %v", corev1.DNSNone)
+	}
+	return policy, config, nil
+}
+
+// UnmarshalPodCapabilities parses the Linux capabilities separated by comma, e.g., `SYS_ADMIN,MKNOD`.
+// The capabilities are named without the `CAP_` prefix as in the container security context.
+func UnmarshalPodCapabilities(capabilitiesSetting string) ([]corev1.Capability, error) {
+	capabilitiesSetting = strings.Trim(capabilitiesSetting, " ")
+	if capabilitiesSetting == "" {
+		return nil, nil
+	}
+
+	capabilities := []corev1.Capability{}
+	existing := map[string]struct{}{}
+	for _, capability := range strings.Split(capabilitiesSetting, ",") {
+		capability = strings.Trim(capability, " ")
+		if !podCapabilityRegex.MatchString(capability) {
+			return nil, fmt.Errorf("invalid capability %q: only uppercase letters, digits and underscores are allowed", capability)
+		}
+		if strings.HasPrefix(capability, "CAP_") {
+			return nil, fmt.Errorf("invalid capability %v: the CAP_ prefix is not allowed", capability)
+		}
+		if _, exists := existing[capability]; exists {
+			continue
+		}
+		existing[capability] = struct{}{}
+		capabilities = append(capabilities, corev1.Capability(capability))
+	}
+	return capabilities, nil
+}
+
+// UnmarshalInstanceManagerExtraArgs parses the extra arguments of the instance manager daemon separated by whitespace,
+// e.g., `--log-format json`. The listen address is managed by Longhorn, so the `--listen` argument is not allowed.
+func UnmarshalInstanceManagerExtraArgs(argsSetting string) ([]string, error) {
+	args := strings.Fields(argsSetting)
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if name == "listen" {
+			return nil, fmt.Errorf("invalid argument %v: the listen address cannot be overridden", arg)
+		}
+	}
+	return args, nil
+}
+
+// InstanceManagerPodRequiredHostDirectories are the host directories always mounted into the instance manager
+// containers under /host, since the engines access the host namespaces and devices through them.
+var InstanceManagerPodRequiredHostDirectories = []string{"/proc", "/dev"}
+
+// UnmarshalInstanceManagerPodHostMountPath parses the host path mounted into the instance manager containers, which
+// should be absolute. The root filesystem is mounted if the path is empty.
+func UnmarshalInstanceManagerPodHostMountPath(pathSetting string) (string, error) {
+	path := strings.Trim(pathSetting, " ")
+	if path == "" {
+		return "/", nil
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("invalid host mount path %v: should be absolute", path)
+	}
+	path = filepath.Clean(path)
+	for _, dir := range InstanceManagerPodRequiredHostDirectories {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return "", fmt.Errorf("invalid host mount path %v: cannot be within %v, which is always mounted", path, dir)
+		}
+	}
+	return path, nil
+}
+
+// InstanceManagerImageNodeOverride is the instance manager image used for the nodes with the label
+type InstanceManagerImageNodeOverride struct {
+	LabelKey   string
+	LabelValue string
+	Image      string
+}
+
+// UnmarshalInstanceManagerImageNodeOverrides parses the instance manager image overrides in the format
+// `kubernetes.io/arch=arm64:example.com/instance-manager:v1.6.0-arm64; <label key>=<label value>:<image>`.
+// The order is kept since the first matching override is used.
+func UnmarshalInstanceManagerImageNodeOverrides(overridesSetting string) ([]InstanceManagerImageNodeOverride, error) {
+	overridesSetting = strings.Trim(overridesSetting, " ")
+	if overridesSetting == "" {
+		return nil, nil
+	}
+
+	overrides := []InstanceManagerImageNodeOverride{}
+	for _, override := range strings.Split(overridesSetting, ";") {
+		override = strings.Trim(override, " ")
+		if override == "" {
+			continue
+		}
+		// The label keys and values cannot contain ':', while the image tag follows ':'
+		label, image, found := strings.Cut(override, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid override %v: should contain the separator ':'", override)
+		}
+		key, value, found := strings.Cut(label, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid override %v: the label should contain the separator '='", override)
+		}
+		key, value, image = strings.Trim(key, " "), strings.Trim(value, " "), strings.Trim(image, " ")
+		if key == "" || image == "" {
+			return nil, fmt.Errorf("invalid override %v: the label key and the image should not be empty", override)
+		}
+		overrides = append(overrides, InstanceManagerImageNodeOverride{LabelKey: key, LabelValue: value, Image: image})
+	}
+	return overrides, nil
+}
+
+// UnmarshalPodTopologySpreadConstraints parses the topology spread constraints in the format
+// `topology.kubernetes.io/zone:1:ScheduleAnyway; kubernetes.io/hostname:1:DoNotSchedule`.
+// The label selectors of the constraints are left to the caller.
+func UnmarshalPodTopologySpreadConstraints(constraintsSetting string) ([]corev1.TopologySpreadConstraint, error) {
+	constraintsSetting = strings.Trim(constraintsSetting, " ")
+	if constraintsSetting == "" {
+		return nil, nil
+	}
+
+	constraints := []corev1.TopologySpreadConstraint{}
+	existing := map[string]struct{}{}
+	for _, item := range strings.Split(constraintsSetting, ";") {
+		parts := strings.Split(strings.Trim(item, " "), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid topology spread constraint %v: should be in the format topologyKey:maxSkew:whenUnsatisfiable", item)
+		}
+		topologyKey, maxSkewValue, whenUnsatisfiable := strings.Trim(parts[0], " "), strings.Trim(parts[1], " "), strings.Trim(parts[2], " ")
+
+		if errs := validation.IsQualifiedName(topologyKey); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid topology key %v: %v", topologyKey, strings.Join(errs, ", "))
+		}
+		if _, exists := existing[topologyKey]; exists {
+			return nil, fmt.Errorf("duplicate topology key %v", topologyKey)
+		}
+		existing[topologyKey] = struct{}{}
+
+		maxSkew, err := strconv.ParseInt(maxSkewValue, 10, 32)
+		if err != nil || maxSkew < 1 {
+			return nil, fmt.Errorf("invalid max skew %v of topology key %v: should be a positive integer", maxSkewValue, topologyKey)
+		}
+
+		switch corev1.UnsatisfiableConstraintAction(whenUnsatisfiable) {
+		case corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			return nil, fmt.Errorf("unsupported whenUnsatisfiable %v of topology key %v", whenUnsatisfiable, topologyKey)
+		}
+
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			TopologyKey:       topologyKey,
+			MaxSkew:           int32(maxSkew),
+			WhenUnsatisfiable: corev1.UnsatisfiableConstraintAction(whenUnsatisfiable),
+		})
+	}
+	return constraints, nil
+}
+
 // GetSettingDefinition gets the setting definition in `settingDefinitions` by the parameter `name`
 func GetSettingDefinition(name SettingName) (SettingDefinition, bool) {
 	settingDefinitionsLock.RLock()
@@ -1678,6 +2509,46 @@ func validateString(sName SettingName, definition SettingDefinition, value strin
 		if _, err := UnmarshalNodeSelector(value); err != nil {
 			return errors.Wrapf(err, "the value of %v is invalid", sName)
 		}
+	case SettingNameInstanceManagerPodLivenessProbe, SettingNameInstanceManagerPodStartupProbe:
+		if _, err := UnmarshalProbeSetting(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodLabels:
+		if _, err := UnmarshalPodLabels(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodAnnotations:
+		if _, err := UnmarshalPodAnnotations(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodExtraHostPathMounts:
+		if _, err := UnmarshalHostPathMounts(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodDNSConfig:
+		if _, _, err := UnmarshalPodDNSConfig(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodCapabilities:
+		if _, err := UnmarshalPodCapabilities(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodTopologySpreadConstraints:
+		if _, err := UnmarshalPodTopologySpreadConstraints(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerExtraArgs:
+		if _, err := UnmarshalInstanceManagerExtraArgs(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerImageNodeOverrides:
+		if _, err := UnmarshalInstanceManagerImageNodeOverrides(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
+	case SettingNameInstanceManagerPodHostMountPath:
+		if _, err := UnmarshalInstanceManagerPodHostMountPath(value); err != nil {
+			return errors.Wrapf(err, "the value of %v is invalid", sName)
+		}
 
 	case SettingNameBackupTarget:
 		u, err := url.Parse(value)
@@ -124,6 +124,23 @@ const (
 	KubeNodeDefaultNodeTagConfigAnnotationKey = "node.longhorn.io/default-node-tags"
 
 	LastAppliedTolerationAnnotationKeySuffix = "last-applied-tolerations"
+	EngineImageDigestAnnotationKeySuffix     = "engine-image-digest"
+
+	// InstanceManagerGracefulCleanupTimeoutAnnotationKeySuffix is the annotation on the instance manager enabling the
+	// graceful cleanup. The value is the duration (e.g. "2m") to wait for the instances to stop before deleting the pod.
+	InstanceManagerGracefulCleanupTimeoutAnnotationKeySuffix = "graceful-cleanup-timeout"
+	// InstanceManagerForceDeletionAnnotationKeySuffix is the annotation on the instance manager allowing the deletion
+	// while the instances are still running in it, if the value is "true".
+	InstanceManagerForceDeletionAnnotationKeySuffix = "force-deletion"
+	// InstanceManagerDebugAnnotationKeySuffix is the annotation on the instance manager enabling the trace level logs
+	// of the instance manager regardless of the global log level, if the value is "true".
+	InstanceManagerDebugAnnotationKeySuffix = "debug"
+	// InstanceManagerPodRetainedAtAnnotationKeySuffix is the annotation on the failed instance manager pod retained for
+	// the post-mortem. The value is the time when the pod started being retained.
+	InstanceManagerPodRetainedAtAnnotationKeySuffix = "post-mortem-retained-at"
+	// InstanceManagerPausedAnnotationKeySuffix is the annotation on the instance manager pausing the reconciliation,
+	// if the value is "true". It allows the manual intervention during the node maintenance.
+	InstanceManagerPausedAnnotationKeySuffix = "paused"
 
 	ConfigMapResourceVersionKey = "configmap-resource-version"
 	UpdateSettingFromLonghorn   = "update-setting-from-longhorn"
@@ -147,6 +164,7 @@ const (
 	LonghornLabelDiskUUID                   = "disk-uuid"
 	LonghornLabelInstanceManagerType        = "instance-manager-type"
 	LonghornLabelInstanceManagerImage       = "instance-manager-image"
+	LonghornLabelInstanceManagerIndex       = "instance-manager-index"
 	LonghornLabelVolume                     = "longhornvolume"
 	LonghornLabelShareManager               = "share-manager"
 	LonghornLabelShareManagerImage          = "share-manager-image"
@@ -174,6 +192,7 @@ const (
 	LonghornLabelLastSystemRestoreBackup    = "last-system-restored-backup"
 	LonghornLabelDataEngine                 = "data-engine"
 	LonghornLabelVersion                    = "version"
+	LonghornLabelPostMortemRetained         = "post-mortem-retained"
 
 	LonghornLabelValueEnabled = "enabled"
 	LonghornLabelValueIgnored = "ignored"
@@ -214,6 +233,7 @@ const (
 const (
 	EnvNodeName       = "NODE_NAME"
 	EnvPodNamespace   = "POD_NAMESPACE"
+	EnvPodName        = "POD_NAME"
 	EnvPodIP          = "POD_IP"
 	EnvServiceAccount = "SERVICE_ACCOUNT"
 
@@ -663,8 +683,14 @@ func ValidateEngineImageChecksumName(name string) bool {
 	return matched
 }
 
-func GetInstanceManagerName(imType longhorn.InstanceManagerType, nodeName, image, dataEngine string) (string, error) {
-	hashedSuffix := util.GetStringChecksum(nodeName + image + dataEngine)[:InstanceManagerSuffixChecksumLength]
+// GetInstanceManagerName returns the name of the instance manager with the given index on the node.
+// Index 0 keeps the name of the default instance manager.
+func GetInstanceManagerName(imType longhorn.InstanceManagerType, nodeName, image, dataEngine string, index int) (string, error) {
+	key := nodeName + image + dataEngine
+	if index > 0 {
+		key += strconv.Itoa(index)
+	}
+	hashedSuffix := util.GetStringChecksum(key)[:InstanceManagerSuffixChecksumLength]
 	switch imType {
 	case longhorn.InstanceManagerTypeEngine:
 		return engineManagerPrefix + hashedSuffix, nil
@@ -1106,6 +1132,22 @@ func ConsolidateInstances(instancesMaps ...map[string]longhorn.InstanceProcess)
 	return consolidated
 }
 
+// GetRunningInstanceNames returns the sorted names of the running or starting instances of the running instance
+// manager. The instances of the instance manager in other states are gone or going to be gone along with the pod.
+func GetRunningInstanceNames(im *longhorn.InstanceManager) []string {
+	runningInstances := []string{}
+	if im.Status.CurrentState != longhorn.InstanceManagerStateRunning {
+		return runningInstances
+	}
+	for name, instance := range ConsolidateInstances(im.Status.InstanceEngines, im.Status.InstanceReplicas, im.Status.Instances) {
+		if instance.Status.State == longhorn.InstanceStateRunning || instance.Status.State == longhorn.InstanceStateStarting {
+			runningInstances = append(runningInstances, name)
+		}
+	}
+	sort.Strings(runningInstances)
+	return runningInstances
+}
+
 func ConsolidateInstanceManagers(instanceManagerMaps ...map[string]*longhorn.InstanceManager) map[string]*longhorn.InstanceManager {
 	consolidated := make(map[string]*longhorn.InstanceManager)
 	for _, instanceManagers := range instanceManagerMaps {
@@ -3,12 +3,16 @@ package types
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 
 	corev1 "k8s.io/api/core/v1"
 
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	"github.com/longhorn/longhorn-manager/util"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -233,3 +237,557 @@ func (s *TestSuite) TestGenerateEngineNameForVolume(c *C) {
 		c.Assert(actual, Equals, testCase.expectedEngineName, Commentf(TestErrResultFmt, testName))
 	}
 }
+
+func (s *TestSuite) TestGetInstanceManagerName(c *C) {
+	name, err := GetInstanceManagerName(longhorn.InstanceManagerTypeAllInOne, "node-1", "longhornio/longhorn-instance-manager:test", "v1", 0)
+	c.Assert(err, IsNil)
+	// Index 0 keeps the name of the default instance manager
+	c.Assert(name, Equals, instanceManagerPrefix+util.GetStringChecksum("node-1" + "longhornio/longhorn-instance-manager:test" + "v1")[:InstanceManagerSuffixChecksumLength])
+
+	names := map[string]struct{}{name: {}}
+	for index := 1; index < 3; index++ {
+		name, err := GetInstanceManagerName(longhorn.InstanceManagerTypeAllInOne, "node-1", "longhornio/longhorn-instance-manager:test", "v1", index)
+		c.Assert(err, IsNil)
+		c.Assert(strings.HasPrefix(name, instanceManagerPrefix), Equals, true)
+		names[name] = struct{}{}
+	}
+	c.Assert(names, HasLen, 3)
+}
+
+func (s *TestSuite) TestValidateBackingImageDownloadParameters(c *C) {
+	type testCase struct {
+		parameters  map[string]string
+		expectError bool
+	}
+	testCases := map[string]testCase{
+		"valid HTTPS URL": {
+			parameters: map[string]string{longhorn.DataSourceTypeDownloadParameterURL: "https://example.com/image.qcow2"},
+		},
+		"valid HTTP URL": {
+			parameters: map[string]string{longhorn.DataSourceTypeDownloadParameterURL: "http://images.default.svc:8000/image.raw"},
+		},
+		"missing URL": {
+			parameters:  map[string]string{},
+			expectError: true,
+		},
+		"malformed URL": {
+			parameters:  map[string]string{longhorn.DataSourceTypeDownloadParameterURL: "https://example.com/%zz"},
+			expectError: true,
+		},
+		"URL without scheme": {
+			parameters:  map[string]string{longhorn.DataSourceTypeDownloadParameterURL: "example.com/image.qcow2"},
+			expectError: true,
+		},
+		"URL without host": {
+			parameters:  map[string]string{longhorn.DataSourceTypeDownloadParameterURL: "https:///image.qcow2"},
+			expectError: true,
+		},
+		"unsupported scheme": {
+			parameters:  map[string]string{longhorn.DataSourceTypeDownloadParameterURL: "ftp://example.com/image.qcow2"},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		err := ValidateBackingImageDownloadParameters(tc.parameters)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalPodDNSConfig(c *C) {
+	ndots := "2"
+
+	type testCase struct {
+		setting        string
+		expectedPolicy corev1.DNSPolicy
+		expectedConfig *corev1.PodDNSConfig
+		expectError    bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting: " ",
+		},
+		"policy only": {
+			setting:        "policy:Default",
+			expectedPolicy: corev1.DNSDefault,
+		},
+		"custom nameservers": {
+			setting:        "policy:None; nameservers:10.0.0.10, fd00::10; searches:corp.example.com,example.com; options:ndots=2,edns0",
+			expectedPolicy: corev1.DNSNone,
+			expectedConfig: &corev1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.10", "fd00::10"},
+				Searches:    []string{"corp.example.com", "example.com"},
+				Options: []corev1.PodDNSConfigOption{
+					{Name: "ndots", Value: &ndots},
+					{Name: "edns0"},
+				},
+			},
+		},
+		"search domains appended to the cluster DNS": {
+			setting: "searches:corp.example.com",
+			expectedConfig: &corev1.PodDNSConfig{
+				Searches: []string{"corp.example.com"},
+			},
+		},
+		"unsupported policy": {
+			setting:     "policy:Custom",
+			expectError: true,
+		},
+		"policy none without nameservers": {
+			setting:     "policy:None; searches:corp.example.com",
+			expectError: true,
+		},
+		"invalid nameserver": {
+			setting:     "nameservers:dns.example.com",
+			expectError: true,
+		},
+		"too many nameservers": {
+			setting:     "nameservers:10.0.0.10,10.0.0.11,10.0.0.12,10.0.0.13",
+			expectError: true,
+		},
+		"option without name": {
+			setting:     "options:=2",
+			expectError: true,
+		},
+		"unsupported key": {
+			setting:     "domain:example.com",
+			expectError: true,
+		},
+		"missing value": {
+			setting:     "searches",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		policy, config, err := UnmarshalPodDNSConfig(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(policy, Equals, tc.expectedPolicy, Commentf(TestErrResultFmt, name))
+		c.Assert(reflect.DeepEqual(config, tc.expectedConfig), Equals, true, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalPodCapabilities(c *C) {
+	type testCase struct {
+		setting              string
+		expectedCapabilities []corev1.Capability
+		expectError          bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting: " ",
+		},
+		"capabilities": {
+			setting:              "SYS_ADMIN, MKNOD,SYS_ADMIN",
+			expectedCapabilities: []corev1.Capability{"SYS_ADMIN", "MKNOD"},
+		},
+		"lowercase capability": {
+			setting:     "sys_admin",
+			expectError: true,
+		},
+		"prefixed capability": {
+			setting:     "CAP_SYS_ADMIN",
+			expectError: true,
+		},
+		"empty capability": {
+			setting:     "SYS_ADMIN,,MKNOD",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		capabilities, err := UnmarshalPodCapabilities(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(capabilities, DeepEquals, tc.expectedCapabilities, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalInstanceManagerExtraArgs(c *C) {
+	type testCase struct {
+		setting      string
+		expectedArgs []string
+		expectError  bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting: " ",
+		},
+		"args": {
+			setting:      " --log-format json  --verbose",
+			expectedArgs: []string{"--log-format", "json", "--verbose"},
+		},
+		"listen": {
+			setting:     "--verbose --listen 0.0.0.0:9500",
+			expectError: true,
+		},
+		"listen with value": {
+			setting:     "--listen=0.0.0.0:9500",
+			expectError: true,
+		},
+		"listen with single dash": {
+			setting:     "-listen 0.0.0.0:9500",
+			expectError: true,
+		},
+		"listen as value": {
+			setting:      "--label listen",
+			expectedArgs: []string{"--label", "listen"},
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		args, err := UnmarshalInstanceManagerExtraArgs(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(args, DeepEquals, tc.expectedArgs, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalInstanceManagerPodHostMountPath(c *C) {
+	type testCase struct {
+		setting      string
+		expectedPath string
+		expectError  bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting:      " ",
+			expectedPath: "/",
+		},
+		"root": {
+			setting:      "/",
+			expectedPath: "/",
+		},
+		"data directory": {
+			setting:      " /var/lib/longhorn/ ",
+			expectedPath: "/var/lib/longhorn",
+		},
+		"relative path": {
+			setting:     "var/lib/longhorn",
+			expectError: true,
+		},
+		"proc directory": {
+			setting:     "/proc",
+			expectError: true,
+		},
+		"within dev directory": {
+			setting:     "/dev/disk/",
+			expectError: true,
+		},
+		"sibling of dev directory": {
+			setting:      "/devices",
+			expectedPath: "/devices",
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		path, err := UnmarshalInstanceManagerPodHostMountPath(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(path, Equals, tc.expectedPath, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalInstanceManagerImageNodeOverrides(c *C) {
+	type testCase struct {
+		setting           string
+		expectedOverrides []InstanceManagerImageNodeOverride
+		expectError       bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting: " ",
+		},
+		"overrides": {
+			setting: "kubernetes.io/arch=arm64:example.com/instance-manager:v1.6.0-arm64; example.com/pool = legacy : example.com/instance-manager:v1.5.0;",
+			expectedOverrides: []InstanceManagerImageNodeOverride{
+				{LabelKey: "kubernetes.io/arch", LabelValue: "arm64", Image: "example.com/instance-manager:v1.6.0-arm64"},
+				{LabelKey: "example.com/pool", LabelValue: "legacy", Image: "example.com/instance-manager:v1.5.0"},
+			},
+		},
+		"empty label value": {
+			setting: "example.com/pool=:instance-manager",
+			expectedOverrides: []InstanceManagerImageNodeOverride{
+				{LabelKey: "example.com/pool", LabelValue: "", Image: "instance-manager"},
+			},
+		},
+		"missing image": {
+			setting:     "kubernetes.io/arch=arm64",
+			expectError: true,
+		},
+		"empty image": {
+			setting:     "kubernetes.io/arch=arm64: ",
+			expectError: true,
+		},
+		"missing label value": {
+			setting:     "kubernetes.io/arch:example.com/instance-manager:v1.6.0-arm64",
+			expectError: true,
+		},
+		"empty label key": {
+			setting:     "=arm64:example.com/instance-manager:v1.6.0-arm64",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		overrides, err := UnmarshalInstanceManagerImageNodeOverrides(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(overrides, DeepEquals, tc.expectedOverrides, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalPodTopologySpreadConstraints(c *C) {
+	type testCase struct {
+		setting             string
+		expectedConstraints []corev1.TopologySpreadConstraint
+		expectError         bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting: " ",
+		},
+		"constraints": {
+			setting: "topology.kubernetes.io/zone:1:ScheduleAnyway; kubernetes.io/hostname: 2 :DoNotSchedule",
+			expectedConstraints: []corev1.TopologySpreadConstraint{
+				{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: corev1.ScheduleAnyway},
+				{TopologyKey: "kubernetes.io/hostname", MaxSkew: 2, WhenUnsatisfiable: corev1.DoNotSchedule},
+			},
+		},
+		"missing field": {
+			setting:     "topology.kubernetes.io/zone:1",
+			expectError: true,
+		},
+		"invalid topology key": {
+			setting:     "invalid key:1:ScheduleAnyway",
+			expectError: true,
+		},
+		"duplicate topology key": {
+			setting:     "kubernetes.io/hostname:1:ScheduleAnyway;kubernetes.io/hostname:2:DoNotSchedule",
+			expectError: true,
+		},
+		"invalid max skew": {
+			setting:     "kubernetes.io/hostname:0:ScheduleAnyway",
+			expectError: true,
+		},
+		"unsupported whenUnsatisfiable": {
+			setting:     "kubernetes.io/hostname:1:Never",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		constraints, err := UnmarshalPodTopologySpreadConstraints(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(constraints, DeepEquals, tc.expectedConstraints, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalProbeSetting(c *C) {
+	type testCase struct {
+		setting       string
+		expectedProbe map[string]int32
+		expectError   bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting:       "",
+			expectedProbe: map[string]int32{},
+		},
+		"valid": {
+			setting: "initial-delay-seconds:30; failure-threshold:60",
+			expectedProbe: map[string]int32{
+				ProbeSettingKeyInitialDelaySeconds: 30,
+				ProbeSettingKeyFailureThreshold:    60,
+			},
+		},
+		"unsupported key": {
+			setting:     "success-threshold:1",
+			expectError: true,
+		},
+		"invalid value": {
+			setting:     "failure-threshold:abc",
+			expectError: true,
+		},
+		"non-positive value": {
+			setting:     "period-seconds:0",
+			expectError: true,
+		},
+		"missing separator": {
+			setting:     "failure-threshold=60",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		probe, err := UnmarshalProbeSetting(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(reflect.DeepEqual(probe, tc.expectedProbe), Equals, true, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalPodMetadata(c *C) {
+	type testCase struct {
+		setting          string
+		isAnnotation     bool
+		expectedMetadata map[string]string
+		expectError      bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting:          "",
+			expectedMetadata: map[string]string{},
+		},
+		"valid labels": {
+			setting: "cost-center:storage; example.com/team:infra",
+			expectedMetadata: map[string]string{
+				"cost-center":      "storage",
+				"example.com/team": "infra",
+			},
+		},
+		"annotation value with colons": {
+			setting:      "example.com/dashboard:https://dashboard.example.com:8443",
+			isAnnotation: true,
+			expectedMetadata: map[string]string{
+				"example.com/dashboard": "https://dashboard.example.com:8443",
+			},
+		},
+		"invalid label value": {
+			setting:     "example.com/dashboard:https://dashboard.example.com",
+			expectError: true,
+		},
+		"invalid key": {
+			setting:     "cost center:storage",
+			expectError: true,
+		},
+		"reserved key": {
+			setting:     "longhorn.io/component:instance-manager",
+			expectError: true,
+		},
+		"reserved instance manager index key": {
+			setting:     GetLonghornLabelKey(LonghornLabelInstanceManagerIndex) + ":1",
+			expectError: true,
+		},
+		"reserved subdomain key": {
+			setting:      "node.longhorn.io/create-default-disk:true",
+			isAnnotation: true,
+			expectError:  true,
+		},
+		"missing separator": {
+			setting:     "cost-center=storage",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		unmarshal := UnmarshalPodLabels
+		settingName := SettingNameInstanceManagerPodLabels
+		if tc.isAnnotation {
+			unmarshal = UnmarshalPodAnnotations
+			settingName = SettingNameInstanceManagerPodAnnotations
+		}
+		metadata, err := unmarshal(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			c.Assert(ValidateSetting(string(settingName), tc.setting), NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(reflect.DeepEqual(metadata, tc.expectedMetadata), Equals, true, Commentf(TestErrResultFmt, name))
+	}
+}
+
+func (s *TestSuite) TestUnmarshalHostPathMounts(c *C) {
+	type testCase struct {
+		setting        string
+		expectedMounts []HostPathMount
+		expectError    bool
+	}
+	testCases := map[string]testCase{
+		"empty": {
+			setting:        " ",
+			expectedMounts: []HostPathMount{},
+		},
+		"valid mounts": {
+			setting: "/opt/devices:/host/opt/devices; /var/run/custom/:/var/run/custom",
+			expectedMounts: []HostPathMount{
+				{HostPath: "/opt/devices", MountPath: "/host/opt/devices"},
+				{HostPath: "/var/run/custom", MountPath: "/var/run/custom"},
+			},
+		},
+		"relative host path": {
+			setting:     "opt/devices:/host/opt/devices",
+			expectError: true,
+		},
+		"relative mount path": {
+			setting:     "/opt/devices:host/opt/devices",
+			expectError: true,
+		},
+		"missing mount path": {
+			setting:     "/opt/devices",
+			expectError: true,
+		},
+		"duplicate mount paths": {
+			setting:     "/opt/devices:/host/opt/devices; /opt/other-devices:/host/opt/devices/",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		fmt.Printf("testing %v\n", name)
+
+		mounts, err := UnmarshalHostPathMounts(tc.setting)
+		if tc.expectError {
+			c.Assert(err, NotNil, Commentf(TestErrResultFmt, name))
+			continue
+		}
+		c.Assert(err, IsNil, Commentf(TestErrErrorFmt, name, err))
+		c.Assert(reflect.DeepEqual(mounts, tc.expectedMounts), Equals, true, Commentf(TestErrResultFmt, name))
+	}
+}
@@ -58,8 +58,8 @@ func (b *backingImageValidator) Create(request *admission.Request, newObj runtim
 
 	switch longhorn.BackingImageDataSourceType(backingImage.Spec.SourceType) {
 	case longhorn.BackingImageDataSourceTypeDownload:
-		if backingImage.Spec.SourceParameters[longhorn.DataSourceTypeDownloadParameterURL] == "" {
-			return werror.NewInvalidError(fmt.Sprintf("invalid parameter %+v for source type %v", backingImage.Spec.SourceParameters, backingImage.Spec.SourceType), "")
+		if err := types.ValidateBackingImageDownloadParameters(backingImage.Spec.SourceParameters); err != nil {
+			return werror.NewInvalidError(err.Error(), "")
 		}
 	case longhorn.BackingImageDataSourceTypeUpload:
 	case longhorn.BackingImageDataSourceTypeExportFromVolume:
@@ -82,6 +82,15 @@ func (b *backingImageValidator) Create(request *admission.Request, newObj runtim
 		if ei.Status.CLIAPIVersion < engineapi.CLIVersionFive {
 			return werror.NewInvalidError(fmt.Sprintf("engine image %v CLI version %v doesn't support this feature, please upgrade engine for volume %v before exporting backing image from the volume", eiName, ei.Status.CLIAPIVersion, volumeName), "")
 		}
+		if snapshotName := backingImage.Spec.SourceParameters[longhorn.DataSourceTypeExportFromVolumeParameterSnapshotName]; snapshotName != "" {
+			snapshot, err := b.ds.GetSnapshotRO(snapshotName)
+			if err != nil {
+				return werror.NewInvalidError(fmt.Sprintf("failed to get snapshot %v of volume %v before exporting backing image", snapshotName, volumeName), "")
+			}
+			if snapshot.Spec.Volume != volumeName {
+				return werror.NewInvalidError(fmt.Sprintf("snapshot %v does not belong to volume %v", snapshotName, volumeName), "")
+			}
+		}
 
 		if backingImage.Spec.SourceParameters[manager.DataSourceTypeExportFromVolumeParameterExportType] != manager.DataSourceTypeExportFromVolumeParameterExportTypeRAW &&
 			backingImage.Spec.SourceParameters[manager.DataSourceTypeExportFromVolumeParameterExportType] != manager.DataSourceTypeExportFromVolumeParameterExportTypeQCOW2 {
@@ -3,11 +3,14 @@ package instancemanager
 import (
 	"fmt"
 
+	"github.com/pkg/errors"
+
 	"k8s.io/apimachinery/pkg/runtime"
 
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 
 	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
 	"github.com/longhorn/longhorn-manager/webhook/admission"
 
 	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
@@ -33,6 +36,7 @@ func (i *instanceManagerValidator) Resource() admission.Resource {
 		OperationTypes: []admissionregv1.OperationType{
 			admissionregv1.Create,
 			admissionregv1.Update,
+			admissionregv1.Delete,
 		},
 	}
 }
@@ -62,6 +66,46 @@ func (i *instanceManagerValidator) Update(request *admission.Request, oldObj run
 	return nil
 }
 
+func (i *instanceManagerValidator) Delete(request *admission.Request, oldObj runtime.Object) error {
+	im, ok := oldObj.(*longhorn.InstanceManager)
+	if !ok {
+		return werror.NewInvalidError(fmt.Sprintf("%v is not a *longhorn.InstanceManager", oldObj), "")
+	}
+
+	if err := i.validateInstanceManagerDeletion(im); err != nil {
+		return werror.NewInvalidError(err.Error(), "")
+	}
+
+	return nil
+}
+
+// validateInstanceManagerDeletion rejects the deletion of the running instance manager with the running instances,
+// which would be killed along with the pod. The instance manager annotated with the force deletion or on the node
+// that is down or deleted can always be deleted.
+func (i *instanceManagerValidator) validateInstanceManagerDeletion(im *longhorn.InstanceManager) error {
+	if im.Annotations[types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix)] == "true" {
+		return nil
+	}
+
+	runningInstances := types.GetRunningInstanceNames(im)
+	if len(runningInstances) == 0 {
+		return nil
+	}
+
+	if im.Spec.NodeID != "" {
+		isDown, err := i.ds.IsNodeDownOrDeleted(im.Spec.NodeID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if node %v is down or deleted before deleting instance manager %v", im.Spec.NodeID, im.Name)
+		}
+		if isDown {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot delete instance manager %v with the running instances %v, unless it is annotated with %v: \"true\"",
+		im.Name, runningInstances, types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix))
+}
+
 func validate(im *longhorn.InstanceManager) error {
 	if im.Labels == nil {
 		return fmt.Errorf("labels for instanceManager %s is not set", im.Name)
@@ -75,9 +119,26 @@ func validate(im *longhorn.InstanceManager) error {
 		return fmt.Errorf("type for instanceManager %s is not set", im.Name)
 	}
 
+	switch im.Spec.Type {
+	case longhorn.InstanceManagerTypeAllInOne, longhorn.InstanceManagerTypeEngine, longhorn.InstanceManagerTypeReplica:
+	default:
+		return fmt.Errorf("type %v for instanceManager %s is invalid", im.Spec.Type, im.Name)
+	}
+
 	if im.Spec.DataEngine == "" {
 		return fmt.Errorf("data engine for instanceManager %s is not set", im.Name)
 	}
 
+	switch im.Spec.DesiredState {
+	case "", longhorn.InstanceManagerStateRunning, longhorn.InstanceManagerStateStopped:
+	default:
+		return fmt.Errorf("desired state %v for instanceManager %s is invalid", im.Spec.DesiredState, im.Name)
+	}
+
+	// The instance manager services occupy the port and the subsequent 4 ports.
+	if im.Spec.Port < 0 || im.Spec.Port+4 > 65535 {
+		return fmt.Errorf("port %v for instanceManager %s is invalid", im.Spec.Port, im.Name)
+	}
+
 	return nil
 }
@@ -0,0 +1,229 @@
+package instancemanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/longhorn/longhorn-manager/datastore"
+	"github.com/longhorn/longhorn-manager/types"
+	"github.com/longhorn/longhorn-manager/util"
+
+	longhorn "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta2"
+	lhfake "github.com/longhorn/longhorn-manager/k8s/pkg/client/clientset/versioned/fake"
+)
+
+func TestValidateType(t *testing.T) {
+	assert := assert.New(t)
+
+	newInstanceManager := func(imType longhorn.InstanceManagerType) *longhorn.InstanceManager {
+		return &longhorn.InstanceManager{
+			ObjectMeta: v1.ObjectMeta{
+				Name:            "instance-manager",
+				Labels:          map[string]string{},
+				OwnerReferences: []v1.OwnerReference{},
+			},
+			Spec: longhorn.InstanceManagerSpec{
+				Type:       imType,
+				DataEngine: longhorn.DataEngineTypeV1,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		imType  longhorn.InstanceManagerType
+		wantErr bool
+	}{
+		"allInOne": {
+			imType:  longhorn.InstanceManagerTypeAllInOne,
+			wantErr: false,
+		},
+		"engine": {
+			imType:  longhorn.InstanceManagerTypeEngine,
+			wantErr: false,
+		},
+		"replica": {
+			imType:  longhorn.InstanceManagerTypeReplica,
+			wantErr: false,
+		},
+		"empty": {
+			imType:  "",
+			wantErr: true,
+		},
+		"invalid": {
+			imType:  "invalid",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		err := validate(newInstanceManager(tc.imType))
+		if tc.wantErr {
+			assert.Error(err, name)
+		} else {
+			assert.NoError(err, name)
+		}
+	}
+}
+
+func TestValidateDesiredState(t *testing.T) {
+	assert := assert.New(t)
+
+	newInstanceManager := func(desiredState longhorn.InstanceManagerState) *longhorn.InstanceManager {
+		return &longhorn.InstanceManager{
+			ObjectMeta: v1.ObjectMeta{
+				Name:            "instance-manager",
+				Labels:          map[string]string{},
+				OwnerReferences: []v1.OwnerReference{},
+			},
+			Spec: longhorn.InstanceManagerSpec{
+				Type:         longhorn.InstanceManagerTypeAllInOne,
+				DataEngine:   longhorn.DataEngineTypeV1,
+				DesiredState: desiredState,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		desiredState longhorn.InstanceManagerState
+		wantErr      bool
+	}{
+		"empty": {
+			desiredState: "",
+			wantErr:      false,
+		},
+		"running": {
+			desiredState: longhorn.InstanceManagerStateRunning,
+			wantErr:      false,
+		},
+		"stopped": {
+			desiredState: longhorn.InstanceManagerStateStopped,
+			wantErr:      false,
+		},
+		"starting": {
+			desiredState: longhorn.InstanceManagerStateStarting,
+			wantErr:      true,
+		},
+		"invalid": {
+			desiredState: "invalid",
+			wantErr:      true,
+		},
+	}
+
+	for name, tc := range tests {
+		err := validate(newInstanceManager(tc.desiredState))
+		if tc.wantErr {
+			assert.Error(err, name)
+		} else {
+			assert.NoError(err, name)
+		}
+	}
+}
+
+func TestValidateInstanceManagerDeletion(t *testing.T) {
+	assert := assert.New(t)
+
+	const namespace = "longhorn-system"
+	kubeClient := fake.NewSimpleClientset()
+	lhClient := lhfake.NewSimpleClientset()
+	informerFactories := util.NewInformerFactories(namespace, kubeClient, lhClient, 0)
+	ds := datastore.NewDataStore(namespace, lhClient, kubeClient, apiextensionsfake.NewSimpleClientset(), informerFactories)
+	nodeIndexer := informerFactories.LhInformerFactory.Longhorn().V1beta2().Nodes().Informer().GetIndexer()
+	for nodeName, readyCondition := range map[string]longhorn.Condition{
+		"ready-node": {Type: longhorn.NodeConditionTypeReady, Status: longhorn.ConditionStatusTrue},
+		"down-node": {
+			Type:   longhorn.NodeConditionTypeReady,
+			Status: longhorn.ConditionStatusFalse,
+			Reason: string(longhorn.NodeConditionReasonKubernetesNodeNotReady),
+		},
+	} {
+		node := &longhorn.Node{
+			ObjectMeta: v1.ObjectMeta{Name: nodeName, Namespace: namespace},
+			Status:     longhorn.NodeStatus{Conditions: []longhorn.Condition{readyCondition}},
+		}
+		assert.NoError(nodeIndexer.Add(node))
+	}
+	validator := &instanceManagerValidator{ds: ds}
+
+	newInstanceManager := func(nodeID string, state longhorn.InstanceManagerState, instanceState longhorn.InstanceState, force bool) *longhorn.InstanceManager {
+		im := &longhorn.InstanceManager{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "instance-manager",
+				Namespace: namespace,
+			},
+			Spec: longhorn.InstanceManagerSpec{
+				NodeID: nodeID,
+				Type:   longhorn.InstanceManagerTypeAllInOne,
+			},
+			Status: longhorn.InstanceManagerStatus{
+				CurrentState: state,
+			},
+		}
+		if instanceState != "" {
+			im.Status.InstanceEngines = map[string]longhorn.InstanceProcess{
+				"volume-e-0": {Status: longhorn.InstanceProcessStatus{State: instanceState}},
+			}
+			im.Status.InstanceReplicas = map[string]longhorn.InstanceProcess{
+				"volume-r-0": {Status: longhorn.InstanceProcessStatus{State: longhorn.InstanceStateStopped}},
+			}
+		}
+		if force {
+			im.Annotations = map[string]string{
+				types.GetLonghornLabelKey(types.InstanceManagerForceDeletionAnnotationKeySuffix): "true",
+			}
+		}
+		return im
+	}
+
+	tests := map[string]struct {
+		im      *longhorn.InstanceManager
+		wantErr bool
+	}{
+		"running instances": {
+			im:      newInstanceManager("ready-node", longhorn.InstanceManagerStateRunning, longhorn.InstanceStateRunning, false),
+			wantErr: true,
+		},
+		"starting instances": {
+			im:      newInstanceManager("ready-node", longhorn.InstanceManagerStateRunning, longhorn.InstanceStateStarting, false),
+			wantErr: true,
+		},
+		"no instance": {
+			im:      newInstanceManager("ready-node", longhorn.InstanceManagerStateRunning, "", false),
+			wantErr: false,
+		},
+		"stopped instances": {
+			im:      newInstanceManager("ready-node", longhorn.InstanceManagerStateRunning, longhorn.InstanceStateStopped, false),
+			wantErr: false,
+		},
+		"force deletion annotation": {
+			im:      newInstanceManager("ready-node", longhorn.InstanceManagerStateRunning, longhorn.InstanceStateRunning, true),
+			wantErr: false,
+		},
+		"instance manager in error state": {
+			im:      newInstanceManager("ready-node", longhorn.InstanceManagerStateError, longhorn.InstanceStateRunning, false),
+			wantErr: false,
+		},
+		"node down": {
+			im:      newInstanceManager("down-node", longhorn.InstanceManagerStateRunning, longhorn.InstanceStateRunning, false),
+			wantErr: false,
+		},
+		"node deleted": {
+			im:      newInstanceManager("deleted-node", longhorn.InstanceManagerStateRunning, longhorn.InstanceStateRunning, false),
+			wantErr: false,
+		},
+	}
+
+	for name, tc := range tests {
+		err := validator.Delete(nil, tc.im)
+		if tc.wantErr {
+			assert.Error(err, name)
+			assert.Contains(err.Error(), "volume-e-0", name)
+		} else {
+			assert.NoError(err, name)
+		}
+	}
+}